@@ -6,14 +6,22 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"runtime"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/joho/godotenv"
+	"github.com/kevo-1/model-nexus/internal/backend"
+	"github.com/kevo-1/model-nexus/internal/encryption"
 	httpHandler "github.com/kevo-1/model-nexus/internal/handler/http"
 	"github.com/kevo-1/model-nexus/internal/logger"
 	"github.com/kevo-1/model-nexus/internal/repository"
+	"github.com/kevo-1/model-nexus/internal/service"
+	"github.com/kevo-1/model-nexus/internal/signing"
+	"github.com/kevo-1/model-nexus/internal/storage"
 	ort "github.com/yalue/onnxruntime_go"
 )
 
@@ -54,14 +62,122 @@ func main() {
 		}
 	}()
 
-	// Step 1: Create model registry
+	// Step 1: Create model and pipeline registries
 	registry := repository.NewModelRegistry()
+	pipelines := repository.NewPipelineRegistry()
 
-	// Step 2: Create HTTP handler (models can be uploaded dynamically via POST /models/upload)
-	handler := httpHandler.NewHandler(registry, "models")
+	// Step 2: Register model backends; upload detects the owning one per artifact
+	backends := backend.NewRegistry()
+	if err := backends.Register(backend.NewONNXBackend()); err != nil {
+		logger.Error("failed to register onnx backend", "error", err)
+		os.Exit(1)
+	}
+
+	// Step 3: Open artifact storage; sessions load from a local cache of it
+	store, err := newStorage()
+	if err != nil {
+		logger.Error("failed to open artifact storage", "error", err)
+		os.Exit(1)
+	}
+	modelsDir := os.Getenv("MODELS_DIR")
+	if modelsDir == "" {
+		modelsDir = "models"
+	}
+	cache, err := storage.NewCache(store, modelsDir)
+	if err != nil {
+		logger.Error("failed to create model cache", "dir", modelsDir, "error", err)
+		os.Exit(1)
+	}
+	logger.Info("artifact storage ready", "backend", storageBackend(), "cache_dir", cache.Dir())
+
+	// Step 4: Create HTTP handler (models can be uploaded dynamically via POST /models/upload)
+	handler := httpHandler.NewHandler(registry, pipelines, backends, cache)
 	routes := handler.SetupRoutes()
 
-	// Step 3: Setup HTTP server
+	// Server-side sources for POST /models/register; none are allowed by default
+	sources := service.SourceConfig{
+		Roots: splitList(os.Getenv("MODEL_SOURCE_ROOTS")),
+		Hosts: splitList(os.Getenv("MODEL_SOURCE_HOSTS")),
+	}
+	if err := handler.AllowModelSources(sources); err != nil {
+		logger.Error("invalid model sources", "error", err)
+		os.Exit(1)
+	}
+	if len(sources.Roots) > 0 || len(sources.Hosts) > 0 {
+		logger.Info("model sources allowed", "roots", sources.Roots, "hosts", sources.Hosts)
+	}
+
+	// Signature policy and trusted keys, checked before anything is served
+	signingConfig, err := newSigningConfig()
+	if err != nil {
+		logger.Error("invalid model signing configuration", "error", err)
+		os.Exit(1)
+	}
+	if err := handler.RequireSignatures(signingConfig); err != nil {
+		logger.Error("invalid model signing configuration", "error", err)
+		os.Exit(1)
+	}
+	logger.Info("model signature policy", "policy", signingConfig.Policy, "trusted_keys", signingConfig.Keys.IDs())
+
+	// Encryption of stored artifacts; off unless a key is configured
+	keys, err := newKeyring()
+	if err != nil {
+		logger.Error("invalid artifact encryption configuration", "error", err)
+		os.Exit(1)
+	}
+	if keys != nil {
+		handler.EncryptArtifacts(keys)
+		logger.Info("artifact encryption enabled", "key_id", keys.Current().ID(), "keys", keys.IDs())
+	}
+
+	// Uploads are staged in the model cache directory unless a staging
+	// directory is set; with encryption on, their plaintext is kept out of
+	// it by default
+	stagingDir := os.Getenv("STAGING_DIR")
+	if stagingDir == "" && keys != nil {
+		stagingDir = filepath.Join(os.TempDir(), "model-nexus-staging")
+	}
+	if stagingDir != "" {
+		if err := handler.StageUploadsIn(stagingDir); err != nil {
+			logger.Error("failed to create staging directory", "dir", stagingDir, "error", err)
+			os.Exit(1)
+		}
+		logger.Info("uploads staged outside the model cache", "dir", stagingDir)
+	}
+
+	// Admin routes are disabled unless a token is configured
+	if token := os.Getenv("ADMIN_TOKEN"); token != "" {
+		handler.RequireAdminToken(token)
+		logger.Info("admin endpoints enabled")
+	}
+
+	// Register the models recorded in storage, so they survive restarts
+	// and moves to another host
+	loaded, err := handler.LoadModels(context.Background())
+	if err != nil {
+		logger.Error("failed to load stored models", "error", err)
+		os.Exit(1)
+	}
+	logger.Info("stored models loaded", "models", loaded)
+
+	// Re-hash stored artifacts periodically; corrupted models stop being served
+	integrityInterval := time.Hour
+	if v := os.Getenv("INTEGRITY_CHECK_INTERVAL"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			logger.Error("invalid INTEGRITY_CHECK_INTERVAL", "value", v, "error", err)
+			os.Exit(1)
+		}
+		integrityInterval = d
+	}
+	integrityCtx, stopIntegrity := context.WithCancel(context.Background())
+	defer stopIntegrity()
+	if integrityInterval > 0 {
+		handler.StartIntegrityChecks(integrityCtx, integrityInterval)
+		logger.Info("artifact integrity checks enabled", "interval", integrityInterval)
+	}
+
+	// Step 5: Setup HTTP server
 
 	port := os.Getenv("PORT")
 	if port == "" {
@@ -78,7 +194,7 @@ func main() {
 		"metrics", fmt.Sprintf("GET http://localhost:%s/metrics", port),
 	)
 
-	// Step 4: Graceful shutdown
+	// Step 6: Graceful shutdown
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, os.Interrupt)
 	// SIGTERM is not available on Windows; only os.Interrupt is cross-platform
@@ -110,3 +226,127 @@ func main() {
 
 	logger.Info("Server stopped gracefully")
 }
+
+func storageBackend() string {
+	if v := os.Getenv("STORAGE_BACKEND"); v != "" {
+		return v
+	}
+	return "local"
+}
+
+// newStorage opens the artifact store selected by STORAGE_BACKEND: the
+// MODELS_DIR directory, or an S3-compatible bucket configured by the S3_*
+// variables, with the standard AWS_* credentials as a fallback.
+func newStorage() (storage.Storage, error) {
+	switch backend := storageBackend(); backend {
+	case "local":
+		dir := os.Getenv("MODELS_DIR")
+		if dir == "" {
+			dir = "models"
+		}
+		return storage.NewLocal(dir)
+	case "s3":
+		pathStyle := false
+		if v := os.Getenv("S3_PATH_STYLE"); v != "" {
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return nil, fmt.Errorf("invalid S3_PATH_STYLE %q: %w", v, err)
+			}
+			pathStyle = b
+		}
+		return storage.NewS3(storage.S3Config{
+			Endpoint:        os.Getenv("S3_ENDPOINT"),
+			Region:          envOr("S3_REGION", "AWS_REGION"),
+			Bucket:          os.Getenv("S3_BUCKET"),
+			Prefix:          os.Getenv("S3_PREFIX"),
+			PathStyle:       pathStyle,
+			AccessKeyID:     envOr("S3_ACCESS_KEY_ID", "AWS_ACCESS_KEY_ID"),
+			SecretAccessKey: envOr("S3_SECRET_ACCESS_KEY", "AWS_SECRET_ACCESS_KEY"),
+			SessionToken:    envOr("S3_SESSION_TOKEN", "AWS_SESSION_TOKEN"),
+		})
+	default:
+		return nil, fmt.Errorf("unknown STORAGE_BACKEND %q: use local or s3", backend)
+	}
+}
+
+// envOr returns the first of the named variables that is set.
+func envOr(names ...string) string {
+	for _, name := range names {
+		if v := os.Getenv(name); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// newSigningConfig reads MODEL_SIGNATURE_POLICY (off, warn or enforce) and
+// the keyring file named by MODEL_TRUSTED_KEYS.
+func newSigningConfig() (service.SigningConfig, error) {
+	policy, err := signing.ParsePolicy(os.Getenv("MODEL_SIGNATURE_POLICY"))
+	if err != nil {
+		return service.SigningConfig{}, err
+	}
+	cfg := service.SigningConfig{Policy: policy}
+	if path := os.Getenv("MODEL_TRUSTED_KEYS"); path != "" {
+		keys, err := signing.LoadKeyring(path)
+		if err != nil {
+			return service.SigningConfig{}, fmt.Errorf("failed to load MODEL_TRUSTED_KEYS: %w", err)
+		}
+		cfg.Keys = keys
+	}
+	return cfg, nil
+}
+
+// newKeyring reads the artifact encryption key from ARTIFACT_KEY or the file
+// named by ARTIFACT_KEY_FILE, and the keys it replaces from
+// ARTIFACT_PREVIOUS_KEYS or ARTIFACT_PREVIOUS_KEY_FILES (comma-separated).
+// It returns nil when no key is configured.
+func newKeyring() (*encryption.Keyring, error) {
+	load := func(values, files string) ([]*encryption.Key, error) {
+		var keys []*encryption.Key
+		for _, v := range splitList(os.Getenv(values)) {
+			key, err := encryption.ParseKey(v)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s: %w", values, err)
+			}
+			keys = append(keys, key)
+		}
+		for _, path := range splitList(os.Getenv(files)) {
+			key, err := encryption.LoadKeyFile(path)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s %s: %w", files, path, err)
+			}
+			keys = append(keys, key)
+		}
+		return keys, nil
+	}
+
+	current, err := load("ARTIFACT_KEY", "ARTIFACT_KEY_FILE")
+	if err != nil {
+		return nil, err
+	}
+	previous, err := load("ARTIFACT_PREVIOUS_KEYS", "ARTIFACT_PREVIOUS_KEY_FILES")
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case len(current) > 1:
+		return nil, fmt.Errorf("set only one of ARTIFACT_KEY and ARTIFACT_KEY_FILE, with a single key")
+	case len(current) == 0 && len(previous) > 0:
+		return nil, fmt.Errorf("previous artifact keys are set without ARTIFACT_KEY or ARTIFACT_KEY_FILE")
+	case len(current) == 0:
+		return nil, nil
+	}
+	return encryption.NewKeyring(current[0], previous...), nil
+}
+
+// splitList splits a comma-separated variable, dropping empty entries.
+func splitList(v string) []string {
+	var items []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
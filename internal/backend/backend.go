@@ -0,0 +1,121 @@
+package backend
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/kevo-1/model-nexus/internal/domain"
+	"github.com/kevo-1/model-nexus/pkg/onnx"
+)
+
+// HeaderSize is the number of leading artifact bytes handed to Detect.
+const HeaderSize = 64
+
+// Backend is a model runtime that owns one artifact format. The service
+// layer consults a Registry of backends so that new runtimes can be added
+// without touching the upload path.
+type Backend interface {
+	// Format is the stable identifier recorded alongside stored artifacts.
+	Format() string
+	// Extension is the file extension used when storing the artifact.
+	Extension() string
+	// Detect reports whether the artifact belongs to this backend, based on
+	// the uploaded filename and the first HeaderSize bytes of its content.
+	Detect(filename string, header []byte) bool
+	// ExtractInfo reads input/output metadata from a stored artifact. The
+	// backend's own description of it goes in the info's Details.
+	ExtractInfo(path string) (*domain.ModelInfo, error)
+	// NewPredictor loads a stored artifact into a ready-to-serve predictor.
+	NewPredictor(id, name, version, path string) (domain.ModelPredictor, error)
+}
+
+// GraphInspector is implemented by backends that can describe the
+// computation graph of a stored artifact, read from a file or from memory.
+type GraphInspector interface {
+	ExtractGraph(path string) (*onnx.GraphSummary, error)
+	ExtractGraphData(data []byte) (*onnx.GraphSummary, error)
+}
+
+// MemoryLoader is implemented by backends that can load an artifact held in
+// memory, so artifacts encrypted at rest are served without writing their
+// plaintext to disk.
+type MemoryLoader interface {
+	// NewPredictorFromData is NewPredictor for the artifact data. info is
+	// the content of its sidecar, e.g. decrypted too; when nil, the
+	// sidecar is read from next to path.
+	NewPredictorFromData(id, name, version, path string, data, info []byte) (domain.ModelPredictor, error)
+}
+
+type Registry struct {
+	mu       sync.RWMutex
+	backends map[string]Backend
+	order    []string
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		backends: make(map[string]Backend),
+	}
+}
+
+// Register adds a backend. Backends are consulted by Detect in the order
+// they were registered.
+func (r *Registry) Register(b Backend) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.backends[b.Format()]; exists {
+		return fmt.Errorf("backend already registered for format %q", b.Format())
+	}
+
+	r.backends[b.Format()] = b
+	r.order = append(r.order, b.Format())
+	return nil
+}
+
+func (r *Registry) Get(format string) (Backend, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	b, ok := r.backends[format]
+	if !ok {
+		return nil, &domain.UnsupportedFormatError{Format: format}
+	}
+	return b, nil
+}
+
+// Detect picks the backend that owns an artifact. An exact extension match
+// wins over content sniffing so that formats with similar headers stay
+// unambiguous when the client names the file properly.
+func (r *Registry) Detect(filename string, header []byte) (Backend, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ext := strings.ToLower(filepath.Ext(filename))
+	if ext != "" {
+		for _, format := range r.order {
+			if b := r.backends[format]; b.Extension() == ext {
+				return b, nil
+			}
+		}
+	}
+
+	for _, format := range r.order {
+		if b := r.backends[format]; b.Detect(filename, header) {
+			return b, nil
+		}
+	}
+
+	return nil, &domain.UnsupportedFormatError{Filename: filename}
+}
+
+func (r *Registry) Formats() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	formats := make([]string, len(r.order))
+	copy(formats, r.order)
+	return formats
+}
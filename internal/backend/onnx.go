@@ -0,0 +1,80 @@
+package backend
+
+import (
+	"github.com/kevo-1/model-nexus/internal/domain"
+	"github.com/kevo-1/model-nexus/pkg/onnx"
+)
+
+// ONNXBackend serves .onnx artifacts through ONNX Runtime.
+type ONNXBackend struct{}
+
+func NewONNXBackend() *ONNXBackend {
+	return &ONNXBackend{}
+}
+
+func (b *ONNXBackend) Format() string {
+	return onnx.Format
+}
+
+func (b *ONNXBackend) Extension() string {
+	return ".onnx"
+}
+
+func (b *ONNXBackend) Detect(filename string, header []byte) bool {
+	return onnx.LooksLikeModel(header)
+}
+
+func (b *ONNXBackend) ExtractInfo(path string) (*domain.ModelInfo, error) {
+	info, err := onnx.ExtractModelInfo(path)
+	if err != nil {
+		return nil, err
+	}
+	info.Format = onnx.Format
+
+	out := &domain.ModelInfo{
+		Format:          onnx.Format,
+		Inputs:          tensorSpecs(info.Inputs),
+		Outputs:         tensorSpecs(info.Outputs),
+		ClassLabels:     info.ClassLabels,
+		FeatureNames:    info.FeatureNames,
+		Unbindable:      info.DtypeProblems(),
+		SupportingFiles: len(info.ExternalData),
+		Details:         info,
+	}
+	if info.Operators != nil && !info.Operators.Compatible() {
+		out.Incompatible = info.Operators.Problems()
+	}
+	for _, f := range info.Lint {
+		out.Lint = append(out.Lint, domain.LintFinding{
+			Severity: domain.LintSeverity(f.Severity),
+			Check:    f.Check,
+			Name:     f.Name,
+			Message:  f.Message,
+		})
+	}
+	return out, nil
+}
+
+func tensorSpecs(tensors []onnx.TensorInfo) []domain.TensorSpec {
+	specs := make([]domain.TensorSpec, len(tensors))
+	for i, t := range tensors {
+		specs[i] = domain.TensorSpec{Name: t.Name, Shape: t.Shape}
+	}
+	return specs
+}
+
+func (b *ONNXBackend) ExtractGraph(path string) (*onnx.GraphSummary, error) {
+	return onnx.ExtractGraph(path)
+}
+
+func (b *ONNXBackend) ExtractGraphData(data []byte) (*onnx.GraphSummary, error) {
+	return onnx.ExtractGraphData(data)
+}
+
+func (b *ONNXBackend) NewPredictor(id, name, version, path string) (domain.ModelPredictor, error) {
+	return onnx.NewONNXPredictor(id, name, version, path)
+}
+
+func (b *ONNXBackend) NewPredictorFromData(id, name, version, path string, data, info []byte) (domain.ModelPredictor, error) {
+	return onnx.NewONNXPredictorFromData(id, name, version, path, data, info)
+}
@@ -0,0 +1,316 @@
+// Package backup reads and writes registry backups: a zip archive holding
+// every stored model as a .mnx package, together with background datasets,
+// ensembles and pipelines.
+//
+// Layout:
+//
+//	backup.json          format version, models, ensembles and pipelines
+//	models/<id>.mnx      the package of each stored model (see the mnx package)
+//	backgrounds/<id>.json  the background dataset of a model, if any
+package backup
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/kevo-1/model-nexus/internal/domain"
+	"github.com/kevo-1/model-nexus/internal/ensemble"
+	"github.com/kevo-1/model-nexus/internal/mnx"
+	"github.com/kevo-1/model-nexus/internal/pipeline"
+)
+
+const (
+	FormatVersion = 1
+	ManifestFile  = "backup.json"
+
+	maxManifestSize   = 64 << 20 // 64 MB
+	maxBackgroundSize = 256 << 20
+)
+
+// Manifest describes the contents of a backup. Models are listed by id;
+// the registry has no aliases to record.
+type Manifest struct {
+	FormatVersion int       `json:"format_version"`
+	CreatedAt     time.Time `json:"created_at"`
+	Models        []Model   `json:"models"`
+	// Ensembles are listed after the ensembles they use as members.
+	Ensembles []ensemble.Config     `json:"ensembles,omitempty"`
+	Pipelines []pipeline.Definition `json:"pipelines,omitempty"`
+	// Skipped lists registered models that could not be backed up.
+	Skipped []Skipped `json:"skipped,omitempty"`
+}
+
+// Model is a stored model in a backup.
+type Model struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	Format  string `json:"format,omitempty"`
+	// Digest is the content digest the artifact is stored under.
+	Digest string `json:"digest"`
+	// Package is the archive entry of the model's .mnx package and
+	// PackageSHA256 the digest of that entry.
+	Package       string `json:"package"`
+	PackageSHA256 string `json:"package_sha256"`
+	// Background is the archive entry of the background dataset, if any.
+	Background string `json:"background,omitempty"`
+}
+
+// Skipped is a model left out of a backup.
+type Skipped struct {
+	ID     string `json:"id"`
+	Reason string `json:"reason"`
+}
+
+// Validate checks the manifest fields. Models and ensembles share one id
+// space, like they do in the registry.
+func (m *Manifest) Validate() error {
+	if m.FormatVersion != FormatVersion {
+		return invalid("format_version", fmt.Sprintf("unsupported format version %d, expected %d", m.FormatVersion, FormatVersion))
+	}
+
+	ids := make(map[string]bool)
+	seen := func(field, id string) error {
+		if err := domain.ValidateModelID(field, id); err != nil {
+			return err
+		}
+		if ids[id] {
+			return invalid(field, fmt.Sprintf("duplicate id %q", id))
+		}
+		ids[id] = true
+		return nil
+	}
+	for i, model := range m.Models {
+		field := fmt.Sprintf("models[%d]", i)
+		if err := seen(field+".id", model.ID); err != nil {
+			return err
+		}
+		if model.Package != packageEntry(model.ID) {
+			return invalid(field+".package", fmt.Sprintf("expected %s", packageEntry(model.ID)))
+		}
+		if model.Background != "" && model.Background != backgroundEntry(model.ID) {
+			return invalid(field+".background", fmt.Sprintf("expected %s", backgroundEntry(model.ID)))
+		}
+	}
+	for i, e := range m.Ensembles {
+		if err := seen(fmt.Sprintf("ensembles[%d].id", i), e.ID); err != nil {
+			return err
+		}
+		if err := e.Validate(); err != nil {
+			return err
+		}
+	}
+
+	pipelines := make(map[string]bool)
+	for i, p := range m.Pipelines {
+		if pipelines[p.ID] {
+			return invalid(fmt.Sprintf("pipelines[%d].id", i), fmt.Sprintf("duplicate id %q", p.ID))
+		}
+		pipelines[p.ID] = true
+		if err := p.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func packageEntry(id string) string {
+	return "models/" + id + mnx.Extension
+}
+
+func backgroundEntry(id string) string {
+	return "backgrounds/" + id + ".json"
+}
+
+// Writer writes a backup. Entries are streamed as they are added; the
+// manifest is written last, by Close.
+type Writer struct {
+	zw       *zip.Writer
+	manifest Manifest
+}
+
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{
+		zw:       zip.NewWriter(w),
+		manifest: Manifest{FormatVersion: FormatVersion, CreatedAt: time.Now().UTC(), Models: []Model{}},
+	}
+}
+
+// AddModel adds a stored model. Its package is written by pkg; background
+// is the JSON of its background dataset, or nil.
+func (w *Writer) AddModel(m Model, pkg func(io.Writer) error, background []byte) error {
+	m.Package = packageEntry(m.ID)
+	// Packages are zip archives already
+	f, err := w.zw.CreateHeader(&zip.FileHeader{Name: m.Package, Method: zip.Store, Modified: w.manifest.CreatedAt})
+	if err != nil {
+		return err
+	}
+	h := sha256.New()
+	if err := pkg(io.MultiWriter(f, h)); err != nil {
+		return err
+	}
+	m.PackageSHA256 = hex.EncodeToString(h.Sum(nil))
+
+	if background != nil {
+		m.Background = backgroundEntry(m.ID)
+		f, err := w.zw.Create(m.Background)
+		if err != nil {
+			return err
+		}
+		if _, err := f.Write(background); err != nil {
+			return err
+		}
+	}
+
+	w.manifest.Models = append(w.manifest.Models, m)
+	return nil
+}
+
+// AddEnsemble adds an ensemble; ensembles it uses must be added first.
+func (w *Writer) AddEnsemble(config ensemble.Config) {
+	w.manifest.Ensembles = append(w.manifest.Ensembles, config)
+}
+
+func (w *Writer) AddPipeline(def pipeline.Definition) {
+	w.manifest.Pipelines = append(w.manifest.Pipelines, def)
+}
+
+// Skip records a model that could not be backed up.
+func (w *Writer) Skip(id, reason string) {
+	w.manifest.Skipped = append(w.manifest.Skipped, Skipped{ID: id, Reason: reason})
+}
+
+// Manifest returns the manifest written so far.
+func (w *Writer) Manifest() *Manifest {
+	return &w.manifest
+}
+
+// Close writes the manifest and finishes the archive.
+func (w *Writer) Close() error {
+	data, err := json.MarshalIndent(&w.manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	f, err := w.zw.Create(ManifestFile)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		return err
+	}
+	return w.zw.Close()
+}
+
+// Archive is an opened backup.
+type Archive struct {
+	Manifest *Manifest
+
+	zr      *zip.ReadCloser
+	entries map[string]*zip.File
+}
+
+// Open opens and validates the backup at path.
+func Open(path string) (*Archive, error) {
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, invalid("file", fmt.Sprintf("not a backup archive: %v", err))
+	}
+	a := &Archive{zr: zr, entries: make(map[string]*zip.File, len(zr.File))}
+	for _, f := range zr.File {
+		a.entries[f.Name] = f
+	}
+
+	m, err := a.readManifest()
+	if err != nil {
+		zr.Close()
+		return nil, err
+	}
+	a.Manifest = m
+	return a, nil
+}
+
+func (a *Archive) readManifest() (*Manifest, error) {
+	f, ok := a.entries[ManifestFile]
+	if !ok {
+		return nil, invalid("file", fmt.Sprintf("backup has no %s", ManifestFile))
+	}
+	r, err := f.Open()
+	if err != nil {
+		return nil, invalid("file", fmt.Sprintf("failed to read %s: %v", ManifestFile, err))
+	}
+	defer r.Close()
+
+	var m Manifest
+	dec := json.NewDecoder(io.LimitReader(r, maxManifestSize))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&m); err != nil {
+		return nil, invalid("manifest", fmt.Sprintf("invalid %s: %v", ManifestFile, err))
+	}
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+	for _, model := range m.Models {
+		if _, ok := a.entries[model.Package]; !ok {
+			return nil, invalid("file", fmt.Sprintf("backup has no %s", model.Package))
+		}
+		if _, ok := a.entries[model.Background]; model.Background != "" && !ok {
+			return nil, invalid("file", fmt.Sprintf("backup has no %s", model.Background))
+		}
+	}
+	return &m, nil
+}
+
+// VerifyPackage checks the package of m against the digest recorded in the
+// manifest.
+func (a *Archive) VerifyPackage(m Model) error {
+	r, err := a.entries[m.Package].Open()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return fmt.Errorf("failed to read %s: %w", m.Package, err)
+	}
+	if sum := hex.EncodeToString(h.Sum(nil)); sum != m.PackageSHA256 {
+		return fmt.Errorf("%s has digest %s, expected %s", m.Package, sum, m.PackageSHA256)
+	}
+	return nil
+}
+
+// OpenPackage returns the .mnx package of m.
+func (a *Archive) OpenPackage(m Model) (io.ReadCloser, error) {
+	return a.entries[m.Package].Open()
+}
+
+// Background returns the background dataset rows of m, or nil.
+func (a *Archive) Background(m Model) ([][]float64, error) {
+	if m.Background == "" {
+		return nil, nil
+	}
+	r, err := a.entries[m.Background].Open()
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	var rows [][]float64
+	if err := json.NewDecoder(io.LimitReader(r, maxBackgroundSize)).Decode(&rows); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", m.Background, err)
+	}
+	return rows, nil
+}
+
+func (a *Archive) Close() error {
+	return a.zr.Close()
+}
+
+func invalid(field, message string) error {
+	return &domain.ValidationError{Field: field, Message: message}
+}
@@ -0,0 +1,200 @@
+package backup
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kevo-1/model-nexus/internal/domain"
+	"github.com/kevo-1/model-nexus/internal/ensemble"
+	"github.com/kevo-1/model-nexus/internal/pipeline"
+)
+
+func writeBackup(t *testing.T, models map[string][]byte, background []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "backup.zip")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	w := NewWriter(f)
+	for _, id := range []string{"iris", "diabetes"} {
+		data, ok := models[id]
+		if !ok {
+			continue
+		}
+		pkg := func(dst io.Writer) error {
+			_, err := dst.Write(data)
+			return err
+		}
+		var bg []byte
+		if id == "iris" {
+			bg = background
+		}
+		if err := w.AddModel(Model{ID: id, Name: id, Version: "1", Digest: "abc"}, pkg, bg); err != nil {
+			t.Fatal(err)
+		}
+	}
+	w.AddEnsemble(ensemble.Config{ID: "vote", Name: "vote", Version: "1", Members: []string{"iris", "diabetes"}, Strategy: ensemble.StrategyMean})
+	w.AddPipeline(pipeline.Definition{ID: "p", Name: "p", Version: "1", Steps: []pipeline.Step{{Name: "a", ModelID: "iris"}}})
+	w.Skip("broken", "artifact failed verification")
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestArchive(t *testing.T) {
+	models := map[string][]byte{"iris": []byte("iris package"), "diabetes": []byte("diabetes package")}
+	path := writeBackup(t, models, []byte(`[[1,2],[3,4]]`))
+
+	a, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+
+	m := a.Manifest
+	if len(m.Models) != 2 || len(m.Ensembles) != 1 || len(m.Pipelines) != 1 || len(m.Skipped) != 1 {
+		t.Fatalf("manifest lists %d models, %d ensembles, %d pipelines and %d skipped",
+			len(m.Models), len(m.Ensembles), len(m.Pipelines), len(m.Skipped))
+	}
+	for _, model := range m.Models {
+		if err := a.VerifyPackage(model); err != nil {
+			t.Fatalf("%s: %v", model.ID, err)
+		}
+		r, err := a.OpenPackage(model)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(r)
+		r.Close()
+		if !bytes.Equal(data, models[model.ID]) {
+			t.Fatalf("%s: package %q, want %q", model.ID, data, models[model.ID])
+		}
+	}
+
+	rows, err := a.Background(m.Models[0])
+	if err != nil || len(rows) != 2 || rows[1][0] != 3 {
+		t.Fatalf("Background = %v, %v", rows, err)
+	}
+	if rows, err := a.Background(m.Models[1]); rows != nil || err != nil {
+		t.Fatalf("Background of a model without one = %v, %v", rows, err)
+	}
+}
+
+// rewrite copies the backup at path, replacing or dropping entries.
+func rewrite(t *testing.T, path string, entries map[string][]byte) string {
+	t.Helper()
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zr.Close()
+
+	out := filepath.Join(t.TempDir(), "rewritten.zip")
+	f, err := os.Create(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	zw := zip.NewWriter(f)
+	for _, e := range zr.File {
+		data, replaced := entries[e.Name]
+		if !replaced {
+			r, err := e.Open()
+			if err != nil {
+				t.Fatal(err)
+			}
+			data, _ = io.ReadAll(r)
+			r.Close()
+		} else if data == nil {
+			continue
+		}
+		w, err := zw.Create(e.Name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write(data)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+func TestVerifyPackageDetectsChanges(t *testing.T) {
+	path := writeBackup(t, map[string][]byte{"iris": []byte("iris package")}, nil)
+	tampered := rewrite(t, path, map[string][]byte{"models/iris.mnx": []byte("other package")})
+
+	a, err := Open(tampered)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+	if err := a.VerifyPackage(a.Manifest.Models[0]); err == nil || !strings.Contains(err.Error(), "expected") {
+		t.Fatalf("VerifyPackage = %v, want a digest mismatch", err)
+	}
+}
+
+func TestOpenRejectsInvalidArchives(t *testing.T) {
+	path := writeBackup(t, map[string][]byte{"iris": []byte("iris package")}, []byte(`[[1]]`))
+	manifest := func(edit func(m map[string]any)) []byte {
+		a, err := Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := json.Marshal(a.Manifest)
+		a.Close()
+		var m map[string]any
+		json.Unmarshal(data, &m)
+		edit(m)
+		data, _ = json.Marshal(m)
+		return data
+	}
+	model := func(m map[string]any) map[string]any {
+		return m["models"].([]any)[0].(map[string]any)
+	}
+
+	tests := []struct {
+		name    string
+		entries map[string][]byte
+		field   string
+	}{
+		{"missing manifest", map[string][]byte{ManifestFile: nil}, "file"},
+		{"missing package", map[string][]byte{"models/iris.mnx": nil}, "file"},
+		{"missing background", map[string][]byte{"backgrounds/iris.json": nil}, "file"},
+		{"unknown manifest field", map[string][]byte{ManifestFile: manifest(func(m map[string]any) { m["aliases"] = []string{"x"} })}, "manifest"},
+		{"unsupported format version", map[string][]byte{ManifestFile: manifest(func(m map[string]any) { m["format_version"] = 2 })}, "format_version"},
+		{"invalid model id", map[string][]byte{ManifestFile: manifest(func(m map[string]any) { model(m)["id"] = "../iris" })}, "models[0].id"},
+		{"package outside models/", map[string][]byte{ManifestFile: manifest(func(m map[string]any) { model(m)["package"] = "backup.json" })}, "models[0].package"},
+		{"background of another model", map[string][]byte{ManifestFile: manifest(func(m map[string]any) { model(m)["background"] = "backgrounds/vote.json" })}, "models[0].background"},
+		{"ensemble reusing a model id", map[string][]byte{ManifestFile: manifest(func(m map[string]any) {
+			m["ensembles"].([]any)[0].(map[string]any)["id"] = "iris"
+		})}, "ensembles[0].id"},
+		{"duplicate pipeline", map[string][]byte{ManifestFile: manifest(func(m map[string]any) {
+			m["pipelines"] = append(m["pipelines"].([]any), m["pipelines"].([]any)[0])
+		})}, "pipelines[1].id"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, err := Open(rewrite(t, path, tt.entries))
+			if err == nil {
+				a.Close()
+				t.Fatal("Open accepted the archive")
+			}
+			var verr *domain.ValidationError
+			if !errors.As(err, &verr) || verr.Field != tt.field {
+				t.Fatalf("Open = %v, want a validation error for %s", err, tt.field)
+			}
+		})
+	}
+}
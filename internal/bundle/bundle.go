@@ -0,0 +1,237 @@
+// Package bundle unpacks multi-file model uploads (a graph plus external
+// data files) shipped as zip or tar archives.
+package bundle
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/kevo-1/model-nexus/internal/domain"
+)
+
+const (
+	// maxEntries bounds the number of files in an archive.
+	maxEntries = 1024
+	// maxUnpackedSize bounds the total unpacked size of any archive.
+	maxUnpackedSize = 64 << 30 // 64 GB
+	// maxExpansion bounds the unpacked size relative to the archive size,
+	// rejecting compression bombs long before maxUnpackedSize. Weights
+	// barely compress, so real bundles stay far below it.
+	maxExpansion = 32
+	// minUnpackedSize is allowed whatever the ratio, so small archives of
+	// highly compressible files still unpack.
+	minUnpackedSize = 64 << 20 // 64 MB
+)
+
+type kind int
+
+const (
+	kindNone kind = iota
+	kindZip
+	kindTar
+	kindTarGz
+)
+
+var (
+	zipMagic  = []byte("PK\x03\x04")
+	gzipMagic = []byte{0x1f, 0x8b}
+)
+
+// Detect reports whether an upload is an archive bundle, based on its
+// filename and leading bytes.
+func Detect(filename string, header []byte) bool {
+	return detect(filename, header) != kindNone
+}
+
+func detect(filename string, header []byte) kind {
+	name := strings.ToLower(filename)
+	switch {
+	case strings.HasSuffix(name, ".zip"):
+		return kindZip
+	case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+		return kindTarGz
+	case strings.HasSuffix(name, ".tar"):
+		return kindTar
+	case bytes.HasPrefix(header, zipMagic):
+		return kindZip
+	case bytes.HasPrefix(header, gzipMagic):
+		return kindTarGz
+	}
+	return kindNone
+}
+
+// Unpack extracts the archive at path into dst and returns the slash-
+// separated relative paths of the regular files it contained. Entries that
+// would escape dst, links and special files are rejected.
+func Unpack(path, filename, dst string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	header := make([]byte, len(zipMagic))
+	n, _ := io.ReadFull(f, header)
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	u := &unpacker{dst: dst, limit: unpackLimit(info.Size())}
+
+	switch detect(filename, header[:n]) {
+	case kindZip:
+		if err := u.unzip(f, info.Size()); err != nil {
+			return nil, err
+		}
+	case kindTar:
+		if err := u.untar(f); err != nil {
+			return nil, err
+		}
+	case kindTarGz:
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return nil, invalidArchive(err)
+		}
+		defer gz.Close()
+		if err := u.untar(gz); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, &domain.ValidationError{Field: "file", Message: "not a zip or tar archive"}
+	}
+
+	if len(u.files) == 0 {
+		return nil, &domain.ValidationError{Field: "file", Message: "archive contains no files"}
+	}
+	return u.files, nil
+}
+
+// unpackLimit is the total size an archive of the given size may unpack to.
+func unpackLimit(archiveSize int64) int64 {
+	limit := max(archiveSize*maxExpansion, minUnpackedSize)
+	return min(limit, maxUnpackedSize)
+}
+
+type unpacker struct {
+	dst   string
+	files []string
+	size  int64
+	limit int64
+}
+
+func (u *unpacker) unzip(r io.ReaderAt, size int64) error {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return invalidArchive(err)
+	}
+
+	for _, entry := range zr.File {
+		mode := entry.Mode()
+		if mode.IsDir() {
+			continue
+		}
+		if !mode.IsRegular() {
+			return unsafeEntry(entry.Name, "only regular files are allowed")
+		}
+
+		rc, err := entry.Open()
+		if err != nil {
+			return invalidArchive(err)
+		}
+		err = u.write(entry.Name, rc)
+		rc.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (u *unpacker) untar(r io.Reader) error {
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return invalidArchive(err)
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			continue
+		case tar.TypeReg:
+			if err := u.write(hdr.Name, tr); err != nil {
+				return err
+			}
+		case tar.TypeXGlobalHeader:
+			continue
+		default:
+			return unsafeEntry(hdr.Name, "only regular files are allowed")
+		}
+	}
+}
+
+// write copies one entry to dst after checking its name and the archive
+// limits.
+func (u *unpacker) write(name string, src io.Reader) error {
+	rel := filepath.FromSlash(strings.TrimPrefix(name, "./"))
+	if !filepath.IsLocal(rel) {
+		return unsafeEntry(name, "path escapes the bundle")
+	}
+
+	if len(u.files) >= maxEntries {
+		return &domain.ValidationError{Field: "file", Message: fmt.Sprintf("archive has more than %d files", maxEntries)}
+	}
+
+	target := filepath.Join(u.dst, rel)
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+
+	// O_EXCL rejects duplicate entries instead of silently overwriting.
+	out, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if errors.Is(err, os.ErrExist) {
+		return unsafeEntry(name, "duplicate entry")
+	}
+	if err != nil {
+		return err
+	}
+
+	remaining := u.limit - u.size
+	n, err := io.Copy(out, io.LimitReader(src, remaining+1))
+	u.size += n
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return invalidArchive(err)
+	}
+	if n > remaining {
+		return &domain.ValidationError{Field: "file", Message: fmt.Sprintf("archive expands beyond %d bytes; bundles may unpack to %dx their size, up to %d bytes", u.limit, maxExpansion, int64(maxUnpackedSize))}
+	}
+
+	u.files = append(u.files, filepath.ToSlash(rel))
+	return nil
+}
+
+func invalidArchive(err error) error {
+	return &domain.ValidationError{Field: "file", Message: fmt.Sprintf("invalid archive: %v", err)}
+}
+
+func unsafeEntry(name, reason string) error {
+	return &domain.ValidationError{Field: "file", Message: fmt.Sprintf("archive entry %q rejected: %s", name, reason)}
+}
@@ -0,0 +1,105 @@
+// Package digest computes the SHA-256 digests that address stored model
+// artifacts.
+package digest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Size is the length of a digest in hex characters.
+const Size = sha256.Size * 2
+
+// Normalize accepts a hex SHA-256 digest, optionally prefixed with
+// "sha256:", and returns it in lower case.
+func Normalize(s string) (string, error) {
+	d := strings.ToLower(strings.TrimPrefix(strings.TrimSpace(s), "sha256:"))
+	if len(d) != Size {
+		return "", fmt.Errorf("digest must be %d hex characters", Size)
+	}
+	if _, err := hex.DecodeString(d); err != nil {
+		return "", fmt.Errorf("digest must be hex encoded")
+	}
+	return d, nil
+}
+
+// File returns the digest of a file's contents.
+func File(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	return Reader(f)
+}
+
+// Reader returns the digest of everything read from r.
+func Reader(r io.Reader) (string, error) {
+	h := sha256.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Combine returns the tree digest of a set of files given as slash-
+// separated relative path -> file digest; see Tree.
+func Combine(files map[string]string) string {
+	lines := make([]string, 0, len(files))
+	for rel, sum := range files {
+		lines = append(lines, sum+"  "+rel+"\n")
+	}
+	sort.Strings(lines)
+
+	h := sha256.New()
+	for _, line := range lines {
+		io.WriteString(h, line)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Tree returns the digest of a directory of files: the SHA-256 of the
+// sorted "<digest>  <path>" lines that sha256sum would print for every
+// regular file under dir, paths slash-separated and relative to dir.
+// Files for which skip returns true are left out.
+func Tree(dir string, skip func(rel string) bool) (string, error) {
+	files := make(map[string]string)
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if skip != nil && skip(rel) {
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return fmt.Errorf("%s is not a regular file", rel)
+		}
+
+		sum, err := File(path)
+		if err != nil {
+			return err
+		}
+		files[rel] = sum
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return Combine(files), nil
+}
@@ -2,6 +2,7 @@ package domain
 
 import (
 	"fmt"
+	"strings"
 )
 
 type ModelNotFoundError struct {
@@ -37,3 +38,123 @@ type ModelAlreadyExistsError struct {
 func (e *ModelAlreadyExistsError) Error() string {
 	return fmt.Sprintf("model already exists: %s", e.ModelID)
 }
+
+// ModelUnhealthyError is returned for a registered model that is not
+// served because its stored artifact failed verification.
+type ModelUnhealthyError struct {
+	ModelID string
+	Reason  string
+}
+
+func (e *ModelUnhealthyError) Error() string {
+	return fmt.Sprintf("model %s is unhealthy: %s", e.ModelID, e.Reason)
+}
+
+type UnsupportedFormatError struct {
+	Filename string
+	Format   string
+}
+
+func (e *UnsupportedFormatError) Error() string {
+	if e.Format != "" {
+		return fmt.Sprintf("unsupported model format: %s", e.Format)
+	}
+	return fmt.Sprintf("unsupported model format for file: %s", e.Filename)
+}
+
+type PipelineNotFoundError struct {
+	PipelineID string
+}
+
+func (e *PipelineNotFoundError) Error() string {
+	return fmt.Sprintf("pipeline not found: %s", e.PipelineID)
+}
+
+type PipelineAlreadyExistsError struct {
+	PipelineID string
+}
+
+func (e *PipelineAlreadyExistsError) Error() string {
+	return fmt.Sprintf("pipeline already exists: %s", e.PipelineID)
+}
+
+type BackgroundNotFoundError struct {
+	ModelID string
+}
+
+func (e *BackgroundNotFoundError) Error() string {
+	return fmt.Sprintf("no background dataset stored for model: %s", e.ModelID)
+}
+
+// ShapeMismatchError reports a named input whose shape does not fit the
+// model, including symbolic axes bound to different lengths across inputs.
+type ShapeMismatchError struct {
+	Input  string
+	Reason string
+}
+
+func (e *ShapeMismatchError) Error() string {
+	return fmt.Sprintf("invalid shape for input %s: %s", e.Input, e.Reason)
+}
+
+// IncompatibleModelError lists why the runtime cannot load a model, e.g.
+// unsupported operators or opset versions.
+type IncompatibleModelError struct {
+	ModelID  string
+	Problems []string
+}
+
+func (e *IncompatibleModelError) Error() string {
+	return fmt.Sprintf("model %s is not supported by the runtime: %s", e.ModelID, strings.Join(e.Problems, "; "))
+}
+
+type UploadNotFoundError struct {
+	UploadID string
+}
+
+func (e *UploadNotFoundError) Error() string {
+	return fmt.Sprintf("upload not found: %s", e.UploadID)
+}
+
+// UploadConflictError is returned for a request that does not fit the
+// state of a resumable upload; Offset is the number of bytes received so
+// far, where the next chunk must start.
+type UploadConflictError struct {
+	UploadID string
+	Offset   int64
+	Reason   string
+}
+
+func (e *UploadConflictError) Error() string {
+	return fmt.Sprintf("upload %s: %s", e.UploadID, e.Reason)
+}
+
+type TooManyUploadsError struct {
+	Limit int
+}
+
+func (e *TooManyUploadsError) Error() string {
+	return fmt.Sprintf("too many uploads in progress (limit %d)", e.Limit)
+}
+
+// SourceFetchError reports a model source that could not be read, e.g. an
+// artifact server that failed or went away.
+type SourceFetchError struct {
+	Source string
+	Reason string
+}
+
+func (e *SourceFetchError) Error() string {
+	return fmt.Sprintf("failed to fetch %s: %s", e.Source, e.Reason)
+}
+
+// SignatureError reports a model rejected because it lacks a valid
+// signature from a trusted key.
+type SignatureError struct {
+	ModelID string
+	Reason  string
+}
+
+func (e *SignatureError) Error() string {
+	return fmt.Sprintf("model %s rejected: %s", e.ModelID, e.Reason)
+}
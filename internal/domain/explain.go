@@ -0,0 +1,75 @@
+package domain
+
+import (
+	"time"
+)
+
+type ExplainMethod string
+
+const (
+	ExplainKernelSHAP  ExplainMethod = "kernel_shap"
+	ExplainPermutation ExplainMethod = "permutation"
+)
+
+// ExplainRequest is a normal prediction request plus explanation settings.
+type ExplainRequest struct {
+	PredictionRequest
+
+	Method      ExplainMethod `json:"method,omitempty"`
+	OutputIndex int           `json:"output_index,omitempty"`
+	// SampleBudget caps the number of model evaluations.
+	SampleBudget int `json:"sample_budget,omitempty"`
+	// TimeLimitMs bounds wall-clock time; the result is marked truncated
+	// when sampling stops early.
+	TimeLimitMs int    `json:"time_limit_ms,omitempty"`
+	Seed        uint64 `json:"seed,omitempty"`
+}
+
+type ExplainResponse struct {
+	ModelID      string        `json:"model_id"`
+	RequestID    string        `json:"request_id"`
+	Method       ExplainMethod `json:"method"`
+	OutputIndex  int           `json:"output_index"`
+	Prediction   float64       `json:"prediction"`
+	BaseValue    float64       `json:"base_value"`
+	Attributions []float64     `json:"attributions"`
+	FeatureNames []string      `json:"feature_names,omitempty"`
+	Evaluations  int           `json:"evaluations"`
+	Truncated    bool          `json:"truncated"`
+	LatencyMs    float64       `json:"latency_ms"`
+	Timestamp    time.Time     `json:"timestamp"`
+}
+
+// BackgroundRequest stores the reference dataset used to explain a model.
+type BackgroundRequest struct {
+	ModelID string      `json:"model_id"`
+	Rows    [][]float64 `json:"rows"`
+}
+
+func (req *ExplainRequest) Validate() error {
+	if err := req.PredictionRequest.Validate(); err != nil {
+		return err
+	}
+
+	if len(req.Inputs) > 0 {
+		return &ValidationError{Field: "inputs", Message: "explanations take a flat features vector"}
+	}
+
+	switch req.Method {
+	case ExplainKernelSHAP, ExplainPermutation:
+	default:
+		return &ValidationError{Field: "method", Message: "method must be kernel_shap or permutation"}
+	}
+
+	if req.OutputIndex < 0 {
+		return &ValidationError{Field: "output_index", Message: "output_index must be non-negative"}
+	}
+	if req.SampleBudget < 0 {
+		return &ValidationError{Field: "sample_budget", Message: "sample_budget must be non-negative"}
+	}
+	if req.TimeLimitMs < 0 {
+		return &ValidationError{Field: "time_limit_ms", Message: "time_limit_ms must be non-negative"}
+	}
+
+	return nil
+}
@@ -15,4 +15,68 @@ type ModelMetadata struct {
 	Name    string `json:"name"`
 	Path    string `json:"path"`
 	Version string `json:"version"`
+	Format  string `json:"format,omitempty"`
+	Digest  string `json:"digest,omitempty"`
+	// SigningKeyID is the trusted key that signed the artifact, if any.
+	SigningKeyID string `json:"signing_key_id,omitempty"`
+}
+
+// MemberResult describes how one member of a composite model contributed to
+// a prediction. Error is set instead of Prediction when the member failed.
+type MemberResult struct {
+	ModelID    string    `json:"model_id"`
+	LatencyMs  float64   `json:"latency_ms"`
+	Prediction []float64 `json:"prediction,omitempty"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// DetailedPredictor is implemented by composite models that can report
+// per-member results alongside the combined prediction.
+type DetailedPredictor interface {
+	PredictDetailed(ctx context.Context, features []float64) ([]float64, []MemberResult, error)
+}
+
+// BatchPredictor is implemented by predictors that can score many rows in
+// one call more cheaply than calling Predict per row.
+type BatchPredictor interface {
+	PredictBatch(ctx context.Context, rows [][]float64) ([][]float64, error)
+}
+
+// LabeledPredictor is implemented by models that declare class labels for
+// their integer class outputs.
+type LabeledPredictor interface {
+	ClassLabels() []string
+}
+
+// MultiOutputPredictor is implemented by models that can return each of
+// their outputs separately. Predict returns the same values concatenated in
+// the order of OutputNames.
+type MultiOutputPredictor interface {
+	OutputNames() []string
+	PredictOutputs(ctx context.Context, features []float64) (map[string][]float64, error)
+}
+
+// FeatureNamer is implemented by models that declare names for their input
+// features.
+type FeatureNamer interface {
+	FeatureNames() []string
+}
+
+// Labeler is implemented by predictors whose class labels and feature
+// names can be set after loading, e.g. from a package manifest. SetLabels
+// must be called before the predictor is shared.
+type Labeler interface {
+	SetLabels(classLabels, featureNames []string)
+}
+
+// Relocatable is implemented by predictors whose artifact can be moved
+// after loading. Relocate must be called before the predictor is shared.
+type Relocatable interface {
+	Relocate(path string)
+}
+
+// TensorPredictor is implemented by predictors that accept separately
+// shaped named inputs.
+type TensorPredictor interface {
+	PredictTensors(ctx context.Context, inputs []NamedTensor) ([]float64, error)
 }
@@ -0,0 +1,32 @@
+package domain
+
+import "fmt"
+
+// MaxModelIDLength bounds the length of a model id.
+const MaxModelIDLength = 64
+
+// ValidateModelID checks id against the model id grammar: 1 to 64 ASCII
+// letters, digits, '_', '-' or '.', starting with a letter or digit. field
+// names the request field the id came from.
+func ValidateModelID(field, id string) error {
+	if id == "" {
+		return &ValidationError{Field: field, Message: fmt.Sprintf("%s is required", field)}
+	}
+	if len(id) > MaxModelIDLength {
+		return &ValidationError{Field: field, Message: fmt.Sprintf("%s must be at most %d characters", field, MaxModelIDLength)}
+	}
+
+	for i := 0; i < len(id); i++ {
+		c := id[i]
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case i > 0 && (c == '_' || c == '-' || c == '.'):
+		default:
+			return &ValidationError{
+				Field:   field,
+				Message: fmt.Sprintf("%s %q is invalid: use letters, digits, '_', '-' or '.', starting with a letter or digit", field, id),
+			}
+		}
+	}
+	return nil
+}
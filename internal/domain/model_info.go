@@ -0,0 +1,94 @@
+package domain
+
+import (
+	"encoding/json"
+)
+
+// ModelInfo describes a model artifact in terms every backend can fill in.
+// The upload path only works with these fields; the backend's own
+// description of the artifact is kept in Details, which is what clients
+// see and what is stored next to the artifact for its predictor.
+type ModelInfo struct {
+	Format  string       `json:"format,omitempty"`
+	Inputs  []TensorSpec `json:"inputs"`
+	Outputs []TensorSpec `json:"outputs"`
+	// ClassLabels and FeatureNames are the labels the model is served
+	// with; change them with SetLabels so Details follows.
+	ClassLabels  []string `json:"class_labels,omitempty"`
+	FeatureNames []string `json:"feature_names,omitempty"`
+	// Incompatible lists why the runtime cannot load the artifact.
+	Incompatible []string `json:"incompatible,omitempty"`
+	// Unbindable lists inputs and outputs predictions cannot feed or read.
+	Unbindable []string `json:"unbindable,omitempty"`
+	// SupportingFiles counts the files the artifact reads besides itself.
+	SupportingFiles int           `json:"supporting_files,omitempty"`
+	Lint            []LintFinding `json:"lint,omitempty"`
+
+	Details ModelDetails `json:"-"`
+}
+
+// TensorSpec is one input or output of a model. Dynamic axes are < 1.
+type TensorSpec struct {
+	Name  string  `json:"name"`
+	Shape []int64 `json:"shape"`
+}
+
+type LintSeverity string
+
+const (
+	LintWarning LintSeverity = "warning"
+	LintError   LintSeverity = "error"
+)
+
+// LintFinding is one structural problem found in a model.
+type LintFinding struct {
+	Severity LintSeverity `json:"severity"`
+	Check    string       `json:"check"`
+	Name     string       `json:"name,omitempty"`
+	Message  string       `json:"message"`
+}
+
+// ModelDetails is a backend's own description of an artifact.
+type ModelDetails interface {
+	// SetLabels records the class labels and feature names the model is
+	// served with.
+	SetLabels(classLabels, featureNames []string)
+}
+
+// SetLabels sets the class labels and feature names the model is served
+// with.
+func (m *ModelInfo) SetLabels(classLabels, featureNames []string) {
+	m.ClassLabels, m.FeatureNames = classLabels, featureNames
+	if m.Details != nil {
+		m.Details.SetLabels(classLabels, featureNames)
+	}
+}
+
+// InputSize is the number of features of the first input, or -1 if none
+// of its axes is fixed.
+func (m *ModelInfo) InputSize() int {
+	if len(m.Inputs) == 0 {
+		return -1
+	}
+	size := int64(1)
+	hasPositiveDim := false
+	for _, dim := range m.Inputs[0].Shape {
+		if dim > 0 {
+			size *= dim
+			hasPositiveDim = true
+		}
+	}
+	if !hasPositiveDim {
+		return -1
+	}
+	return int(size)
+}
+
+// MarshalJSON writes the backend's description of the artifact.
+func (m *ModelInfo) MarshalJSON() ([]byte, error) {
+	if m.Details != nil {
+		return json.Marshal(m.Details)
+	}
+	type plain ModelInfo
+	return json.Marshal((*plain)(m))
+}
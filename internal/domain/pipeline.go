@@ -0,0 +1,46 @@
+package domain
+
+import (
+	"time"
+)
+
+type PipelineRequest struct {
+	PipelineID string    `json:"pipeline_id"`
+	RequestID  string    `json:"request_id,omitempty"`
+	Features   []float64 `json:"features"`
+	Trace      bool      `json:"trace,omitempty"`
+}
+
+type PipelineResponse struct {
+	PipelineID string      `json:"pipeline_id"`
+	RequestID  string      `json:"request_id"`
+	LatencyMs  float64     `json:"latency_ms"`
+	OutputStep string      `json:"output_step"`
+	Prediction []float64   `json:"prediction"`
+	Timestamp  time.Time   `json:"timestamp"`
+	Trace      []StepTrace `json:"trace,omitempty"`
+}
+
+// StepTrace records what one pipeline step received and produced.
+type StepTrace struct {
+	Step    string    `json:"step"`
+	ModelID string    `json:"model_id"`
+	Skipped bool      `json:"skipped,omitempty"`
+	Input   []float64 `json:"input,omitempty"`
+	Output  []float64 `json:"output,omitempty"`
+	// Outputs holds each output by name, for models that name them.
+	Outputs   map[string][]float64 `json:"outputs,omitempty"`
+	LatencyMs float64              `json:"latency_ms"`
+}
+
+func (req *PipelineRequest) Validate() error {
+	if req.PipelineID == "" {
+		return &ValidationError{Field: "pipeline_id", Message: "pipeline_id is required"}
+	}
+
+	if len(req.Features) == 0 {
+		return &ValidationError{Field: "features", Message: "features cannot be empty"}
+	}
+
+	return nil
+}
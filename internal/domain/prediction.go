@@ -9,6 +9,16 @@ type PredictionRequest struct {
 	ModelID   string    `json:"model_id"`
 	RequestID string    `json:"request_id,omitempty"`
 	Features  []float64 `json:"features"`
+	// Inputs feeds each named model input separately, for models with
+	// several inputs or dynamic axes. Mutually exclusive with Features.
+	Inputs []NamedTensor `json:"inputs,omitempty"`
+}
+
+// NamedTensor is one model input: row-major Data laid out in Shape.
+type NamedTensor struct {
+	Name  string    `json:"name"`
+	Shape []int64   `json:"shape"`
+	Data  []float64 `json:"data"`
 }
 
 type PredictionResponse struct {
@@ -18,11 +28,21 @@ type PredictionResponse struct {
 	Prediction []float64 `json:"prediction"`
 	Timestamp  time.Time `json:"timestamp"`
 	Confidence *float64  `json:"confidence,omitempty"`
+	Labels     []string  `json:"labels,omitempty"`
+
+	Members []MemberResult `json:"members,omitempty"`
 }
 
 func (req *PredictionRequest) Validate() error {
-	if req.ModelID == "" {
-		return &ValidationError{Field: "model_id", Message: "model_id is required"}
+	if err := ValidateModelID("model_id", req.ModelID); err != nil {
+		return err
+	}
+
+	if len(req.Inputs) > 0 {
+		if len(req.Features) > 0 {
+			return &ValidationError{Field: "inputs", Message: "features and inputs cannot both be set"}
+		}
+		return validateNamedTensors(req.Inputs)
 	}
 
 	if len(req.Features) == 0 {
@@ -32,6 +52,37 @@ func (req *PredictionRequest) Validate() error {
 	return nil
 }
 
+func validateNamedTensors(inputs []NamedTensor) error {
+	seen := make(map[string]bool, len(inputs))
+	for _, in := range inputs {
+		if in.Name == "" {
+			return &ValidationError{Field: "inputs", Message: "every input needs a name"}
+		}
+		if seen[in.Name] {
+			return &ValidationError{Field: "inputs", Message: fmt.Sprintf("input %q given twice", in.Name)}
+		}
+		seen[in.Name] = true
+
+		size := int64(1)
+		for _, d := range in.Shape {
+			if d <= 0 {
+				return &ValidationError{Field: "inputs", Message: fmt.Sprintf("input %q has non-positive dim %d", in.Name, d)}
+			}
+			size *= d
+			if size > int64(len(in.Data)) {
+				break
+			}
+		}
+		if size != int64(len(in.Data)) {
+			return &ValidationError{
+				Field:   "inputs",
+				Message: fmt.Sprintf("input %q has %d values, shape %v needs %d", in.Name, len(in.Data), in.Shape, size),
+			}
+		}
+	}
+	return nil
+}
+
 type ValidationError struct {
 	Field   string
 	Message string
@@ -40,3 +91,22 @@ type ValidationError struct {
 func (e *ValidationError) Error() string {
 	return fmt.Sprintf("Validation error [%s]: %s", e.Field, e.Message)
 }
+
+// LabelsFor maps integer class predictions to their labels. It returns nil
+// unless every value is a valid class index, so regression outputs and
+// probability vectors are never mislabelled.
+func LabelsFor(prediction []float64, classLabels []string) []string {
+	if len(classLabels) == 0 || len(prediction) == 0 {
+		return nil
+	}
+
+	labels := make([]string, len(prediction))
+	for i, v := range prediction {
+		idx := int(v)
+		if float64(idx) != v || idx < 0 || idx >= len(classLabels) {
+			return nil
+		}
+		labels[i] = classLabels[idx]
+	}
+	return labels
+}
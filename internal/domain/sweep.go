@@ -0,0 +1,106 @@
+package domain
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+const (
+	MaxSweepAxisPoints = 1000
+	MaxSweepPoints     = 10000
+)
+
+// SweepRequest varies one or two features of a base vector over a grid and
+// asks for the model output at every grid point.
+type SweepRequest struct {
+	ModelID   string      `json:"model_id"`
+	RequestID string      `json:"request_id,omitempty"`
+	Features  []float64   `json:"features"`
+	Vary      []SweepAxis `json:"vary"`
+}
+
+// SweepAxis selects a feature and its values: either an explicit list in
+// Values, or Steps evenly spaced points from Min to Max inclusive.
+type SweepAxis struct {
+	Index  int       `json:"index"`
+	Values []float64 `json:"values,omitempty"`
+	Min    float64   `json:"min,omitempty"`
+	Max    float64   `json:"max,omitempty"`
+	Steps  int       `json:"steps,omitempty"`
+}
+
+type SweepPoint struct {
+	Values     []float64 `json:"values"`
+	Prediction []float64 `json:"prediction"`
+}
+
+type SweepResponse struct {
+	ModelID   string       `json:"model_id"`
+	RequestID string       `json:"request_id"`
+	Axes      []SweepAxis  `json:"axes"`
+	Points    []SweepPoint `json:"points"`
+	LatencyMs float64      `json:"latency_ms"`
+	Timestamp time.Time    `json:"timestamp"`
+}
+
+func (req *SweepRequest) Validate() error {
+	if err := ValidateModelID("model_id", req.ModelID); err != nil {
+		return err
+	}
+
+	if len(req.Features) == 0 {
+		return &ValidationError{Field: "features", Message: "features cannot be empty"}
+	}
+
+	if len(req.Vary) == 0 || len(req.Vary) > 2 {
+		return &ValidationError{Field: "vary", Message: "vary must list one or two features"}
+	}
+
+	total := 1
+	for i, axis := range req.Vary {
+		field := fmt.Sprintf("vary[%d]", i)
+		if axis.Index < 0 || axis.Index >= len(req.Features) {
+			return &ValidationError{Field: field, Message: fmt.Sprintf("index %d out of range for %d features", axis.Index, len(req.Features))}
+		}
+		if i == 1 && axis.Index == req.Vary[0].Index {
+			return &ValidationError{Field: field, Message: "cannot vary the same feature twice"}
+		}
+
+		n := len(axis.Values)
+		if n == 0 {
+			if axis.Steps < 2 {
+				return &ValidationError{Field: field, Message: "either values or steps >= 2 with min and max are required"}
+			}
+			if math.IsNaN(axis.Min) || math.IsNaN(axis.Max) || math.IsInf(axis.Min, 0) || math.IsInf(axis.Max, 0) {
+				return &ValidationError{Field: field, Message: "min and max must be finite"}
+			}
+			n = axis.Steps
+		}
+		if n > MaxSweepAxisPoints {
+			return &ValidationError{Field: field, Message: fmt.Sprintf("at most %d points per axis", MaxSweepAxisPoints)}
+		}
+		total *= n
+	}
+
+	if total > MaxSweepPoints {
+		return &ValidationError{Field: "vary", Message: fmt.Sprintf("grid has %d points, at most %d are allowed", total, MaxSweepPoints)}
+	}
+
+	return nil
+}
+
+// Grid returns the values of the axis, expanding a min/max/steps range.
+func (a SweepAxis) Grid() []float64 {
+	if len(a.Values) > 0 {
+		return a.Values
+	}
+
+	grid := make([]float64, a.Steps)
+	step := (a.Max - a.Min) / float64(a.Steps-1)
+	for i := range grid {
+		grid[i] = a.Min + float64(i)*step
+	}
+	grid[a.Steps-1] = a.Max
+	return grid
+}
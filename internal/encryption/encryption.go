@@ -0,0 +1,340 @@
+// Package encryption seals stored model artifacts with AES-256-GCM. Every
+// object is encrypted with its own random data key, which is kept in the
+// object header wrapped by a master key. Rotating the master key only
+// requires the previous key to read objects until they are re-encrypted.
+//
+// Object layout:
+//
+//	magic (8) | master key id (8) | wrap nonce (12) | wrapped data key (48) | nonce prefix (7)
+//	chunk 0 | chunk 1 | ... | final chunk
+//
+// Each chunk seals up to 64 KB of plaintext. Its nonce is the prefix, a
+// 32-bit chunk counter and a final-chunk flag, so reordered, dropped or
+// truncated chunks fail authentication.
+package encryption
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+const (
+	// KeySize is the size of master and data keys (AES-256).
+	KeySize = 32
+	// HeaderSize is the size of the object header.
+	HeaderSize = len(magic) + keyIDSize + nonceSize + KeySize + tagSize + prefixSize
+
+	magic      = "MNXENC1\x00"
+	keyIDSize  = 8
+	nonceSize  = 12
+	tagSize    = 16
+	prefixSize = 7
+	chunkSize  = 64 << 10
+)
+
+// ErrUnknownKey is returned for objects sealed by a master key that is not
+// configured.
+var ErrUnknownKey = errors.New("object is encrypted with an unknown master key")
+
+// Key is a master key.
+type Key struct {
+	id   [keyIDSize]byte
+	aead cipher.AEAD
+}
+
+// NewKey creates a master key from KeySize secret bytes. Its id is derived
+// from the secret, so the same secret always has the same id.
+func NewKey(secret []byte) (*Key, error) {
+	if len(secret) != KeySize {
+		return nil, fmt.Errorf("key must be %d bytes, got %d", KeySize, len(secret))
+	}
+	aead, err := newAEAD(secret)
+	if err != nil {
+		return nil, err
+	}
+	k := &Key{aead: aead}
+	sum := sha256.Sum256(secret)
+	copy(k.id[:], sum[:])
+	return k, nil
+}
+
+// ParseKey decodes a key given as base64 or hex text.
+func ParseKey(s string) (*Key, error) {
+	s = strings.TrimSpace(s)
+	if secret, err := hex.DecodeString(s); err == nil && len(secret) == KeySize {
+		return NewKey(secret)
+	}
+	secret, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("key must be %d bytes encoded as base64 or hex", KeySize)
+	}
+	return NewKey(secret)
+}
+
+// LoadKeyFile reads a key file holding the raw key bytes or their base64
+// or hex encoding.
+func LoadKeyFile(path string) (*Key, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == KeySize {
+		return NewKey(data)
+	}
+	return ParseKey(string(data))
+}
+
+// ID identifies the key in object headers and logs.
+func (k *Key) ID() string {
+	return hex.EncodeToString(k.id[:])
+}
+
+// Keyring encrypts with the current master key and decrypts with any
+// configured one.
+type Keyring struct {
+	current *Key
+	keys    map[[keyIDSize]byte]*Key
+}
+
+// NewKeyring encrypts new objects with current. Objects sealed by one of
+// the previous keys can still be read.
+func NewKeyring(current *Key, previous ...*Key) *Keyring {
+	k := &Keyring{current: current, keys: make(map[[keyIDSize]byte]*Key)}
+	for _, key := range append(previous, current) {
+		k.keys[key.id] = key
+	}
+	return k
+}
+
+// Current returns the key new objects are sealed with.
+func (k *Keyring) Current() *Key {
+	return k.current
+}
+
+// IDs returns the ids of every configured key in order.
+func (k *Keyring) IDs() []string {
+	ids := make([]string, 0, len(k.keys))
+	for _, key := range k.keys {
+		ids = append(ids, key.ID())
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// IsEncrypted reports whether an object starts with an encryption header.
+func IsEncrypted(header []byte) bool {
+	return bytes.HasPrefix(header, []byte(magic))
+}
+
+// KeyID returns the id of the master key that sealed an object, read from
+// its header.
+func KeyID(header []byte) (string, error) {
+	if !IsEncrypted(header) || len(header) < len(magic)+keyIDSize {
+		return "", errors.New("object is not encrypted")
+	}
+	return hex.EncodeToString(header[len(magic) : len(magic)+keyIDSize]), nil
+}
+
+// EncryptedSize returns the size of an object holding n plaintext bytes.
+func EncryptedSize(n int64) int64 {
+	chunks := max((n+chunkSize-1)/chunkSize, 1)
+	return int64(HeaderSize) + n + tagSize*chunks
+}
+
+// PlaintextSize returns the plaintext size of an object of n bytes.
+func PlaintextSize(n int64) (int64, error) {
+	body := n - int64(HeaderSize)
+	chunks := (body + chunkSize + tagSize - 1) / (chunkSize + tagSize)
+	if body < tagSize || chunks < 1 {
+		return 0, errors.New("object is too short")
+	}
+	plain := body - chunks*tagSize
+	if EncryptedSize(plain) != n {
+		return 0, errors.New("object size does not match its chunking")
+	}
+	return plain, nil
+}
+
+// Encrypt seals src into dst under a new data key.
+func (k *Keyring) Encrypt(dst io.Writer, src io.Reader) error {
+	dataKey := make([]byte, KeySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return err
+	}
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return err
+	}
+
+	header := make([]byte, 0, HeaderSize)
+	header = append(header, magic...)
+	header = append(header, k.current.id[:]...)
+	wrapNonce := make([]byte, nonceSize)
+	if _, err := rand.Read(wrapNonce); err != nil {
+		return err
+	}
+	header = append(header, wrapNonce...)
+	header = k.current.aead.Seal(header, wrapNonce, dataKey, header[:len(magic)+keyIDSize])
+	prefix := make([]byte, prefixSize)
+	if _, err := rand.Read(prefix); err != nil {
+		return err
+	}
+	header = append(header, prefix...)
+	if _, err := dst.Write(header); err != nil {
+		return err
+	}
+
+	// Read one byte ahead to know which chunk is the last
+	buf := make([]byte, chunkSize+1)
+	out := make([]byte, 0, chunkSize+tagSize)
+	n, err := io.ReadFull(src, buf)
+	for counter := uint32(0); ; counter++ {
+		if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+			return err
+		}
+		final := n <= chunkSize
+		size := min(n, chunkSize)
+
+		out = aead.Seal(out[:0], chunkNonce(prefix, counter, final), buf[:size], header)
+		if _, err := dst.Write(out); err != nil {
+			return err
+		}
+		if final {
+			return nil
+		}
+		if counter == ^uint32(0) {
+			return errors.New("object is too large to encrypt")
+		}
+
+		buf[0] = buf[chunkSize]
+		n, err = io.ReadFull(src, buf[1:])
+		n++
+	}
+}
+
+// Decrypt returns a reader of the plaintext of an object read from src.
+// Reads fail if the object was modified or truncated; plaintext is only
+// returned once its chunk has been authenticated.
+func (k *Keyring) Decrypt(src io.Reader) (io.Reader, error) {
+	header := make([]byte, HeaderSize)
+	if _, err := io.ReadFull(src, header); err != nil {
+		return nil, fmt.Errorf("failed to read encryption header: %w", err)
+	}
+	if !IsEncrypted(header) {
+		return nil, errors.New("object is not encrypted")
+	}
+
+	var id [keyIDSize]byte
+	copy(id[:], header[len(magic):])
+	key, ok := k.keys[id]
+	if !ok {
+		return nil, fmt.Errorf("%w %s", ErrUnknownKey, hex.EncodeToString(id[:]))
+	}
+
+	wrapped := header[len(magic)+keyIDSize : HeaderSize-prefixSize]
+	dataKey, err := key.aead.Open(nil, wrapped[:nonceSize], wrapped[nonceSize:], header[:len(magic)+keyIDSize])
+	if err != nil {
+		return nil, errors.New("failed to unwrap data key: header was modified")
+	}
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &reader{
+		src:    src,
+		aead:   aead,
+		header: header,
+		prefix: header[HeaderSize-prefixSize:],
+		buf:    make([]byte, chunkSize+tagSize+1),
+	}, nil
+}
+
+type reader struct {
+	src     io.Reader
+	aead    cipher.AEAD
+	header  []byte
+	prefix  []byte
+	counter uint32
+
+	buf     []byte // sealed chunk plus one byte of lookahead
+	pending int    // lookahead bytes at the start of buf
+	plain   []byte // authenticated plaintext not yet returned
+	done    bool
+	err     error
+}
+
+func (r *reader) Read(p []byte) (int, error) {
+	for len(r.plain) == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+		if r.done {
+			return 0, io.EOF
+		}
+		r.err = r.next()
+	}
+	n := copy(p, r.plain)
+	r.plain = r.plain[n:]
+	return n, nil
+}
+
+// next authenticates the next chunk.
+func (r *reader) next() error {
+	n, err := io.ReadFull(r.src, r.buf[r.pending:])
+	n += r.pending
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return err
+	}
+	final := n <= chunkSize+tagSize
+	size := min(n, chunkSize+tagSize)
+	if size < tagSize {
+		return errors.New("encrypted object is truncated")
+	}
+
+	plain, err := r.aead.Open(r.buf[:0], chunkNonce(r.prefix, r.counter, final), r.buf[:size], r.header)
+	if err != nil {
+		return fmt.Errorf("chunk %d failed authentication: object was modified or truncated", r.counter)
+	}
+	// Keep the plaintext out of the way of the next read into buf
+	r.plain = append([]byte(nil), plain...)
+
+	if final {
+		r.done = true
+		return nil
+	}
+	r.buf[0] = r.buf[size]
+	r.pending = 1
+	r.counter++
+	return nil
+}
+
+func chunkNonce(prefix []byte, counter uint32, final bool) []byte {
+	nonce := make([]byte, nonceSize)
+	copy(nonce, prefix)
+	binary.BigEndian.PutUint32(nonce[prefixSize:], counter)
+	if final {
+		nonce[nonceSize-1] = 1
+	}
+	return nonce
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
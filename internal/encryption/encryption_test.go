@@ -0,0 +1,244 @@
+package encryption
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func newTestKey(t *testing.T) *Key {
+	t.Helper()
+	secret := make([]byte, KeySize)
+	if _, err := rand.Read(secret); err != nil {
+		t.Fatal(err)
+	}
+	k, err := NewKey(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return k
+}
+
+func seal(t *testing.T, k *Keyring, plain []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := k.Encrypt(&buf, bytes.NewReader(plain)); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func open(k *Keyring, sealed []byte) ([]byte, error) {
+	r, err := k.Decrypt(bytes.NewReader(sealed))
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(r)
+}
+
+func TestRoundTrip(t *testing.T) {
+	k := NewKeyring(newTestKey(t))
+	for _, n := range []int{0, 1, chunkSize - 1, chunkSize, chunkSize + 1, 3*chunkSize + 5} {
+		plain := make([]byte, n)
+		rand.Read(plain)
+
+		sealed := seal(t, k, plain)
+		if int64(len(sealed)) != EncryptedSize(int64(n)) {
+			t.Fatalf("%d bytes sealed into %d, EncryptedSize = %d", n, len(sealed), EncryptedSize(int64(n)))
+		}
+		if size, err := PlaintextSize(int64(len(sealed))); err != nil || size != int64(n) {
+			t.Fatalf("PlaintextSize(%d) = %d, %v, want %d", len(sealed), size, err, n)
+		}
+		if id, err := KeyID(sealed); err != nil || id != k.Current().ID() {
+			t.Fatalf("KeyID = %s, %v, want %s", id, err, k.Current().ID())
+		}
+
+		got, err := open(k, sealed)
+		if err != nil {
+			t.Fatalf("%d bytes: %v", n, err)
+		}
+		if !bytes.Equal(got, plain) {
+			t.Fatalf("%d bytes: plaintext differs after a round trip", n)
+		}
+	}
+}
+
+func TestDecryptDetectsTampering(t *testing.T) {
+	k := NewKeyring(newTestKey(t))
+	plain := make([]byte, 3*chunkSize+5)
+	rand.Read(plain)
+	sealed := seal(t, k, plain)
+
+	sealedChunk := chunkSize + tagSize
+	chunk := func(i int) []byte {
+		start := HeaderSize + i*sealedChunk
+		return sealed[start:min(start+sealedChunk, len(sealed))]
+	}
+	join := func(parts ...[]byte) []byte {
+		return bytes.Join(parts, nil)
+	}
+	header := sealed[:HeaderSize]
+
+	tests := []struct {
+		name   string
+		object []byte
+	}{
+		{"reordered chunks", join(header, chunk(1), chunk(0), chunk(2), chunk(3))},
+		{"dropped chunk", join(header, chunk(0), chunk(2), chunk(3))},
+		{"truncated at a chunk boundary", join(header, chunk(0), chunk(1))},
+		{"truncated final chunk", sealed[:len(sealed)-1]},
+		{"appended chunk", join(sealed, chunk(1))},
+		{"flipped ciphertext byte", flip(sealed, HeaderSize+10)},
+		{"flipped header byte", flip(sealed, len(magic)+keyIDSize+2)},
+		{"header only", header},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := open(k, tt.object)
+			if err == nil {
+				t.Fatalf("decrypted %d bytes, want an error", len(got))
+			}
+		})
+	}
+}
+
+func flip(b []byte, i int) []byte {
+	b = bytes.Clone(b)
+	b[i] ^= 1
+	return b
+}
+
+func TestDecryptWrongKey(t *testing.T) {
+	k1, k2 := newTestKey(t), newTestKey(t)
+	sealed := seal(t, NewKeyring(k1), []byte("model bytes"))
+
+	tests := []struct {
+		name    string
+		keys    *Keyring
+		unknown bool // fails with ErrUnknownKey
+	}{
+		{"other key", NewKeyring(k2), true},
+		{"other key with previous keys", NewKeyring(k2, newTestKey(t)), true},
+		{"forged key id", forged(t, k1, k2), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := open(tt.keys, sealed)
+			if err == nil {
+				t.Fatal("decrypted with the wrong key")
+			}
+			if errors.Is(err, ErrUnknownKey) != tt.unknown {
+				t.Fatalf("error %v, ErrUnknownKey = %v", err, tt.unknown)
+			}
+		})
+	}
+
+	if _, err := open(NewKeyring(k1), bytes.Repeat([]byte("plaintext"), HeaderSize)); err == nil {
+		t.Fatal("decrypted a plaintext object")
+	}
+}
+
+// forged returns a keyring holding key under the id of impersonated, so
+// the wrapped data key is opened with the wrong key.
+func forged(t *testing.T, impersonated, key *Key) *Keyring {
+	t.Helper()
+	k := &Key{id: impersonated.id, aead: key.aead}
+	return NewKeyring(k)
+}
+
+func TestRotation(t *testing.T) {
+	k1, k2, k3 := newTestKey(t), newTestKey(t), newTestKey(t)
+	plain := bytes.Repeat([]byte("weights"), chunkSize/3)
+
+	// rotate re-encrypts an object under the current key of keys, like
+	// the service does for objects sealed by a previous key.
+	rotate := func(keys *Keyring, sealed []byte) []byte {
+		t.Helper()
+		r, err := keys.Decrypt(bytes.NewReader(sealed))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var buf bytes.Buffer
+		if err := keys.Encrypt(&buf, r); err != nil {
+			t.Fatal(err)
+		}
+		return buf.Bytes()
+	}
+
+	sealed := seal(t, NewKeyring(k1), plain)
+	rotated := rotate(NewKeyring(k2, k1), sealed)
+	// Rotating an already rotated object keeps it readable, under the
+	// newest key only
+	again := rotate(NewKeyring(k3, k2, k1), rotated)
+
+	tests := []struct {
+		name   string
+		object []byte
+		keys   *Keyring
+		keyID  string
+		ok     bool
+	}{
+		{"rotated with the new key", rotated, NewKeyring(k2), k2.ID(), true},
+		{"rotated without the old key", rotated, NewKeyring(k1), k2.ID(), false},
+		{"rotated twice with the newest key", again, NewKeyring(k3), k3.ID(), true},
+		{"rotated twice with a previous key", again, NewKeyring(k2, k1), k3.ID(), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if id, _ := KeyID(tt.object); id != tt.keyID {
+				t.Fatalf("sealed by %s, want %s", id, tt.keyID)
+			}
+			got, err := open(tt.keys, tt.object)
+			if !tt.ok {
+				if !errors.Is(err, ErrUnknownKey) {
+					t.Fatalf("error %v, want ErrUnknownKey", err)
+				}
+				return
+			}
+			if err != nil || !bytes.Equal(got, plain) {
+				t.Fatalf("decrypted %d bytes, %v; want the original %d", len(got), err, len(plain))
+			}
+		})
+	}
+}
+
+func TestParseKey(t *testing.T) {
+	secret := bytes.Repeat([]byte{0xab}, KeySize)
+	want, err := NewKey(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		input string
+		ok    bool
+	}{
+		{"hex", strings.Repeat("ab", KeySize), true},
+		{"base64", "q6urq6urq6urq6urq6urq6urq6urq6urq6urq6urq6s=", true},
+		{"surrounding whitespace", "  " + strings.Repeat("ab", KeySize) + "\n", true},
+		{"short hex", strings.Repeat("ab", KeySize-1), false},
+		{"short base64", "q6urq6urq6s=", false},
+		{"not encoded", "not a key", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k, err := ParseKey(tt.input)
+			if !tt.ok {
+				if err == nil {
+					t.Fatal("ParseKey succeeded")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if k.ID() != want.ID() {
+				t.Fatalf("key id %s, want %s", k.ID(), want.ID())
+			}
+		})
+	}
+}
@@ -0,0 +1,404 @@
+package ensemble
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kevo-1/model-nexus/internal/domain"
+)
+
+// Format identifies ensembles in model metadata.
+const Format = "ensemble"
+
+type Strategy string
+
+const (
+	StrategyMean         Strategy = "mean"
+	StrategyWeightedMean Strategy = "weighted_mean"
+	StrategyVote         Strategy = "vote"
+	StrategyStacking     Strategy = "stacking"
+)
+
+// Config defines an ensemble served as a single logical model.
+type Config struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Version   string    `json:"version"`
+	Members   []string  `json:"members"`
+	Weights   []float64 `json:"weights,omitempty"`
+	Strategy  Strategy  `json:"strategy"`
+	MetaModel string    `json:"meta_model,omitempty"`
+}
+
+func (c *Config) Validate() error {
+	if c.ID == "" || c.Name == "" || c.Version == "" {
+		return &domain.ValidationError{Field: "id/name/version", Message: "id, name, and version are required"}
+	}
+
+	if err := domain.ValidateModelID("id", c.ID); err != nil {
+		return err
+	}
+
+	if len(c.Members) == 0 {
+		return &domain.ValidationError{Field: "members", Message: "at least one member model is required"}
+	}
+
+	seen := make(map[string]bool, len(c.Members))
+	for _, m := range c.Members {
+		if err := domain.ValidateModelID("members", m); err != nil {
+			return err
+		}
+		if m == c.ID {
+			return &domain.ValidationError{Field: "members", Message: "an ensemble cannot contain itself"}
+		}
+		if seen[m] {
+			return &domain.ValidationError{Field: "members", Message: fmt.Sprintf("duplicate member %q", m)}
+		}
+		seen[m] = true
+	}
+
+	if len(c.Weights) > 0 {
+		if len(c.Weights) != len(c.Members) {
+			return &domain.ValidationError{Field: "weights", Message: "weights must have one entry per member"}
+		}
+		for _, w := range c.Weights {
+			if w < 0 || math.IsNaN(w) || math.IsInf(w, 0) {
+				return &domain.ValidationError{Field: "weights", Message: "weights must be finite and non-negative"}
+			}
+		}
+	}
+
+	switch c.Strategy {
+	case StrategyMean, StrategyVote:
+	case StrategyWeightedMean:
+		if len(c.Weights) == 0 {
+			return &domain.ValidationError{Field: "weights", Message: "weighted_mean requires weights"}
+		}
+	case StrategyStacking:
+		if c.MetaModel == "" {
+			return &domain.ValidationError{Field: "meta_model", Message: "stacking requires a meta_model"}
+		}
+		if err := domain.ValidateModelID("meta_model", c.MetaModel); err != nil {
+			return err
+		}
+		if c.MetaModel == c.ID {
+			return &domain.ValidationError{Field: "meta_model", Message: "an ensemble cannot stack into itself"}
+		}
+	default:
+		return &domain.ValidationError{
+			Field:   "strategy",
+			Message: fmt.Sprintf("unknown strategy %q (expected mean, weighted_mean, vote or stacking)", c.Strategy),
+		}
+	}
+
+	return nil
+}
+
+// Resolver looks up member models. Members are resolved on every call so
+// that an ensemble always fans out to whatever is currently registered.
+type Resolver interface {
+	Get(id string) (domain.ModelPredictor, error)
+}
+
+type Ensemble struct {
+	config   Config
+	resolver Resolver
+}
+
+func New(config Config, resolver Resolver) (*Ensemble, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	for _, id := range config.dependencies() {
+		if _, err := resolver.Get(id); err != nil {
+			return nil, err
+		}
+	}
+	if err := checkCycles(config, resolver); err != nil {
+		return nil, err
+	}
+
+	return &Ensemble{config: config, resolver: resolver}, nil
+}
+
+// dependencies lists the models an ensemble calls: its members and, for
+// stacking, the meta model.
+func (c *Config) dependencies() []string {
+	if c.Strategy != StrategyStacking {
+		return c.Members
+	}
+	return append(append([]string{}, c.Members...), c.MetaModel)
+}
+
+// checkCycles walks the registered ensembles reachable from config and
+// rejects it if one of them calls config's id, which would recurse forever.
+// Such a cycle is closed by registering an ensemble in place of a model
+// that other ensembles already use, e.g. on a restore with overwrite.
+func checkCycles(config Config, resolver Resolver) error {
+	visited := make(map[string]bool)
+	var walk func(path []string, id string) error
+	walk = func(path []string, id string) error {
+		path = append(path[:len(path):len(path)], id)
+		if id == config.ID {
+			return &domain.ValidationError{
+				Field:   "members",
+				Message: fmt.Sprintf("ensemble would call itself: %s", strings.Join(path, " -> ")),
+			}
+		}
+		if visited[id] {
+			return nil
+		}
+		visited[id] = true
+
+		model, err := resolver.Get(id)
+		if err != nil {
+			return nil // only registered ensembles have members
+		}
+		e, ok := model.(*Ensemble)
+		if !ok {
+			return nil
+		}
+		for _, dep := range e.config.dependencies() {
+			if err := walk(path, dep); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for _, id := range config.dependencies() {
+		if err := walk([]string{config.ID}, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *Ensemble) Config() Config {
+	return e.config
+}
+
+func (e *Ensemble) Predict(ctx context.Context, features []float64) ([]float64, error) {
+	prediction, _, err := e.PredictDetailed(ctx, features)
+	return prediction, err
+}
+
+func (e *Ensemble) PredictDetailed(ctx context.Context, features []float64) ([]float64, []domain.MemberResult, error) {
+	results := e.fanOut(ctx, features)
+
+	// Invalid input is the caller's fault, not a member failure; surface it
+	// unchanged so the handler can answer 400.
+	for _, r := range results {
+		if r.inputErr != nil {
+			return nil, e.report(results), r.inputErr
+		}
+	}
+
+	var prediction []float64
+	var err error
+
+	switch e.config.Strategy {
+	case StrategyMean:
+		prediction, err = e.mean(results, false)
+	case StrategyWeightedMean:
+		prediction, err = e.mean(results, true)
+	case StrategyVote:
+		prediction, err = e.vote(results)
+	case StrategyStacking:
+		prediction, err = e.stack(ctx, results)
+	}
+
+	if err != nil {
+		return nil, e.report(results), &domain.PredictionError{ModelID: e.config.ID, Cause: err}
+	}
+	return prediction, e.report(results), nil
+}
+
+func (e *Ensemble) Metadata() domain.ModelMetadata {
+	return domain.ModelMetadata{
+		ID:      e.config.ID,
+		Name:    e.config.Name,
+		Version: e.config.Version,
+		Format:  Format,
+	}
+}
+
+// Close is a no-op: members are owned by the registry, not the ensemble.
+func (e *Ensemble) Close() error {
+	return nil
+}
+
+type memberResult struct {
+	index      int
+	modelID    string
+	latency    time.Duration
+	prediction []float64
+	err        error
+	inputErr   *domain.InvalidInputError
+}
+
+func (e *Ensemble) fanOut(ctx context.Context, features []float64) []memberResult {
+	results := make([]memberResult, len(e.config.Members))
+
+	var wg sync.WaitGroup
+	for i, id := range e.config.Members {
+		wg.Add(1)
+		go func(i int, id string) {
+			defer wg.Done()
+
+			r := memberResult{index: i, modelID: id}
+			start := time.Now()
+
+			model, err := e.resolver.Get(id)
+			if err == nil {
+				r.prediction, err = model.Predict(ctx, features)
+			}
+			r.latency = time.Since(start)
+			r.err = err
+
+			if inputErr, ok := err.(*domain.InvalidInputError); ok {
+				r.inputErr = inputErr
+			}
+			results[i] = r
+		}(i, id)
+	}
+	wg.Wait()
+
+	return results
+}
+
+func (e *Ensemble) report(results []memberResult) []domain.MemberResult {
+	out := make([]domain.MemberResult, len(results))
+	for i, r := range results {
+		out[i] = domain.MemberResult{
+			ModelID:    r.modelID,
+			LatencyMs:  float64(r.latency.Microseconds()) / 1000,
+			Prediction: r.prediction,
+		}
+		if r.err != nil {
+			out[i].Error = r.err.Error()
+		}
+	}
+	return out
+}
+
+func (e *Ensemble) weight(index int) float64 {
+	if len(e.config.Weights) == 0 {
+		return 1
+	}
+	return e.config.Weights[index]
+}
+
+// successful returns the member results that produced a prediction, and
+// checks they all agree on output length.
+func successful(results []memberResult) ([]memberResult, error) {
+	var ok []memberResult
+	for _, r := range results {
+		if r.err == nil {
+			ok = append(ok, r)
+		}
+	}
+
+	if len(ok) == 0 {
+		return nil, fmt.Errorf("all %d members failed", len(results))
+	}
+
+	width := len(ok[0].prediction)
+	for _, r := range ok[1:] {
+		if len(r.prediction) != width {
+			return nil, fmt.Errorf("member %s returned %d values, member %s returned %d",
+				ok[0].modelID, width, r.modelID, len(r.prediction))
+		}
+	}
+	return ok, nil
+}
+
+func (e *Ensemble) mean(results []memberResult, weighted bool) ([]float64, error) {
+	ok, err := successful(results)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]float64, len(ok[0].prediction))
+	total := 0.0
+	for _, r := range ok {
+		w := 1.0
+		if weighted {
+			w = e.weight(r.index)
+		}
+		total += w
+		for j, v := range r.prediction {
+			out[j] += w * v
+		}
+	}
+
+	if total == 0 {
+		return nil, fmt.Errorf("weights of the successful members sum to zero")
+	}
+	for j := range out {
+		out[j] /= total
+	}
+	return out, nil
+}
+
+// vote takes a (weighted) majority per output position. Ties go to the
+// smallest label so results are deterministic.
+func (e *Ensemble) vote(results []memberResult) ([]float64, error) {
+	ok, err := successful(results)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]float64, len(ok[0].prediction))
+	for j := range out {
+		tally := make(map[float64]float64)
+		for _, r := range ok {
+			tally[math.Round(r.prediction[j])] += e.weight(r.index)
+		}
+
+		labels := make([]float64, 0, len(tally))
+		for label := range tally {
+			labels = append(labels, label)
+		}
+		sort.Float64s(labels)
+
+		best := labels[0]
+		for _, label := range labels[1:] {
+			if tally[label] > tally[best] {
+				best = label
+			}
+		}
+		out[j] = best
+	}
+	return out, nil
+}
+
+// stack feeds the concatenated member outputs, in member order, to the
+// meta model. Every member must succeed because the meta model expects a
+// fixed feature layout.
+func (e *Ensemble) stack(ctx context.Context, results []memberResult) ([]float64, error) {
+	var stacked []float64
+	for _, r := range results {
+		if r.err != nil {
+			return nil, fmt.Errorf("member %s failed: %w", r.modelID, r.err)
+		}
+		stacked = append(stacked, r.prediction...)
+	}
+
+	meta, err := e.resolver.Get(e.config.MetaModel)
+	if err != nil {
+		return nil, err
+	}
+
+	prediction, err := meta.Predict(ctx, stacked)
+	if err != nil {
+		return nil, fmt.Errorf("meta model %s failed: %w", e.config.MetaModel, err)
+	}
+	return prediction, nil
+}
@@ -0,0 +1,73 @@
+package ensemble
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/kevo-1/model-nexus/internal/domain"
+	"github.com/kevo-1/model-nexus/pkg/onnx"
+)
+
+// models resolves ids from a map, like the registry does.
+type models map[string]domain.ModelPredictor
+
+func (m models) Get(id string) (domain.ModelPredictor, error) {
+	if p, ok := m[id]; ok {
+		return p, nil
+	}
+	return nil, &domain.ModelNotFoundError{ModelID: id}
+}
+
+func TestNewRejectsCycles(t *testing.T) {
+	reg := models{
+		"m": onnx.NewDummyPredictor("m", "m", "1", "m.onnx"),
+		"n": onnx.NewDummyPredictor("n", "n", "1", "n.onnx"),
+	}
+	register := func(config Config) {
+		t.Helper()
+		e, err := New(config, reg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		reg[config.ID] = e
+	}
+	register(Config{ID: "e1", Name: "e1", Version: "1", Members: []string{"m", "n"}, Strategy: StrategyMean})
+	register(Config{ID: "e2", Name: "e2", Version: "1", Members: []string{"e1"}, Strategy: StrategyMean})
+
+	tests := []struct {
+		name   string
+		config Config
+		cycle  string // "" if the ensemble is accepted
+	}{
+		{
+			name:   "member cycle",
+			config: Config{ID: "m", Name: "m", Version: "2", Members: []string{"n", "e2"}, Strategy: StrategyMean},
+			cycle:  "m -> e2 -> e1 -> m",
+		},
+		{
+			name:   "meta model cycle",
+			config: Config{ID: "n", Name: "n", Version: "2", Members: []string{"m"}, Strategy: StrategyStacking, MetaModel: "e1"},
+			cycle:  "n -> e1 -> n",
+		},
+		{
+			name:   "diamond",
+			config: Config{ID: "x", Name: "x", Version: "1", Members: []string{"e1", "e2"}, Strategy: StrategyMean},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(tt.config, reg)
+			if tt.cycle == "" {
+				if err != nil {
+					t.Fatalf("New = %v, want an ensemble", err)
+				}
+				return
+			}
+			verr, ok := err.(*domain.ValidationError)
+			if !ok || !strings.Contains(verr.Message, tt.cycle) {
+				t.Fatalf("New = %v, want a validation error naming %s", err, tt.cycle)
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package explain
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+)
+
+// Func evaluates the model output being explained for one feature vector.
+type Func func(ctx context.Context, features []float64) (float64, error)
+
+type Config struct {
+	// Budget is the maximum number of model evaluations.
+	Budget int
+	Seed   uint64
+}
+
+type Result struct {
+	Value        float64
+	BaseValue    float64
+	Attributions []float64
+	Evaluations  int
+	Truncated    bool
+}
+
+// errStop signals that the budget or deadline ran out. Algorithms treat it
+// as "stop sampling and report what we have".
+var errStop = errors.New("sampling stopped")
+
+// maxBackgroundRows bounds how many background rows are mixed into each
+// perturbed sample, so that the budget goes into coverage, not averaging.
+const maxBackgroundRows = 100
+
+// evaluator counts model calls and stops sampling once the budget or the
+// context deadline is reached.
+type evaluator struct {
+	f      Func
+	budget int
+	calls  int
+}
+
+func (e *evaluator) eval(ctx context.Context, features []float64) (float64, error) {
+	if e.calls >= e.budget {
+		return 0, errStop
+	}
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return 0, errStop
+	}
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	e.calls++
+	v, err := e.f(ctx, features)
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return 0, errStop
+	}
+	return v, err
+}
+
+func (e *evaluator) remaining() int {
+	return e.budget - e.calls
+}
+
+// sampleRows picks up to n background rows without replacement.
+func sampleRows(rng *rand.Rand, background [][]float64, n int) [][]float64 {
+	if n >= len(background) {
+		return background
+	}
+	idx := rng.Perm(len(background))[:n]
+	rows := make([][]float64, n)
+	for i, j := range idx {
+		rows[i] = background[j]
+	}
+	return rows
+}
+
+// meanOver evaluates the model on every row and returns the average.
+func meanOver(ctx context.Context, ev *evaluator, rows [][]float64) (float64, error) {
+	sum := 0.0
+	for _, row := range rows {
+		v, err := ev.eval(ctx, row)
+		if err != nil {
+			return 0, err
+		}
+		sum += v
+	}
+	return sum / float64(len(rows)), nil
+}
+
+// baseline evaluates f(x) and the expected value over the background.
+// Both are required for any result, so running out of budget or time here
+// is reported as an error rather than a truncated result.
+func baseline(ctx context.Context, ev *evaluator, x []float64, rows [][]float64) (float64, float64, error) {
+	fx, err := ev.eval(ctx, x)
+	if err != nil {
+		return 0, 0, stopToError(ctx, err)
+	}
+
+	base, err := meanOver(ctx, ev, rows)
+	if err != nil {
+		return 0, 0, stopToError(ctx, err)
+	}
+
+	return fx, base, nil
+}
+
+func stopToError(ctx context.Context, err error) error {
+	if !errors.Is(err, errStop) {
+		return err
+	}
+	if ctx.Err() != nil {
+		return context.DeadlineExceeded
+	}
+	return ErrBudgetTooSmall
+}
+
+// ErrBudgetTooSmall is returned when the budget cannot even cover the
+// prediction and the background expectation.
+var ErrBudgetTooSmall = errors.New("sample budget too small to evaluate the background")
+
+func newRand(seed uint64) *rand.Rand {
+	return rand.New(rand.NewPCG(seed, seed^0x9e3779b97f4a7c15))
+}
@@ -0,0 +1,217 @@
+package explain
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand/v2"
+)
+
+// KernelSHAP estimates Shapley values with the model-agnostic Kernel SHAP
+// method. Coalitions are sampled in proportion to the Shapley kernel, which
+// turns the weighted regression into an ordinary least-squares problem, and
+// each coalition is paired with its complement to reduce variance. The
+// efficiency constraint sum(phi) = f(x) - E[f(b)] is enforced exactly by
+// eliminating the last feature.
+func KernelSHAP(ctx context.Context, f Func, x []float64, background [][]float64, cfg Config) (*Result, error) {
+	rng := newRand(cfg.Seed)
+	ev := &evaluator{f: f, budget: cfg.Budget}
+	m := len(x)
+
+	rows := sampleRows(rng, background, min(maxBackgroundRows, max(1, cfg.Budget/(4*(m+1)))))
+
+	fx, base, err := baseline(ctx, ev, x, rows)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &Result{Value: fx, BaseValue: base, Attributions: make([]float64, m)}
+	delta := fx - base
+
+	if m == 1 {
+		res.Attributions[0] = delta
+		res.Evaluations = ev.calls
+		return res, nil
+	}
+
+	sizes := kernelSizeWeights(m)
+	var coalitions [][]bool
+	var values []float64
+
+	for {
+		if ev.remaining() < 2*len(rows) {
+			break
+		}
+
+		z := sampleCoalition(rng, sizes, m)
+		v, err := coalitionValue(ctx, ev, x, rows, z)
+		if err == nil {
+			coalitions = append(coalitions, z)
+			values = append(values, v)
+
+			comp := complement(z)
+			v, err = coalitionValue(ctx, ev, x, rows, comp)
+			if err == nil {
+				coalitions = append(coalitions, comp)
+				values = append(values, v)
+			}
+		}
+		if errors.Is(err, errStop) {
+			res.Truncated = true
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	res.Evaluations = ev.calls
+	if len(coalitions) == 0 {
+		res.Truncated = true
+		return res, nil
+	}
+
+	// Regress y = v(z) - base - z_m*delta on columns (z_j - z_m), j < m.
+	k := m - 1
+	ata := make([][]float64, k)
+	for i := range ata {
+		ata[i] = make([]float64, k)
+	}
+	aty := make([]float64, k)
+
+	for s, z := range coalitions {
+		zm := indicator(z[m-1])
+		y := values[s] - base - zm*delta
+		for i := 0; i < k; i++ {
+			ai := indicator(z[i]) - zm
+			aty[i] += ai * y
+			for j := 0; j < k; j++ {
+				ata[i][j] += ai * (indicator(z[j]) - zm)
+			}
+		}
+	}
+
+	phi := solve(ata, aty)
+	sum := 0.0
+	for i, p := range phi {
+		res.Attributions[i] = p
+		sum += p
+	}
+	res.Attributions[m-1] = delta - sum
+
+	return res, nil
+}
+
+// kernelSizeWeights returns the cumulative distribution over coalition
+// sizes 1..m-1 implied by the Shapley kernel: p(s) ∝ (m-1) / (s(m-s)).
+func kernelSizeWeights(m int) []float64 {
+	cdf := make([]float64, m-1)
+	total := 0.0
+	for s := 1; s < m; s++ {
+		total += float64(m-1) / float64(s*(m-s))
+		cdf[s-1] = total
+	}
+	for i := range cdf {
+		cdf[i] /= total
+	}
+	return cdf
+}
+
+func sampleCoalition(rng *rand.Rand, cdf []float64, m int) []bool {
+	u := rng.Float64()
+	size := 1
+	for i, c := range cdf {
+		if u <= c {
+			size = i + 1
+			break
+		}
+	}
+
+	z := make([]bool, m)
+	for _, j := range rng.Perm(m)[:size] {
+		z[j] = true
+	}
+	return z
+}
+
+func complement(z []bool) []bool {
+	out := make([]bool, len(z))
+	for i, v := range z {
+		out[i] = !v
+	}
+	return out
+}
+
+// coalitionValue is E_b[f(x_z ∪ b_~z)]: features in the coalition come from
+// x, the rest from each background row in turn.
+func coalitionValue(ctx context.Context, ev *evaluator, x []float64, rows [][]float64, z []bool) (float64, error) {
+	mixed := make([]float64, len(x))
+	sum := 0.0
+	for _, row := range rows {
+		for j := range x {
+			if z[j] {
+				mixed[j] = x[j]
+			} else {
+				mixed[j] = row[j]
+			}
+		}
+		v, err := ev.eval(ctx, mixed)
+		if err != nil {
+			return 0, err
+		}
+		sum += v
+	}
+	return sum / float64(len(rows)), nil
+}
+
+func indicator(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// solve solves the small symmetric system a·x = b by Gaussian elimination
+// with partial pivoting. A tiny ridge term keeps it well-posed when some
+// features never varied in the sampled coalitions.
+func solve(a [][]float64, b []float64) []float64 {
+	n := len(b)
+	for i := 0; i < n; i++ {
+		a[i][i] += 1e-9
+	}
+
+	for col := 0; col < n; col++ {
+		pivot := col
+		for r := col + 1; r < n; r++ {
+			if math.Abs(a[r][col]) > math.Abs(a[pivot][col]) {
+				pivot = r
+			}
+		}
+		a[col], a[pivot] = a[pivot], a[col]
+		b[col], b[pivot] = b[pivot], b[col]
+
+		if a[col][col] == 0 {
+			continue
+		}
+		for r := col + 1; r < n; r++ {
+			factor := a[r][col] / a[col][col]
+			for c := col; c < n; c++ {
+				a[r][c] -= factor * a[col][c]
+			}
+			b[r] -= factor * b[col]
+		}
+	}
+
+	x := make([]float64, n)
+	for i := n - 1; i >= 0; i-- {
+		if a[i][i] == 0 {
+			continue
+		}
+		sum := b[i]
+		for c := i + 1; c < n; c++ {
+			sum -= a[i][c] * x[c]
+		}
+		x[i] = sum / a[i][i]
+	}
+	return x
+}
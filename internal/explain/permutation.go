@@ -0,0 +1,60 @@
+package explain
+
+import (
+	"context"
+	"errors"
+)
+
+// Permutation attributes the prediction by replacing one feature at a time
+// with values drawn from the background and measuring how much the output
+// moves on average: attribution_j = f(x) - E_b[f(x with x_j = b_j)].
+func Permutation(ctx context.Context, f Func, x []float64, background [][]float64, cfg Config) (*Result, error) {
+	rng := newRand(cfg.Seed)
+	ev := &evaluator{f: f, budget: cfg.Budget}
+	m := len(x)
+
+	rows := sampleRows(rng, background, min(maxBackgroundRows, max(1, cfg.Budget/(m+2))))
+
+	fx, base, err := baseline(ctx, ev, x, rows)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &Result{Value: fx, BaseValue: base, Attributions: make([]float64, m)}
+
+	perFeature := min(len(rows), ev.remaining()/m)
+	if perFeature == 0 {
+		res.Truncated = true
+		res.Evaluations = ev.calls
+		return res, nil
+	}
+
+	perturbed := make([]float64, m)
+	for j := 0; j < m; j++ {
+		copy(perturbed, x)
+		sum := 0.0
+		n := 0
+		for _, row := range rows[:perFeature] {
+			perturbed[j] = row[j]
+			v, err := ev.eval(ctx, perturbed)
+			if errors.Is(err, errStop) {
+				res.Truncated = true
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+			sum += v
+			n++
+		}
+		if n > 0 {
+			res.Attributions[j] = fx - sum/float64(n)
+		}
+		if res.Truncated {
+			break
+		}
+	}
+
+	res.Evaluations = ev.calls
+	return res, nil
+}
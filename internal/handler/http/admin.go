@@ -0,0 +1,33 @@
+package http
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/kevo-1/model-nexus/internal/logger"
+)
+
+// requireAdmin only passes requests carrying the admin token as a bearer
+// credential. Without a configured token the route is disabled.
+func (h *Handler) requireAdmin(next http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if h.adminToken == "" {
+			http.Error(w, "admin endpoints are disabled: no admin token is configured", http.StatusForbidden)
+			return
+		}
+
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(h.adminToken)) != 1 {
+			logger.Warn("admin request rejected",
+				"request_id", logger.GetRequestID(r.Context()),
+				"path", r.URL.Path,
+			)
+			w.Header().Set("WWW-Authenticate", `Bearer realm="admin"`)
+			http.Error(w, "a valid admin token is required", http.StatusUnauthorized)
+			return
+		}
+
+		next(w, r)
+	})
+}
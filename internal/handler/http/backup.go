@@ -0,0 +1,79 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/kevo-1/model-nexus/internal/domain"
+	"github.com/kevo-1/model-nexus/internal/logger"
+	"github.com/kevo-1/model-nexus/internal/service"
+)
+
+const maxBackupSize = 16 << 30 // 16 GB
+
+// handleBackup streams a backup of the whole registry: GET /admin/backup.
+func (h *Handler) handleBackup(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	requestID := logger.GetRequestID(r.Context())
+	backup := h.backupService.Backup(r.Context())
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", backup.Filename()))
+	w.WriteHeader(http.StatusOK)
+
+	// The status is sent; a failure can only cut the archive short
+	if err := backup.Write(w); err != nil {
+		logger.Error("registry backup interrupted",
+			"request_id", requestID,
+			"error", err,
+		)
+	}
+}
+
+// handleRestore restores a backup sent as the request body:
+// POST /admin/restore?conflict=skip|overwrite|rename.
+func (h *Handler) handleRestore(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	requestID := logger.GetRequestID(r.Context())
+
+	policy, err := service.ParseConflictPolicy(r.URL.Query().Get("conflict"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	report, err := h.backupService.Restore(r.Context(), http.MaxBytesReader(w, r.Body, maxBackupSize), policy)
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		http.Error(w, fmt.Sprintf("backups are limited to %d bytes", int64(maxBackupSize)), http.StatusRequestEntityTooLarge)
+		return
+	}
+	if err != nil {
+		switch e := err.(type) {
+		case *domain.ValidationError:
+			http.Error(w, e.Error(), http.StatusBadRequest)
+		default:
+			logger.Error("registry restore failed",
+				"request_id", requestID,
+				"error_type", fmt.Sprintf("%T", err),
+				"error", err,
+			)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(report)
+}
@@ -0,0 +1,42 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/kevo-1/model-nexus/internal/domain"
+	"github.com/kevo-1/model-nexus/internal/logger"
+)
+
+// handleRotateEncryption re-encrypts stored artifacts with the current key:
+// POST /admin/encryption/rotate. Artifacts that could not be rewritten are
+// listed in the report.
+func (h *Handler) handleRotateEncryption(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	requestID := logger.GetRequestID(r.Context())
+
+	report, err := h.modelService.RotateEncryptionKeys(r.Context())
+	if err != nil {
+		switch e := err.(type) {
+		case *domain.ValidationError:
+			http.Error(w, e.Error(), http.StatusBadRequest)
+		default:
+			logger.Error("unexpected error",
+				"request_id", requestID,
+				"error_type", fmt.Sprintf("%T", err),
+				"error", err,
+			)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(report)
+}
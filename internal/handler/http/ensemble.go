@@ -0,0 +1,57 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/kevo-1/model-nexus/internal/domain"
+	"github.com/kevo-1/model-nexus/internal/ensemble"
+	"github.com/kevo-1/model-nexus/internal/logger"
+)
+
+type EnsembleResponse struct {
+	Model  domain.ModelMetadata `json:"model"`
+	Config ensemble.Config      `json:"config"`
+}
+
+func (h *Handler) handleCreateEnsemble(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	requestID := logger.GetRequestID(r.Context())
+
+	var config ensemble.Config
+	if err := json.NewDecoder(r.Body).Decode(&config); err != nil {
+		logger.Warn("json decode error", "request_id", requestID, "error", err)
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	meta, err := h.modelService.RegisterEnsemble(config)
+	if err != nil {
+		switch err.(type) {
+		case *domain.ValidationError:
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case *domain.ModelNotFoundError:
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case *domain.ModelUnhealthyError:
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		case *domain.ModelAlreadyExistsError:
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			logger.Error("ensemble registration failed",
+				"request_id", requestID,
+				"model_id", config.ID,
+				"error", err,
+			)
+			http.Error(w, "Failed to register ensemble", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(EnsembleResponse{Model: meta, Config: config})
+}
@@ -0,0 +1,103 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/kevo-1/model-nexus/internal/domain"
+	"github.com/kevo-1/model-nexus/internal/logger"
+)
+
+const maxBackgroundSize = 32 << 20 // 32 MB
+
+func (h *Handler) handleExplain(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	requestID := logger.GetRequestID(r.Context())
+
+	var req domain.ExplainRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Warn("json decode error", "request_id", requestID, "error", err)
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	res, err := h.explainService.Explain(r.Context(), req)
+	if err != nil {
+		switch e := err.(type) {
+		case *domain.ValidationError:
+			http.Error(w, e.Error(), http.StatusBadRequest)
+		case *domain.ModelNotFoundError:
+			http.Error(w, e.Error(), http.StatusNotFound)
+		case *domain.ModelUnhealthyError:
+			http.Error(w, e.Error(), http.StatusServiceUnavailable)
+		case *domain.BackgroundNotFoundError:
+			http.Error(w, e.Error(), http.StatusBadRequest)
+		case *domain.InvalidInputError:
+			http.Error(w, e.Error(), http.StatusBadRequest)
+		case *domain.PredictionError:
+			http.Error(w, e.Error(), http.StatusInternalServerError)
+		default:
+			if errors.Is(err, context.DeadlineExceeded) {
+				http.Error(w, "time limit reached before the baseline could be computed", http.StatusGatewayTimeout)
+				return
+			}
+			logger.Error("unexpected error",
+				"request_id", requestID,
+				"error_type", fmt.Sprintf("%T", err),
+				"error", err,
+			)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(res)
+}
+
+func (h *Handler) handleSetBackground(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	requestID := logger.GetRequestID(r.Context())
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxBackgroundSize)
+
+	var req domain.BackgroundRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Warn("json decode error", "request_id", requestID, "error", err)
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.explainService.SetBackground(r.Context(), req); err != nil {
+		switch err.(type) {
+		case *domain.ValidationError:
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case *domain.ModelNotFoundError:
+			http.Error(w, err.Error(), http.StatusNotFound)
+		default:
+			logger.Error("failed to store background dataset",
+				"request_id", requestID,
+				"model_id", req.ModelID,
+				"error", err,
+			)
+			http.Error(w, "Failed to store background dataset", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]any{"model_id": req.ModelID, "rows": len(req.Rows)})
+}
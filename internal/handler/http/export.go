@@ -0,0 +1,55 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/kevo-1/model-nexus/internal/domain"
+	"github.com/kevo-1/model-nexus/internal/logger"
+)
+
+// handleExportModel streams a registered model as a .mnx package that
+// /models/upload accepts: GET /models/{id}/export.
+func (h *Handler) handleExportModel(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	requestID := logger.GetRequestID(r.Context())
+	modelID := r.PathValue("id")
+
+	export, err := h.modelService.ExportPackage(r.Context(), modelID)
+	if err != nil {
+		switch e := err.(type) {
+		case *domain.ValidationError:
+			http.Error(w, e.Error(), http.StatusBadRequest)
+		case *domain.ModelNotFoundError:
+			http.Error(w, e.Error(), http.StatusNotFound)
+		case *domain.ModelUnhealthyError:
+			http.Error(w, e.Error(), http.StatusServiceUnavailable)
+		default:
+			logger.Error("unexpected error",
+				"request_id", requestID,
+				"model_id", modelID,
+				"error_type", fmt.Sprintf("%T", err),
+				"error", err,
+			)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", export.Filename()))
+	w.WriteHeader(http.StatusOK)
+
+	// The status is sent; a failure can only cut the package short
+	if err := export.Write(w); err != nil {
+		logger.Error("model export interrupted",
+			"request_id", requestID,
+			"model_id", modelID,
+			"error", err,
+		)
+	}
+}
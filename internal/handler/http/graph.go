@@ -0,0 +1,43 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/kevo-1/model-nexus/internal/domain"
+	"github.com/kevo-1/model-nexus/internal/logger"
+)
+
+func (h *Handler) handleModelGraph(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	requestID := logger.GetRequestID(r.Context())
+	modelID := r.PathValue("id")
+
+	graph, err := h.modelService.Graph(r.Context(), modelID)
+	if err != nil {
+		switch e := err.(type) {
+		case *domain.ValidationError:
+			http.Error(w, e.Error(), http.StatusBadRequest)
+		case *domain.ModelNotFoundError:
+			http.Error(w, e.Error(), http.StatusNotFound)
+		default:
+			logger.Error("unexpected error",
+				"request_id", requestID,
+				"model_id", modelID,
+				"error_type", fmt.Sprintf("%T", err),
+				"error", err,
+			)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(graph)
+}
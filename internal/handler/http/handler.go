@@ -1,34 +1,105 @@
 package http
 
 import (
+	"context"
 	"net/http"
+	"time"
 
+	"github.com/kevo-1/model-nexus/internal/backend"
+	"github.com/kevo-1/model-nexus/internal/encryption"
 	"github.com/kevo-1/model-nexus/internal/repository"
 	"github.com/kevo-1/model-nexus/internal/service"
+	"github.com/kevo-1/model-nexus/internal/storage"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 type Handler struct {
 	predictionService *service.PredictionService
 	modelService      *service.ModelService
+	uploadService     *service.UploadService
+	explainService    *service.ExplainService
+	backupService     *service.BackupService
 	modelRegistry     *repository.ModelRegistry
+	pipelineRegistry  *repository.PipelineRegistry
+	adminToken        string
 }
 
-func NewHandler(registry *repository.ModelRegistry, modelsDir string) *Handler {
+func NewHandler(registry *repository.ModelRegistry, pipelines *repository.PipelineRegistry, backends *backend.Registry, cache *storage.Cache) *Handler {
+	modelService := service.NewModelService(registry, pipelines, backends, cache)
+	explainService := service.NewExplainService(registry, modelService)
+
 	return &Handler{
-		predictionService: service.NewPredictionService(registry),
-		modelService:      service.NewModelService(registry, modelsDir),
+		predictionService: service.NewPredictionService(registry, pipelines),
+		modelService:      modelService,
+		uploadService:     service.NewUploadService(modelService),
+		explainService:    explainService,
+		backupService:     service.NewBackupService(modelService, explainService),
 		modelRegistry:     registry,
+		pipelineRegistry:  pipelines,
 	}
 }
 
+// StartIntegrityChecks verifies stored model artifacts every interval in
+// the background until ctx is done.
+func (h *Handler) StartIntegrityChecks(ctx context.Context, interval time.Duration) {
+	go h.modelService.RunIntegrityChecks(ctx, interval)
+}
+
+// LoadModels registers the models recorded in artifact storage and returns
+// how many were loaded. It is called once at startup, after encryption and
+// signatures are configured.
+func (h *Handler) LoadModels(ctx context.Context) (int, error) {
+	return h.modelService.LoadModels(ctx)
+}
+
+// AllowModelSources sets where /models/register may read models from.
+func (h *Handler) AllowModelSources(cfg service.SourceConfig) error {
+	return h.modelService.SetSources(cfg)
+}
+
+// RequireSignatures sets the signature policy and trusted keys for models
+// registered through any endpoint.
+func (h *Handler) RequireSignatures(cfg service.SigningConfig) error {
+	return h.modelService.SetSigning(cfg)
+}
+
+// EncryptArtifacts encrypts model artifacts stored through any endpoint
+// with the current key of keys.
+func (h *Handler) EncryptArtifacts(keys *encryption.Keyring) {
+	h.modelService.SetEncryption(keys)
+}
+
+// StageUploadsIn stages uploads, resumable uploads and restores in dir
+// instead of the model cache directory.
+func (h *Handler) StageUploadsIn(dir string) error {
+	return h.modelService.SetStagingDir(dir)
+}
+
+// RequireAdminToken sets the bearer token admin routes require. Without
+// one they are disabled. It must be called before serving requests.
+func (h *Handler) RequireAdminToken(token string) {
+	h.adminToken = token
+}
+
 func (h *Handler) SetupRoutes() http.Handler {
 	mux := http.NewServeMux()
 
 	mux.HandleFunc("/predict", h.handlePredict)
+	mux.HandleFunc("/explain", h.handleExplain)
+	mux.HandleFunc("/sweep", h.handleSweep)
 	mux.HandleFunc("/health", h.handleHealth)
 	mux.HandleFunc("/models/upload", h.handleUploadModel)
+	mux.HandleFunc("/models/validate", h.handleValidateModel)
+	mux.HandleFunc("/models/register", h.handleRegisterModel)
+	mux.HandleFunc("/uploads", h.handleCreateUpload)
+	mux.HandleFunc("/uploads/{upload}", h.handleUpload)
+	mux.HandleFunc("/uploads/{upload}/finalize", h.handleFinalizeUpload)
 	mux.HandleFunc("/models/info", h.handleModelInfo)
+	mux.HandleFunc("/models/background", h.handleSetBackground)
+	mux.HandleFunc("/models/{id}/graph", h.handleModelGraph)
+	mux.HandleFunc("/ensembles", h.handleCreateEnsemble)
+	mux.HandleFunc("/pipelines", h.handlePipelines)
+	mux.HandleFunc("/pipelines/predict", h.handlePipelinePredict)
 	mux.HandleFunc("/models", func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodGet {
 			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -46,8 +117,16 @@ func (h *Handler) SetupRoutes() http.Handler {
 		http.ServeFile(w, r, "index.html")
 	})
 
-	handler := corsMiddleware(mux)
-	handler = RequestIDMiddleware(handler)
+	// Admin routes, and exports that carry decrypted artifacts, require the
+	// admin token and are not shared with other origins
+	routes := http.NewServeMux()
+	routes.Handle("/", corsMiddleware(mux))
+	routes.Handle("/models/{id}/export", h.requireAdmin(h.handleExportModel))
+	routes.Handle("/admin/encryption/rotate", h.requireAdmin(h.handleRotateEncryption))
+	routes.Handle("/admin/backup", h.requireAdmin(h.handleBackup))
+	routes.Handle("/admin/restore", h.requireAdmin(h.handleRestore))
+
+	handler := RequestIDMiddleware(routes)
 	handler = MetricsMiddleware(handler)
 
 	return handler
@@ -56,8 +135,9 @@ func (h *Handler) SetupRoutes() http.Handler {
 func corsMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
 		w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+		w.Header().Set("Access-Control-Expose-Headers", "Location, Upload-Offset")
 
 		if r.Method == "OPTIONS" {
 			w.WriteHeader(http.StatusOK)
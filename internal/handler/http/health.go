@@ -3,12 +3,16 @@ package http
 import (
 	"encoding/json"
 	"net/http"
+	"sort"
 	"time"
 )
 
 type HealthResponse struct {
 	Status    string    `json:"status"`
 	Timestamp time.Time `json:"timestamp"`
+	// UnhealthyModels lists models withheld from serving; the server itself
+	// stays up, so the status code is still 200.
+	UnhealthyModels []string `json:"unhealthy_models,omitempty"`
 }
 
 func (h *Handler) handleHealth(w http.ResponseWriter, r *http.Request) {
@@ -17,6 +21,12 @@ func (h *Handler) handleHealth(w http.ResponseWriter, r *http.Request) {
 		Timestamp: time.Now(),
 	}
 
+	if unhealthy := h.modelRegistry.Unhealthy(); len(unhealthy) > 0 {
+		sort.Strings(unhealthy)
+		response.Status = "degraded"
+		response.UnhealthyModels = unhealthy
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(response)
@@ -4,16 +4,29 @@ import (
 	"encoding/json"
 	"net/http"
 
+	"github.com/kevo-1/model-nexus/internal/domain"
+	"github.com/kevo-1/model-nexus/internal/ensemble"
 	"github.com/kevo-1/model-nexus/internal/logger"
+	"github.com/kevo-1/model-nexus/internal/mnx"
 	"github.com/kevo-1/model-nexus/pkg/onnx"
 )
 
 type ModelInfoResponse struct {
-	ID      string            `json:"id"`
-	Name    string            `json:"name"`
-	Version string            `json:"version"`
-	Inputs  []onnx.TensorInfo `json:"inputs"`
-	Outputs []onnx.TensorInfo `json:"outputs"`
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	onnx.ProtoMetadata
+	Inputs  []onnx.TensorInfo  `json:"inputs"`
+	Outputs []onnx.TensorInfo  `json:"outputs"`
+	Lint    []onnx.LintFinding `json:"lint,omitempty"`
+
+	// Set for models uploaded as .mnx packages
+	Description   string          `json:"description,omitempty"`
+	Tags          []string        `json:"tags,omitempty"`
+	Features      []mnx.Feature   `json:"features,omitempty"`
+	Preprocessing json.RawMessage `json:"preprocessing,omitempty"`
+
+	Ensemble *ensemble.Config `json:"ensemble,omitempty"`
 }
 
 func (h *Handler) handleModelInfo(w http.ResponseWriter, r *http.Request) {
@@ -30,8 +43,14 @@ func (h *Handler) handleModelInfo(w http.ResponseWriter, r *http.Request) {
 		json.NewEncoder(w).Encode(map[string]string{"error": "model ID is required"})
 		return
 	}
+	if err := domain.ValidateModelID("id", modelID); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
 
-	predictor, err := h.modelRegistry.Get(modelID)
+	predictor, err := h.modelRegistry.Lookup(modelID)
 	if err != nil {
 		logger.Warn("model info: model not found", "model_id", modelID, "error", err)
 		w.Header().Set("Content-Type", "application/json")
@@ -56,11 +75,25 @@ func (h *Handler) handleModelInfo(w http.ResponseWriter, r *http.Request) {
 	if ip, ok := predictor.(InfoProvider); ok {
 		info := ip.ModelInfo()
 		if info != nil {
+			resp.ProtoMetadata = info.ProtoMetadata
 			resp.Inputs = info.Inputs
 			resp.Outputs = info.Outputs
+			resp.Lint = info.Lint
 		}
 	}
 
+	if pkg := h.modelService.Package(modelID); pkg != nil {
+		resp.Description = pkg.Description
+		resp.Tags = pkg.Tags
+		resp.Features = pkg.Features
+		resp.Preprocessing = pkg.Preprocessing
+	}
+
+	if e, ok := predictor.(*ensemble.Ensemble); ok {
+		config := e.Config()
+		resp.Ensemble = &config
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(resp)
 }
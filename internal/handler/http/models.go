@@ -6,10 +6,16 @@ import (
 )
 
 type ModelDetail struct {
-	ID      string `json:"id"`
-	Name    string `json:"name"`
-	Version string `json:"version"`
-	Path    string `json:"path"`
+	ID           string   `json:"id"`
+	Name         string   `json:"name"`
+	Version      string   `json:"version"`
+	Path         string   `json:"path"`
+	Format       string   `json:"format,omitempty"`
+	Digest       string   `json:"digest,omitempty"`
+	Tags         []string `json:"tags,omitempty"`
+	SigningKeyID string   `json:"signing_key_id,omitempty"`
+	Healthy      bool     `json:"healthy"`
+	Problem      string   `json:"problem,omitempty"`
 }
 
 type ModelsResponse struct {
@@ -22,16 +28,27 @@ func (h *Handler) handleListModels(w http.ResponseWriter, r *http.Request) {
 
 	details := make([]ModelDetail, 0, len(modelIDs))
 	for _, id := range modelIDs {
-		predictor, err := h.modelRegistry.Get(id)
+		predictor, err := h.modelRegistry.Lookup(id)
 		if err != nil {
 			continue
 		}
 		meta := predictor.Metadata()
+		problem := h.modelRegistry.Health(id)
+		var tags []string
+		if pkg := h.modelService.Package(id); pkg != nil {
+			tags = pkg.Tags
+		}
 		details = append(details, ModelDetail{
-			ID:      meta.ID,
-			Name:    meta.Name,
-			Version: meta.Version,
-			Path:    meta.Path,
+			ID:           meta.ID,
+			Name:         meta.Name,
+			Version:      meta.Version,
+			Path:         meta.Path,
+			Format:       meta.Format,
+			Digest:       h.modelService.Digest(id),
+			Tags:         tags,
+			SigningKeyID: h.modelService.SigningKey(id),
+			Healthy:      problem == "",
+			Problem:      problem,
 		})
 	}
 
@@ -0,0 +1,109 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/kevo-1/model-nexus/internal/domain"
+	"github.com/kevo-1/model-nexus/internal/logger"
+	"github.com/kevo-1/model-nexus/internal/pipeline"
+)
+
+type PipelinesResponse struct {
+	Pipelines []pipeline.Definition `json:"pipelines"`
+	Count     int                   `json:"count"`
+}
+
+func (h *Handler) handlePipelines(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		defs := h.pipelineRegistry.List()
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(PipelinesResponse{Pipelines: defs, Count: len(defs)})
+	case http.MethodPost:
+		h.handleCreatePipeline(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *Handler) handleCreatePipeline(w http.ResponseWriter, r *http.Request) {
+	requestID := logger.GetRequestID(r.Context())
+
+	var def pipeline.Definition
+	if err := json.NewDecoder(r.Body).Decode(&def); err != nil {
+		logger.Warn("json decode error", "request_id", requestID, "error", err)
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.modelService.RegisterPipeline(def); err != nil {
+		switch err.(type) {
+		case *domain.ValidationError:
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case *domain.ModelNotFoundError:
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case *domain.PipelineAlreadyExistsError:
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			logger.Error("pipeline registration failed",
+				"request_id", requestID,
+				"pipeline_id", def.ID,
+				"error", err,
+			)
+			http.Error(w, "Failed to register pipeline", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(def)
+}
+
+func (h *Handler) handlePipelinePredict(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	requestID := logger.GetRequestID(r.Context())
+
+	var req domain.PipelineRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Warn("json decode error", "request_id", requestID, "error", err)
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	res, err := h.predictionService.PredictPipeline(r.Context(), req)
+	if err != nil {
+		switch e := err.(type) {
+		case *domain.ValidationError:
+			http.Error(w, e.Error(), http.StatusBadRequest)
+		case *domain.PipelineNotFoundError:
+			http.Error(w, e.Error(), http.StatusNotFound)
+		case *domain.ModelNotFoundError:
+			http.Error(w, e.Error(), http.StatusNotFound)
+		case *domain.ModelUnhealthyError:
+			http.Error(w, e.Error(), http.StatusServiceUnavailable)
+		case *domain.InvalidInputError:
+			http.Error(w, e.Error(), http.StatusBadRequest)
+		case *domain.PredictionError:
+			http.Error(w, e.Error(), http.StatusInternalServerError)
+		default:
+			logger.Error("unexpected error",
+				"request_id", requestID,
+				"error_type", fmt.Sprintf("%T", err),
+				"error", err,
+			)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(res)
+}
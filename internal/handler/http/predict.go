@@ -37,9 +37,15 @@ func (h *Handler) handlePredict(w http.ResponseWriter, r *http.Request) {
 		case *domain.ModelNotFoundError:
 			http.Error(w, e.Error(), http.StatusNotFound)
 			return
+		case *domain.ModelUnhealthyError:
+			http.Error(w, e.Error(), http.StatusServiceUnavailable)
+			return
 		case *domain.InvalidInputError:
 			http.Error(w, e.Error(), http.StatusBadRequest)
 			return
+		case *domain.ShapeMismatchError:
+			http.Error(w, e.Error(), http.StatusBadRequest)
+			return
 		case *domain.PredictionError:
 			logger.Error("prediction failed",
 				"request_id", requestID,
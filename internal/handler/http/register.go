@@ -0,0 +1,58 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/kevo-1/model-nexus/internal/domain"
+	"github.com/kevo-1/model-nexus/internal/logger"
+	"github.com/kevo-1/model-nexus/internal/service"
+	"github.com/kevo-1/model-nexus/pkg/onnx"
+)
+
+// handleRegisterModel registers a model from a server-side file or URL:
+// POST /models/register {"id", "name", "version", "source", "sha256"}.
+func (h *Handler) handleRegisterModel(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	requestID := logger.GetRequestID(r.Context())
+
+	var req service.RegisterSourceRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 64<<10)).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	res, err := h.modelService.RegisterFromSource(r.Context(), req)
+	if err != nil {
+		switch err.(type) {
+		case *domain.ValidationError, *onnx.ParseError:
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case *domain.ModelAlreadyExistsError:
+			http.Error(w, err.Error(), http.StatusConflict)
+		case *domain.UnsupportedFormatError:
+			http.Error(w, err.Error(), http.StatusUnsupportedMediaType)
+		case *domain.IncompatibleModelError:
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		case *domain.SignatureError:
+			http.Error(w, err.Error(), http.StatusForbidden)
+		case *domain.SourceFetchError:
+			http.Error(w, err.Error(), http.StatusBadGateway)
+		default:
+			logger.Error("model registration failed",
+				"request_id", requestID,
+				"model_id", req.ID,
+				"error", err,
+			)
+			http.Error(w, "Failed to register model", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(res)
+}
@@ -0,0 +1,164 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/kevo-1/model-nexus/internal/domain"
+	"github.com/kevo-1/model-nexus/internal/logger"
+	"github.com/kevo-1/model-nexus/internal/service"
+	"github.com/kevo-1/model-nexus/pkg/onnx"
+)
+
+const maxChunkSize = 64 << 20 // 64 MB
+
+// handleCreateUpload opens a resumable upload:
+// POST /uploads {"id", "name", "version", "filename", "size", "sha256"}.
+func (h *Handler) handleCreateUpload(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req service.CreateUploadRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 64<<10)).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	status, err := h.uploadService.Create(req)
+	if err != nil {
+		writeUploadError(w, r, req.ID, err)
+		return
+	}
+
+	w.Header().Set("Location", "/uploads/"+status.UploadID)
+	writeUploadStatus(w, http.StatusCreated, status)
+}
+
+// handleUpload serves one resumable upload: GET reports progress, PUT
+// ?offset=N appends a chunk and DELETE aborts it.
+func (h *Handler) handleUpload(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("upload")
+
+	var (
+		status *service.UploadStatus
+		err    error
+	)
+	switch r.Method {
+	case http.MethodGet:
+		status, err = h.uploadService.Status(id)
+	case http.MethodPut:
+		offset, perr := strconv.ParseInt(r.URL.Query().Get("offset"), 10, 64)
+		if perr != nil || offset < 0 {
+			http.Error(w, "offset query parameter must be a non-negative integer", http.StatusBadRequest)
+			return
+		}
+		status, err = h.uploadService.WriteChunk(id, offset, http.MaxBytesReader(w, r.Body, maxChunkSize))
+	case http.MethodDelete:
+		if err := h.uploadService.Abort(id); err != nil {
+			writeUploadError(w, r, "", err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if err != nil {
+		writeUploadError(w, r, "", err)
+		return
+	}
+
+	writeUploadStatus(w, http.StatusOK, status)
+}
+
+// handleFinalizeUpload registers the assembled model:
+// POST /uploads/{upload}/finalize {"sha256"}.
+func (h *Handler) handleFinalizeUpload(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		SHA256 string `json:"sha256"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 64<<10)).Decode(&req); err != nil {
+			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+	}
+
+	res, err := h.uploadService.Finalize(r.Context(), r.PathValue("upload"), req.SHA256)
+	if err != nil {
+		writeUploadError(w, r, "", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(res)
+}
+
+func writeUploadStatus(w http.ResponseWriter, code int, status *service.UploadStatus) {
+	w.Header().Set("Upload-Offset", strconv.FormatInt(status.Offset, 10))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(status)
+}
+
+// writeUploadError maps resumable upload and registration errors to
+// responses. Conflicts carry the offset the next chunk must start at.
+func writeUploadError(w http.ResponseWriter, r *http.Request, modelID string, err error) {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		http.Error(w, fmt.Sprintf("chunks are limited to %d bytes", int64(maxChunkSize)), http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	switch e := err.(type) {
+	case *domain.ValidationError, *onnx.ParseError:
+		http.Error(w, e.Error(), http.StatusBadRequest)
+	case *domain.UploadNotFoundError:
+		http.Error(w, e.Error(), http.StatusNotFound)
+	case *domain.UploadConflictError:
+		w.Header().Set("Upload-Offset", strconv.FormatInt(e.Offset, 10))
+		http.Error(w, e.Error(), http.StatusConflict)
+	case *domain.ModelAlreadyExistsError:
+		http.Error(w, e.Error(), http.StatusConflict)
+	case *domain.TooManyUploadsError:
+		http.Error(w, e.Error(), http.StatusTooManyRequests)
+	case *domain.UnsupportedFormatError:
+		http.Error(w, e.Error(), http.StatusUnsupportedMediaType)
+	case *domain.IncompatibleModelError:
+		http.Error(w, e.Error(), http.StatusUnprocessableEntity)
+	case *domain.SignatureError:
+		http.Error(w, e.Error(), http.StatusForbidden)
+	default:
+		if r.Method == http.MethodPut {
+			// Usually the client went away mid-chunk; the bytes received
+			// so far are kept
+			logger.Warn("upload chunk interrupted",
+				"request_id", logger.GetRequestID(r.Context()),
+				"upload_id", r.PathValue("upload"),
+				"error", err,
+			)
+			http.Error(w, "Chunk interrupted; query the upload for the offset to resume from", http.StatusInternalServerError)
+			return
+		}
+		logger.Error("resumable upload failed",
+			"request_id", logger.GetRequestID(r.Context()),
+			"upload_id", r.PathValue("upload"),
+			"model_id", modelID,
+			"error_type", fmt.Sprintf("%T", err),
+			"error", err,
+		)
+		http.Error(w, "Upload failed", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,69 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/kevo-1/model-nexus/internal/domain"
+	"github.com/kevo-1/model-nexus/internal/logger"
+)
+
+// statusClientClosedRequest is the non-standard status logged for requests
+// the client abandoned; the client never sees it.
+const statusClientClosedRequest = 499
+
+func (h *Handler) handleSweep(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	requestID := logger.GetRequestID(r.Context())
+
+	var req domain.SweepRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Warn("json decode error", "request_id", requestID, "error", err)
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	res, err := h.predictionService.Sweep(r.Context(), req)
+	if err != nil {
+		switch e := err.(type) {
+		case *domain.ValidationError:
+			http.Error(w, e.Error(), http.StatusBadRequest)
+		case *domain.ModelNotFoundError:
+			http.Error(w, e.Error(), http.StatusNotFound)
+		case *domain.ModelUnhealthyError:
+			http.Error(w, e.Error(), http.StatusServiceUnavailable)
+		case *domain.InvalidInputError:
+			http.Error(w, e.Error(), http.StatusBadRequest)
+		case *domain.PredictionError:
+			http.Error(w, e.Error(), http.StatusInternalServerError)
+		default:
+			// The client went away or its deadline passed mid-sweep
+			if errors.Is(err, context.Canceled) {
+				http.Error(w, "request canceled", statusClientClosedRequest)
+				return
+			}
+			if errors.Is(err, context.DeadlineExceeded) {
+				http.Error(w, "request timed out", http.StatusRequestTimeout)
+				return
+			}
+			logger.Error("unexpected error",
+				"request_id", requestID,
+				"error_type", fmt.Sprintf("%T", err),
+				"error", err,
+			)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(res)
+}
@@ -2,11 +2,13 @@ package http
 
 import (
 	"encoding/json"
+	"mime/multipart"
 	"net/http"
 
 	"github.com/kevo-1/model-nexus/internal/domain"
 	"github.com/kevo-1/model-nexus/internal/logger"
 	"github.com/kevo-1/model-nexus/internal/service"
+	"github.com/kevo-1/model-nexus/pkg/onnx"
 )
 
 const maxUploadSize = 500 << 20 // 500 MB
@@ -19,58 +21,36 @@ func (h *Handler) handleUploadModel(w http.ResponseWriter, r *http.Request) {
 
 	requestID := logger.GetRequestID(r.Context())
 
-	// Limit request body size before parsing
-	r.Body = http.MaxBytesReader(w, r.Body, maxUploadSize)
-
-	if err := r.ParseMultipartForm(32 << 20); err != nil { // 32MB in memory, rest on disk
-		logger.Warn("failed to parse multipart form", "request_id", requestID, "error", err)
-		http.Error(w, "Failed to parse form: request may be too large or malformed", http.StatusBadRequest)
-		return
-	}
-
-	// Extract metadata fields from form
-	modelID := r.FormValue("id")
-	modelName := r.FormValue("name")
-	modelVersion := r.FormValue("version")
-
-	if modelID == "" || modelName == "" || modelVersion == "" {
-		http.Error(w, "id, name, and version form fields are required", http.StatusBadRequest)
-		return
-	}
-
-	// Extract the file
-	file, header, err := r.FormFile("file")
-	if err != nil {
-		logger.Warn("failed to get model file from form", "request_id", requestID, "error", err)
-		http.Error(w, "model file is required (form field: 'file')", http.StatusBadRequest)
+	req, file, ok := parseUploadForm(w, r)
+	if !ok {
 		return
 	}
 	defer file.Close()
 
 	logger.Info("model upload received",
 		"request_id", requestID,
-		"model_id", modelID,
-		"filename", header.Filename,
-		"size_bytes", header.Size,
+		"model_id", req.ID,
+		"filename", req.Filename,
 	)
 
-	res, err := h.modelService.RegisterModel(service.RegisterModelRequest{
-		ID:      modelID,
-		Name:    modelName,
-		Version: modelVersion,
-		File:    file,
-	})
+	res, err := h.modelService.RegisterModel(r.Context(), req)
 
 	if err != nil {
 		switch err.(type) {
-		case *domain.ValidationError:
+		case *domain.ValidationError, *onnx.ParseError:
 			http.Error(w, err.Error(), http.StatusBadRequest)
 		case *domain.ModelAlreadyExistsError:
 			http.Error(w, err.Error(), http.StatusConflict)
+		case *domain.UnsupportedFormatError:
+			http.Error(w, err.Error(), http.StatusUnsupportedMediaType)
+		case *domain.IncompatibleModelError:
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		case *domain.SignatureError:
+			http.Error(w, err.Error(), http.StatusForbidden)
 		default:
 			logger.Error("model registration failed",
 				"request_id", requestID,
-				"model_id", modelID,
+				"model_id", req.ID,
 				"error", err,
 			)
 			http.Error(w, "Failed to register model", http.StatusInternalServerError)
@@ -82,3 +62,37 @@ func (h *Handler) handleUploadModel(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(res)
 }
+
+// parseUploadForm reads the multipart form shared by /models/upload and
+// /models/validate. It writes the error response itself and reports
+// whether the caller should continue; the caller closes the file.
+func parseUploadForm(w http.ResponseWriter, r *http.Request) (service.RegisterModelRequest, multipart.File, bool) {
+	requestID := logger.GetRequestID(r.Context())
+
+	// Limit request body size before parsing
+	r.Body = http.MaxBytesReader(w, r.Body, maxUploadSize)
+
+	if err := r.ParseMultipartForm(32 << 20); err != nil { // 32MB in memory, rest on disk
+		logger.Warn("failed to parse multipart form", "request_id", requestID, "error", err)
+		http.Error(w, "Failed to parse form: request may be too large or malformed", http.StatusBadRequest)
+		return service.RegisterModelRequest{}, nil, false
+	}
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		logger.Warn("failed to get model file from form", "request_id", requestID, "error", err)
+		http.Error(w, "model file is required (form field: 'file')", http.StatusBadRequest)
+		return service.RegisterModelRequest{}, nil, false
+	}
+
+	return service.RegisterModelRequest{
+		ID:           r.FormValue("id"),
+		Name:         r.FormValue("name"),
+		Version:      r.FormValue("version"),
+		Filename:     header.Filename,
+		File:         file,
+		SHA256:       r.FormValue("sha256"),
+		Signature:    r.FormValue("signature"),
+		SigningKeyID: r.FormValue("signing_key_id"),
+	}, file, true
+}
@@ -0,0 +1,49 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/kevo-1/model-nexus/internal/domain"
+	"github.com/kevo-1/model-nexus/internal/logger"
+)
+
+// handleValidateModel accepts the same form as /models/upload and reports
+// whether the model would deploy, without registering or storing it.
+func (h *Handler) handleValidateModel(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	requestID := logger.GetRequestID(r.Context())
+
+	req, file, ok := parseUploadForm(w, r)
+	if !ok {
+		return
+	}
+	defer file.Close()
+
+	report, err := h.modelService.ValidateModel(r.Context(), req)
+	var verr *domain.ValidationError
+	if errors.As(err, &verr) {
+		http.Error(w, verr.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		logger.Error("unexpected error",
+			"request_id", requestID,
+			"filename", req.Filename,
+			"error_type", fmt.Sprintf("%T", err),
+			"error", err,
+		)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(report)
+}
@@ -0,0 +1,253 @@
+// Package mnx reads and writes .mnx model packages: a zip archive holding a
+// model artifact together with the configuration needed to serve it.
+//
+// Layout:
+//
+//	manifest.json    name, version, tags, labels, feature schema, examples
+//	model_info.json  extracted model info; written on export, ignored on import
+//	model/           the model file and any external data files
+package mnx
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/kevo-1/model-nexus/internal/domain"
+)
+
+const (
+	Extension     = ".mnx"
+	FormatVersion = 1
+
+	ManifestFile = "manifest.json"
+	InfoFile     = "model_info.json"
+	ModelDir     = "model"
+
+	maxManifestSize  = 16 << 20 // 16 MB
+	maxExamples      = 100
+	defaultTolerance = 1e-4
+)
+
+// IsPackage reports whether an upload is a model package, based on its
+// filename.
+func IsPackage(filename string) bool {
+	return strings.HasSuffix(strings.ToLower(filename), Extension)
+}
+
+// Manifest describes a packaged model.
+type Manifest struct {
+	FormatVersion int      `json:"format_version"`
+	Name          string   `json:"name"`
+	Version       string   `json:"version"`
+	Description   string   `json:"description,omitempty"`
+	Tags          []string `json:"tags,omitempty"`
+	// Model is the slash-separated path of the model file, under model/.
+	Model string `json:"model"`
+	// Digest is the content digest of the model artifact (see the digest
+	// package); it is checked on import when set.
+	Digest string `json:"digest,omitempty"`
+	// Signature is a detached signature over the model digest; see the
+	// signing package.
+	Signature   *Signature `json:"signature,omitempty"`
+	ClassLabels []string   `json:"class_labels,omitempty"`
+	Features    []Feature  `json:"features,omitempty"`
+	// Preprocessing is client-side configuration stored and returned
+	// verbatim, e.g. scaling applied before features are sent.
+	Preprocessing json.RawMessage `json:"preprocessing,omitempty"`
+	Examples      []Example       `json:"examples,omitempty"`
+}
+
+// Signature is a base64 ed25519 signature and the id of the key that made
+// it, if known.
+type Signature struct {
+	KeyID string `json:"key_id,omitempty"`
+	Value string `json:"value"`
+}
+
+// Feature describes one input feature, in input order.
+type Feature struct {
+	Name        string   `json:"name"`
+	Dtype       string   `json:"dtype,omitempty"`
+	Description string   `json:"description,omitempty"`
+	Min         *float64 `json:"min,omitempty"`
+	Max         *float64 `json:"max,omitempty"`
+}
+
+// Example is a sample request. When Expected is set it is a golden output
+// the model must reproduce within Tolerance (default 1e-4), relative to
+// the magnitude of the expected value and absolute near zero.
+type Example struct {
+	Name      string    `json:"name,omitempty"`
+	Features  []float64 `json:"features"`
+	Expected  []float64 `json:"expected,omitempty"`
+	Tolerance float64   `json:"tolerance,omitempty"`
+}
+
+// FeatureNames returns the declared feature names, or nil.
+func (m *Manifest) FeatureNames() []string {
+	if len(m.Features) == 0 {
+		return nil
+	}
+	names := make([]string, len(m.Features))
+	for i, f := range m.Features {
+		names[i] = f.Name
+	}
+	return names
+}
+
+// Validate checks the manifest fields.
+func (m *Manifest) Validate() error {
+	if m.FormatVersion != FormatVersion {
+		return invalid("format_version", fmt.Sprintf("unsupported format version %d, expected %d", m.FormatVersion, FormatVersion))
+	}
+	if m.Name == "" || m.Version == "" {
+		return invalid("name/version", "name and version are required")
+	}
+	if m.Model == "" || path.Clean(m.Model) != m.Model || !strings.HasPrefix(m.Model, ModelDir+"/") || !filepath.IsLocal(filepath.FromSlash(m.Model)) {
+		return invalid("model", fmt.Sprintf("model must be a clean path under %s/", ModelDir))
+	}
+
+	if m.Signature != nil && m.Signature.Value == "" {
+		return invalid("signature.value", "signature value is required")
+	}
+
+	seen := make(map[string]bool, len(m.Features))
+	for i, f := range m.Features {
+		if f.Name == "" {
+			return invalid(fmt.Sprintf("features[%d].name", i), "feature name is required")
+		}
+		if seen[f.Name] {
+			return invalid(fmt.Sprintf("features[%d].name", i), fmt.Sprintf("duplicate feature %q", f.Name))
+		}
+		seen[f.Name] = true
+		if f.Min != nil && f.Max != nil && *f.Min > *f.Max {
+			return invalid(fmt.Sprintf("features[%d]", i), "min is greater than max")
+		}
+	}
+
+	if len(m.Preprocessing) > 0 && !json.Valid(m.Preprocessing) {
+		return invalid("preprocessing", "preprocessing must be valid JSON")
+	}
+
+	if len(m.Examples) > maxExamples {
+		return invalid("examples", fmt.Sprintf("at most %d examples are allowed", maxExamples))
+	}
+	for i, e := range m.Examples {
+		if len(e.Features) == 0 {
+			return invalid(fmt.Sprintf("examples[%d].features", i), "features cannot be empty")
+		}
+		if len(m.Features) > 0 && len(e.Features) != len(m.Features) {
+			return invalid(fmt.Sprintf("examples[%d].features", i), fmt.Sprintf("expected %d features, got %d", len(m.Features), len(e.Features)))
+		}
+		if e.Tolerance < 0 || math.IsNaN(e.Tolerance) {
+			return invalid(fmt.Sprintf("examples[%d].tolerance", i), "tolerance must be non-negative")
+		}
+	}
+	return nil
+}
+
+// Compare checks a model output against the golden output of e. It
+// returns a description of the first mismatch, or "" if the output
+// matches or e has no golden output.
+func (e *Example) Compare(got []float64) string {
+	if e.Expected == nil {
+		return ""
+	}
+	if len(got) != len(e.Expected) {
+		return fmt.Sprintf("returned %d values, expected %d", len(got), len(e.Expected))
+	}
+
+	tol := e.Tolerance
+	if tol == 0 {
+		tol = defaultTolerance
+	}
+	for i, want := range e.Expected {
+		// Golden outputs come from JSON and are always finite, and NaN
+		// would otherwise pass the comparison below
+		if math.IsNaN(got[i]) || math.IsInf(got[i], 0) || math.Abs(got[i]-want) > tol*math.Max(1, math.Abs(want)) {
+			return fmt.Sprintf("output[%d] = %g, expected %g (tolerance %g)", i, got[i], want, tol)
+		}
+	}
+	return ""
+}
+
+// Read loads and validates the manifest of a package unpacked into dir.
+func Read(dir string) (*Manifest, error) {
+	f, err := os.Open(filepath.Join(dir, ManifestFile))
+	if os.IsNotExist(err) {
+		return nil, invalid("file", fmt.Sprintf("package has no %s", ManifestFile))
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var m Manifest
+	dec := json.NewDecoder(io.LimitReader(f, maxManifestSize))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&m); err != nil {
+		return nil, invalid("manifest", fmt.Sprintf("invalid %s: %v", ManifestFile, err))
+	}
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// File is one file of the model artifact, relative to model/.
+type File struct {
+	Path string
+	Open func() (io.ReadCloser, error)
+}
+
+// Write writes a package holding m, the model info JSON (if any) and the
+// model files.
+func Write(w io.Writer, m *Manifest, info []byte, files []File) error {
+	zw := zip.NewWriter(w)
+
+	manifest, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeEntry(zw, ManifestFile, strings.NewReader(string(manifest))); err != nil {
+		return err
+	}
+	if info != nil {
+		if err := writeEntry(zw, InfoFile, strings.NewReader(string(info))); err != nil {
+			return err
+		}
+	}
+
+	for _, f := range files {
+		r, err := f.Open()
+		if err != nil {
+			return err
+		}
+		err = writeEntry(zw, ModelDir+"/"+f.Path, r)
+		r.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+func writeEntry(zw *zip.Writer, name string, r io.Reader) error {
+	w, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, r)
+	return err
+}
+
+func invalid(field, message string) error {
+	return &domain.ValidationError{Field: field, Message: message}
+}
@@ -0,0 +1,269 @@
+package pipeline
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/kevo-1/model-nexus/internal/domain"
+)
+
+// InputStep is the reserved step name that refers to the request features.
+const InputStep = "input"
+
+// Definition is a multi-step inference pipeline. Steps run in declaration
+// order and may only read from the request features or from earlier steps,
+// which keeps every definition a DAG without a separate topological sort.
+type Definition struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	Steps   []Step `json:"steps"`
+	// Outputs lists candidate result steps; the first one that ran is
+	// returned. When empty, the last step that ran is returned.
+	Outputs []string `json:"outputs,omitempty"`
+}
+
+type Step struct {
+	Name    string `json:"name"`
+	ModelID string `json:"model_id"`
+	// Inputs are concatenated in order to build the step's features. When
+	// empty, the step receives the request features unchanged.
+	Inputs []Source `json:"inputs,omitempty"`
+	// When gates the step; a skipped step produces no output and every
+	// step reading from it is skipped as well.
+	When *Condition `json:"when,omitempty"`
+}
+
+// Source selects values from the request features or an earlier step.
+// Output names one output of the step's model, and Indices pick values
+// within it; without Output the step's outputs are read concatenated in
+// declaration order, which is only unambiguous for single-output models.
+type Source struct {
+	Step    string `json:"step"`
+	Output  string `json:"output,omitempty"`
+	Indices []int  `json:"indices,omitempty"`
+}
+
+type Operator string
+
+const (
+	OpGT  Operator = "gt"
+	OpGTE Operator = "gte"
+	OpLT  Operator = "lt"
+	OpLTE Operator = "lte"
+	OpEQ  Operator = "eq"
+	OpNE  Operator = "ne"
+)
+
+// Condition compares one value produced by an earlier step to a constant.
+// Index is within the named Output, like the indices of a Source.
+type Condition struct {
+	Step   string   `json:"step"`
+	Output string   `json:"output,omitempty"`
+	Index  int      `json:"index"`
+	Op     Operator `json:"op"`
+	Value  float64  `json:"value"`
+}
+
+func (d *Definition) Validate() error {
+	if d.ID == "" || d.Name == "" || d.Version == "" {
+		return &domain.ValidationError{Field: "id/name/version", Message: "id, name, and version are required"}
+	}
+
+	if err := domain.ValidateModelID("id", d.ID); err != nil {
+		return err
+	}
+
+	if len(d.Steps) == 0 {
+		return &domain.ValidationError{Field: "steps", Message: "at least one step is required"}
+	}
+
+	known := map[string]bool{InputStep: true}
+	for i, step := range d.Steps {
+		field := fmt.Sprintf("steps[%d]", i)
+
+		if step.Name == "" || step.ModelID == "" {
+			return &domain.ValidationError{Field: field, Message: "name and model_id are required"}
+		}
+		if err := domain.ValidateModelID(field+".model_id", step.ModelID); err != nil {
+			return err
+		}
+		if known[step.Name] {
+			return &domain.ValidationError{Field: field, Message: fmt.Sprintf("step name %q is reserved or already used", step.Name)}
+		}
+
+		for _, src := range step.Inputs {
+			if !known[src.Step] {
+				return &domain.ValidationError{Field: field, Message: fmt.Sprintf("input references unknown or later step %q", src.Step)}
+			}
+			if src.Step == InputStep && src.Output != "" {
+				return &domain.ValidationError{Field: field, Message: "the request features have no named outputs"}
+			}
+			for _, idx := range src.Indices {
+				if idx < 0 {
+					return &domain.ValidationError{Field: field, Message: "input indices must be non-negative"}
+				}
+			}
+		}
+
+		if c := step.When; c != nil {
+			if !known[c.Step] {
+				return &domain.ValidationError{Field: field, Message: fmt.Sprintf("condition references unknown or later step %q", c.Step)}
+			}
+			if c.Step == InputStep && c.Output != "" {
+				return &domain.ValidationError{Field: field, Message: "the request features have no named outputs"}
+			}
+			if c.Index < 0 {
+				return &domain.ValidationError{Field: field, Message: "condition index must be non-negative"}
+			}
+			switch c.Op {
+			case OpGT, OpGTE, OpLT, OpLTE, OpEQ, OpNE:
+			default:
+				return &domain.ValidationError{Field: field, Message: fmt.Sprintf("unknown condition op %q", c.Op)}
+			}
+		}
+
+		known[step.Name] = true
+	}
+
+	for _, out := range d.Outputs {
+		if out == InputStep || !known[out] {
+			return &domain.ValidationError{Field: "outputs", Message: fmt.Sprintf("unknown output step %q", out)}
+		}
+	}
+
+	return nil
+}
+
+// ModelIDs returns the distinct models referenced by the pipeline.
+func (d *Definition) ModelIDs() []string {
+	seen := make(map[string]bool)
+	var ids []string
+	for _, step := range d.Steps {
+		if !seen[step.ModelID] {
+			seen[step.ModelID] = true
+			ids = append(ids, step.ModelID)
+		}
+	}
+	return ids
+}
+
+// CheckOutputs checks the outputs that sources and conditions name against
+// the models of their steps. outputs returns the output names of a model,
+// or nil if it does not name them. Reading single values without naming
+// the output is only allowed from models with one output, so a reordered
+// or reshaped output cannot feed the wrong values.
+func (d *Definition) CheckOutputs(outputs func(modelID string) []string) error {
+	models := make(map[string]string, len(d.Steps))
+	check := func(field, step, output string, indexed bool) error {
+		modelID, ok := models[step]
+		if !ok {
+			return nil // the request features
+		}
+		names := outputs(modelID)
+		switch {
+		case output != "" && names == nil:
+			return &domain.ValidationError{Field: field, Message: fmt.Sprintf("model %s of step %s does not name its outputs", modelID, step)}
+		case output != "" && !slices.Contains(names, output):
+			return &domain.ValidationError{Field: field, Message: fmt.Sprintf("model %s of step %s has no output %q (outputs: %s)", modelID, step, output, strings.Join(names, ", "))}
+		case output == "" && indexed && len(names) > 1:
+			return &domain.ValidationError{Field: field, Message: fmt.Sprintf("model %s of step %s has %d outputs; name the one to read with output", modelID, step, len(names))}
+		}
+		return nil
+	}
+
+	for i, step := range d.Steps {
+		field := fmt.Sprintf("steps[%d]", i)
+		for _, src := range step.Inputs {
+			if err := check(field, src.Step, src.Output, len(src.Indices) > 0); err != nil {
+				return err
+			}
+		}
+		if c := step.When; c != nil {
+			if err := check(field, c.Step, c.Output, true); err != nil {
+				return err
+			}
+		}
+		models[step.Name] = step.ModelID
+	}
+	return nil
+}
+
+// Values is what a step produced: its outputs concatenated in declaration
+// order and, for models that name them, each output by name.
+type Values struct {
+	Flat  []float64
+	Named map[string][]float64
+}
+
+// output returns the values of one output, or all of them if name is empty.
+func (v Values) output(step, name string) ([]float64, error) {
+	if name == "" {
+		return v.Flat, nil
+	}
+	values, ok := v.Named[name]
+	if !ok {
+		return nil, fmt.Errorf("step %s produced no output %q", step, name)
+	}
+	return values, nil
+}
+
+// Eval reports whether the condition holds for what its step produced.
+func (c *Condition) Eval(produced Values) (bool, error) {
+	values, err := produced.output(c.Step, c.Output)
+	if err != nil {
+		return false, err
+	}
+	if c.Index >= len(values) {
+		return false, fmt.Errorf("condition index %d out of range for %s with %d values", c.Index, c.source(), len(values))
+	}
+
+	v := values[c.Index]
+	switch c.Op {
+	case OpGT:
+		return v > c.Value, nil
+	case OpGTE:
+		return v >= c.Value, nil
+	case OpLT:
+		return v < c.Value, nil
+	case OpLTE:
+		return v <= c.Value, nil
+	case OpEQ:
+		return v == c.Value, nil
+	case OpNE:
+		return v != c.Value, nil
+	}
+	return false, fmt.Errorf("unknown condition op %q", c.Op)
+}
+
+// Select resolves a source against what its step produced.
+func (s *Source) Select(produced Values) ([]float64, error) {
+	values, err := produced.output(s.Step, s.Output)
+	if err != nil {
+		return nil, err
+	}
+	if len(s.Indices) == 0 {
+		return values, nil
+	}
+
+	out := make([]float64, len(s.Indices))
+	for i, idx := range s.Indices {
+		if idx >= len(values) {
+			return nil, fmt.Errorf("index %d out of range for %s with %d values", idx, describe(s.Step, s.Output), len(values))
+		}
+		out[i] = values[idx]
+	}
+	return out, nil
+}
+
+func (c *Condition) source() string {
+	return describe(c.Step, c.Output)
+}
+
+func describe(step, output string) string {
+	if output == "" {
+		return "step " + step
+	}
+	return fmt.Sprintf("output %s of step %s", output, step)
+}
@@ -8,20 +8,40 @@ import (
 )
 
 type ModelRegistry struct {
-	mu     sync.RWMutex
-	models map[string]domain.ModelPredictor
+	mu        sync.RWMutex
+	models    map[string]domain.ModelPredictor
+	unhealthy map[string]string // id -> reason
 }
 
 func NewModelRegistry() *ModelRegistry {
 	return &ModelRegistry{
-		models: make(map[string]domain.ModelPredictor),
+		models:    make(map[string]domain.ModelPredictor),
+		unhealthy: make(map[string]string),
 	}
 }
 
+// Get returns a model for serving. Models marked unhealthy are refused
+// with a ModelUnhealthyError.
 func (r *ModelRegistry) Get(id string) (domain.ModelPredictor, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
+	model, ok := r.models[id]
+	if !ok {
+		return nil, &domain.ModelNotFoundError{ModelID: id}
+	}
+	if reason, bad := r.unhealthy[id]; bad {
+		return nil, &domain.ModelUnhealthyError{ModelID: id, Reason: reason}
+	}
+	return model, nil
+}
+
+// Lookup returns a model regardless of its health, for callers that only
+// describe it.
+func (r *ModelRegistry) Lookup(id string) (domain.ModelPredictor, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
 	model, ok := r.models[id]
 	if !ok {
 		return nil, &domain.ModelNotFoundError{ModelID: id}
@@ -29,6 +49,41 @@ func (r *ModelRegistry) Get(id string) (domain.ModelPredictor, error) {
 	return model, nil
 }
 
+// SetHealth marks a model healthy (empty reason) or unhealthy.
+func (r *ModelRegistry) SetHealth(id, reason string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.models[id]; !ok {
+		return
+	}
+	if reason == "" {
+		delete(r.unhealthy, id)
+	} else {
+		r.unhealthy[id] = reason
+	}
+}
+
+// Health returns the reason a model is unhealthy, or "" if it is healthy.
+func (r *ModelRegistry) Health(id string) string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.unhealthy[id]
+}
+
+// Unhealthy returns the ids of models marked unhealthy.
+func (r *ModelRegistry) Unhealthy() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ids := make([]string, 0, len(r.unhealthy))
+	for id := range r.unhealthy {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
 func (r *ModelRegistry) Register(id string, model domain.ModelPredictor) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
@@ -43,6 +98,20 @@ func (r *ModelRegistry) Register(id string, model domain.ModelPredictor) error {
 	return nil
 }
 
+// Replace registers model under id, replacing the model registered there,
+// which is returned, if any. The replacement starts out healthy.
+func (r *ModelRegistry) Replace(id string, model domain.ModelPredictor) domain.ModelPredictor {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	old := r.models[id]
+	r.models[id] = model
+	delete(r.unhealthy, id)
+	metrics.SetModelsLoaded(len(r.models))
+
+	return old
+}
+
 func (r *ModelRegistry) List() []string {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
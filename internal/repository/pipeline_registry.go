@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"sync"
+
+	"github.com/kevo-1/model-nexus/internal/domain"
+	"github.com/kevo-1/model-nexus/internal/pipeline"
+)
+
+type PipelineRegistry struct {
+	mu        sync.RWMutex
+	pipelines map[string]pipeline.Definition
+}
+
+func NewPipelineRegistry() *PipelineRegistry {
+	return &PipelineRegistry{
+		pipelines: make(map[string]pipeline.Definition),
+	}
+}
+
+func (r *PipelineRegistry) Get(id string) (pipeline.Definition, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	def, ok := r.pipelines[id]
+	if !ok {
+		return pipeline.Definition{}, &domain.PipelineNotFoundError{PipelineID: id}
+	}
+	return def, nil
+}
+
+func (r *PipelineRegistry) Register(def pipeline.Definition) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.pipelines[def.ID]; exists {
+		return &domain.PipelineAlreadyExistsError{PipelineID: def.ID}
+	}
+
+	r.pipelines[def.ID] = def
+	return nil
+}
+
+// Replace registers def, replacing the pipeline with the same id, if any.
+func (r *PipelineRegistry) Replace(def pipeline.Definition) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.pipelines[def.ID] = def
+}
+
+func (r *PipelineRegistry) List() []pipeline.Definition {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	defs := make([]pipeline.Definition, 0, len(r.pipelines))
+	for _, def := range r.pipelines {
+		defs = append(defs, def)
+	}
+	return defs
+}
@@ -0,0 +1,496 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kevo-1/model-nexus/internal/backup"
+	"github.com/kevo-1/model-nexus/internal/domain"
+	"github.com/kevo-1/model-nexus/internal/ensemble"
+	"github.com/kevo-1/model-nexus/internal/logger"
+	"github.com/kevo-1/model-nexus/internal/mnx"
+	"github.com/kevo-1/model-nexus/internal/pipeline"
+)
+
+// ConflictPolicy decides what a restore does with an archived model,
+// ensemble or pipeline whose id is already registered.
+type ConflictPolicy string
+
+const (
+	// ConflictSkip keeps the registered one.
+	ConflictSkip ConflictPolicy = "skip"
+	// ConflictOverwrite replaces the registered one.
+	ConflictOverwrite ConflictPolicy = "overwrite"
+	// ConflictRename restores the archived one under a new id, <id>-2,
+	// <id>-3, ..., and points restored ensembles and pipelines at it.
+	ConflictRename ConflictPolicy = "rename"
+)
+
+// ParseConflictPolicy parses a policy name; "" means ConflictSkip.
+func ParseConflictPolicy(s string) (ConflictPolicy, error) {
+	switch p := ConflictPolicy(strings.ToLower(strings.TrimSpace(s))); p {
+	case "":
+		return ConflictSkip, nil
+	case ConflictSkip, ConflictOverwrite, ConflictRename:
+		return p, nil
+	}
+	return "", &domain.ValidationError{
+		Field:   "conflict",
+		Message: fmt.Sprintf("unknown conflict policy %q (expected skip, overwrite or rename)", s),
+	}
+}
+
+// BackupService backs up the whole registry into one archive and restores
+// such archives, possibly into another server.
+type BackupService struct {
+	models  *ModelService
+	explain *ExplainService
+
+	restoreMu sync.Mutex // one restore at a time, so renames do not race
+}
+
+func NewBackupService(models *ModelService, explain *ExplainService) *BackupService {
+	return &BackupService{models: models, explain: explain}
+}
+
+// RegistryBackup is a backup of the registry, written by Write.
+type RegistryBackup struct {
+	s       *BackupService
+	ctx     context.Context
+	created time.Time
+}
+
+// Backup prepares a backup of every registered model, ensemble and
+// pipeline. Stored models are written as their .mnx export, with their
+// background dataset; the registry is read as the archive is written.
+func (s *BackupService) Backup(ctx context.Context) *RegistryBackup {
+	return &RegistryBackup{s: s, ctx: ctx, created: time.Now().UTC()}
+}
+
+// Filename is the name the archive is downloaded as.
+func (b *RegistryBackup) Filename() string {
+	return "model-nexus-" + b.created.Format("20060102T150405Z") + ".zip"
+}
+
+// Write writes the archive. Models that cannot be exported, e.g. because
+// their artifact failed verification, are left out and listed in the
+// manifest; only failures to write the archive are returned.
+func (b *RegistryBackup) Write(w io.Writer) error {
+	s, ctx := b.s, b.ctx
+	bw := backup.NewWriter(w)
+
+	ids := s.models.registry.List()
+	sort.Strings(ids)
+
+	ensembles := make(map[string]ensemble.Config)
+	for _, id := range ids {
+		predictor, err := s.models.registry.Lookup(id)
+		if err != nil {
+			continue // removed since it was listed
+		}
+		if e, ok := predictor.(*ensemble.Ensemble); ok {
+			ensembles[id] = e.Config()
+			continue
+		}
+
+		export, err := s.models.ExportPackage(ctx, id)
+		if err != nil {
+			logger.Warn("model left out of backup", "model_id", id, "error", err)
+			bw.Skip(id, err.Error())
+			continue
+		}
+
+		var background []byte
+		rows, err := s.explain.background(ctx, id)
+		var nf *domain.BackgroundNotFoundError
+		switch {
+		case errors.As(err, &nf):
+		case err != nil:
+			return fmt.Errorf("failed to read background dataset of %s: %w", id, err)
+		default:
+			if background, err = json.Marshal(rows); err != nil {
+				return err
+			}
+		}
+
+		meta := predictor.Metadata()
+		m := backup.Model{
+			ID:      id,
+			Name:    meta.Name,
+			Version: meta.Version,
+			Format:  meta.Format,
+			Digest:  s.models.Digest(id),
+		}
+		if err := bw.AddModel(m, export.Write, background); err != nil {
+			return fmt.Errorf("failed to back up model %s: %w", id, err)
+		}
+	}
+
+	// Ensembles may use other ensembles as members; list those first
+	added := make(map[string]bool, len(ensembles))
+	var add func(id string)
+	add = func(id string) {
+		config, ok := ensembles[id]
+		if !ok || added[id] {
+			return
+		}
+		added[id] = true
+		for _, member := range append(append([]string{}, config.Members...), config.MetaModel) {
+			add(member)
+		}
+		bw.AddEnsemble(config)
+	}
+	for _, id := range ids {
+		add(id)
+	}
+
+	pipelines := s.models.pipelines.List()
+	sort.Slice(pipelines, func(i, j int) bool { return pipelines[i].ID < pipelines[j].ID })
+	for _, def := range pipelines {
+		bw.AddPipeline(def)
+	}
+
+	m := bw.Manifest()
+	if err := bw.Close(); err != nil {
+		return err
+	}
+
+	logger.Info("registry backed up",
+		"models", len(m.Models),
+		"ensembles", len(m.Ensembles),
+		"pipelines", len(m.Pipelines),
+		"skipped", len(m.Skipped),
+	)
+	return nil
+}
+
+// RestoreStatus is the outcome of restoring one archived item.
+type RestoreStatus string
+
+const (
+	RestoreImported    RestoreStatus = "imported"
+	RestoreOverwritten RestoreStatus = "overwritten"
+	RestoreRenamed     RestoreStatus = "renamed"
+	RestoreSkipped     RestoreStatus = "skipped"
+	RestoreFailed      RestoreStatus = "failed"
+)
+
+// RestoreItem reports on one archived model, ensemble or pipeline.
+type RestoreItem struct {
+	Kind string `json:"kind"` // model, ensemble or pipeline
+	ID   string `json:"id"`
+	// ImportedAs is the id it is now registered under.
+	ImportedAs string        `json:"imported_as,omitempty"`
+	Status     RestoreStatus `json:"status"`
+	Digest     string        `json:"digest,omitempty"`
+	// Verified is set once a restored model's stored artifact re-hashes to
+	// its archived digest, or a restored ensemble or pipeline resolves
+	// every model it references.
+	Verified bool     `json:"verified"`
+	Error    string   `json:"error,omitempty"`
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// RestoreReport is the outcome of a restore.
+type RestoreReport struct {
+	Policy ConflictPolicy `json:"policy"`
+	// Verified is true when the backup was complete, nothing failed and
+	// every restored item was verified.
+	Verified    bool `json:"verified"`
+	Imported    int  `json:"imported"`
+	Overwritten int  `json:"overwritten"`
+	Renamed     int  `json:"renamed"`
+	Skipped     int  `json:"skipped"`
+	Failed      int  `json:"failed"`
+
+	Items []RestoreItem `json:"items"`
+	// Missing lists models the backup could not include.
+	Missing []backup.Skipped `json:"missing,omitempty"`
+}
+
+func (r *RestoreReport) add(item RestoreItem) {
+	switch item.Status {
+	case RestoreImported:
+		r.Imported++
+	case RestoreOverwritten:
+		r.Overwritten++
+	case RestoreRenamed:
+		r.Renamed++
+	case RestoreSkipped:
+		r.Skipped++
+	case RestoreFailed:
+		r.Failed++
+	}
+	r.Items = append(r.Items, item)
+}
+
+// restore tracks the ids archived items were restored under.
+type restore struct {
+	archived map[string]bool   // ids of archived models and ensembles
+	ids      map[string]string // archived id -> registered id
+	failed   map[string]bool   // archived ids that failed to restore
+}
+
+// resolve maps the model ids an ensemble or pipeline references to the ids
+// they were restored under. Ids not in the archive are used as they are.
+func (r *restore) resolve(ids []string) ([]string, error) {
+	out := make([]string, len(ids))
+	for i, id := range ids {
+		if r.failed[id] {
+			return nil, fmt.Errorf("model %s was not restored", id)
+		}
+		out[i] = id
+		if to, ok := r.ids[id]; ok {
+			out[i] = to
+		}
+	}
+	return out, nil
+}
+
+// restoreTarget picks the id an archived item with a registered id is restored
+// under, and the status it gets. exists reports whether an id is in use.
+func restoreTarget(id string, policy ConflictPolicy, exists func(string) bool) (string, RestoreStatus) {
+	if !exists(id) {
+		return id, RestoreImported
+	}
+	switch policy {
+	case ConflictOverwrite:
+		return id, RestoreOverwritten
+	case ConflictRename:
+		for n := 2; ; n++ {
+			suffix := fmt.Sprintf("-%d", n)
+			candidate := id[:min(len(id), domain.MaxModelIDLength-len(suffix))] + suffix
+			if !exists(candidate) {
+				return candidate, RestoreRenamed
+			}
+		}
+	}
+	return id, RestoreSkipped
+}
+
+// Restore registers the models, ensembles and pipelines of a backup read
+// from r. Items whose id is already registered are handled by policy.
+// Each model's package is checked against its archived digest, uploaded
+// through the regular pipeline (including the signature policy), and its
+// stored artifact verified. Ensembles and pipelines follow renamed models.
+// Problems with single items are reported; only an invalid archive or I/O
+// failures return an error.
+func (s *BackupService) Restore(ctx context.Context, r io.Reader, policy ConflictPolicy) (*RestoreReport, error) {
+	s.restoreMu.Lock()
+	defer s.restoreMu.Unlock()
+
+	work, err := os.MkdirTemp(s.models.staging, ".upload-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to stage backup: %w", err)
+	}
+	defer os.RemoveAll(work)
+
+	path := filepath.Join(work, "backup.zip")
+	if _, err := writeFile(path, r); err != nil {
+		return nil, fmt.Errorf("failed to stage backup: %w", err)
+	}
+	archive, err := backup.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer archive.Close()
+	m := archive.Manifest
+
+	st := &restore{
+		archived: make(map[string]bool),
+		ids:      make(map[string]string),
+		failed:   make(map[string]bool),
+	}
+	for _, model := range m.Models {
+		st.archived[model.ID] = true
+	}
+	for _, e := range m.Ensembles {
+		st.archived[e.ID] = true
+	}
+	// An archived id conflicts if it is registered; a new id must also not
+	// be that of an archived item restored later
+	modelTarget := func(id string) (string, RestoreStatus) {
+		return restoreTarget(id, policy, func(c string) bool {
+			_, err := s.models.registry.Lookup(c)
+			return err == nil || (c != id && st.archived[c] && st.ids[c] == "")
+		})
+	}
+
+	report := &RestoreReport{Policy: policy, Items: []RestoreItem{}, Missing: m.Skipped}
+	for _, model := range m.Models {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		report.add(s.restoreModel(ctx, archive, model, modelTarget, st))
+	}
+	for _, config := range m.Ensembles {
+		report.add(s.restoreEnsemble(config, modelTarget, st))
+	}
+	for _, def := range m.Pipelines {
+		report.add(s.restorePipeline(def, policy, st))
+	}
+
+	report.Verified = len(report.Missing) == 0
+	for _, item := range report.Items {
+		if item.Status == RestoreFailed || (item.Status != RestoreSkipped && !item.Verified) {
+			report.Verified = false
+		}
+	}
+
+	logger.Info("registry restored",
+		"policy", policy,
+		"imported", report.Imported,
+		"overwritten", report.Overwritten,
+		"renamed", report.Renamed,
+		"skipped", report.Skipped,
+		"failed", report.Failed,
+		"verified", report.Verified,
+	)
+	return report, nil
+}
+
+func (s *BackupService) restoreModel(ctx context.Context, archive *backup.Archive, m backup.Model, target func(string) (string, RestoreStatus), st *restore) RestoreItem {
+	item := RestoreItem{Kind: "model", ID: m.ID, Digest: m.Digest}
+	fail := func(err error) RestoreItem {
+		st.failed[m.ID] = true
+		item.Status = RestoreFailed
+		item.ImportedAs = ""
+		item.Error = err.Error()
+		logger.Warn("model not restored", "model_id", m.ID, "error", err)
+		return item
+	}
+
+	id, status := target(m.ID)
+	st.ids[m.ID] = id
+	item.Status = status
+	if status == RestoreSkipped {
+		return item
+	}
+	item.ImportedAs = id
+
+	if err := archive.VerifyPackage(m); err != nil {
+		return fail(err)
+	}
+	pkg, err := archive.OpenPackage(m)
+	if err != nil {
+		return fail(err)
+	}
+	res, err := s.models.RegisterModel(ctx, RegisterModelRequest{
+		ID:       id,
+		Name:     m.Name,
+		Version:  m.Version,
+		Filename: m.ID + mnx.Extension,
+		File:     pkg,
+		replace:  status == RestoreOverwritten,
+	})
+	pkg.Close()
+	if err != nil {
+		return fail(err)
+	}
+
+	// The package digest is checked on upload; this checks what was stored
+	if res.Model.Digest != m.Digest {
+		item.Error = fmt.Sprintf("restored with digest %s, expected %s", res.Model.Digest, m.Digest)
+	} else if err := s.models.verifyModel(ctx, id); err != nil {
+		item.Error = fmt.Sprintf("stored artifact failed verification: %v", err)
+	} else {
+		item.Verified = true
+	}
+
+	rows, err := archive.Background(m)
+	switch {
+	case err != nil:
+		item.Warnings = append(item.Warnings, fmt.Sprintf("background dataset not restored: %v", err))
+	case rows != nil:
+		if err := s.explain.SetBackground(ctx, domain.BackgroundRequest{ModelID: id, Rows: rows}); err != nil {
+			item.Warnings = append(item.Warnings, fmt.Sprintf("background dataset not restored: %v", err))
+		}
+	case status == RestoreOverwritten:
+		if err := s.explain.clearBackground(ctx, id); err != nil {
+			item.Warnings = append(item.Warnings, fmt.Sprintf("background dataset of the replaced model not removed: %v", err))
+		}
+	}
+	return item
+}
+
+func (s *BackupService) restoreEnsemble(config ensemble.Config, target func(string) (string, RestoreStatus), st *restore) RestoreItem {
+	item := RestoreItem{Kind: "ensemble", ID: config.ID}
+	fail := func(err error) RestoreItem {
+		st.failed[config.ID] = true
+		item.Status = RestoreFailed
+		item.ImportedAs = ""
+		item.Error = err.Error()
+		logger.Warn("ensemble not restored", "model_id", config.ID, "error", err)
+		return item
+	}
+
+	id, status := target(config.ID)
+	st.ids[config.ID] = id
+	item.Status = status
+	if status == RestoreSkipped {
+		return item
+	}
+	item.ImportedAs = id
+
+	members, err := st.resolve(config.Members)
+	if err != nil {
+		return fail(err)
+	}
+	config.ID, config.Members = id, members
+	if config.MetaModel != "" {
+		meta, err := st.resolve([]string{config.MetaModel})
+		if err != nil {
+			return fail(err)
+		}
+		config.MetaModel = meta[0]
+	}
+
+	if _, err := s.models.registerEnsemble(config, status == RestoreOverwritten); err != nil {
+		return fail(err)
+	}
+	item.Verified = true
+	return item
+}
+
+func (s *BackupService) restorePipeline(def pipeline.Definition, policy ConflictPolicy, st *restore) RestoreItem {
+	item := RestoreItem{Kind: "pipeline", ID: def.ID}
+
+	id, status := restoreTarget(def.ID, policy, func(id string) bool {
+		_, err := s.models.pipelines.Get(id)
+		return err == nil
+	})
+	item.Status = status
+	if status == RestoreSkipped {
+		return item
+	}
+
+	steps := make([]pipeline.Step, len(def.Steps))
+	copy(steps, def.Steps)
+	for i := range steps {
+		ids, err := st.resolve([]string{steps[i].ModelID})
+		if err != nil {
+			item.Status, item.Error = RestoreFailed, err.Error()
+			return item
+		}
+		steps[i].ModelID = ids[0]
+	}
+	def.ID, def.Steps = id, steps
+
+	if err := s.models.registerPipeline(def, status == RestoreOverwritten); err != nil {
+		item.Status, item.Error = RestoreFailed, err.Error()
+		logger.Warn("pipeline not restored", "pipeline_id", item.ID, "error", err)
+		return item
+	}
+	item.ImportedAs = id
+	item.Verified = true
+	return item
+}
@@ -0,0 +1,92 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/kevo-1/model-nexus/internal/domain"
+)
+
+func TestParseConflictPolicy(t *testing.T) {
+	tests := []struct {
+		input string
+		want  ConflictPolicy
+		ok    bool
+	}{
+		{"", ConflictSkip, true},
+		{"skip", ConflictSkip, true},
+		{"Overwrite", ConflictOverwrite, true},
+		{" rename ", ConflictRename, true},
+		{"merge", "", false},
+	}
+	for _, tt := range tests {
+		got, err := ParseConflictPolicy(tt.input)
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("ParseConflictPolicy(%q) = %q, %v; want %q, ok %v", tt.input, got, err, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestRestoreTarget(t *testing.T) {
+	long := strings.Repeat("m", domain.MaxModelIDLength)
+	registered := map[string]bool{"iris": true, "iris-2": true, long: true}
+	exists := func(id string) bool { return registered[id] }
+
+	tests := []struct {
+		name   string
+		id     string
+		policy ConflictPolicy
+		want   string
+		status RestoreStatus
+	}{
+		{"new id, skip", "diabetes", ConflictSkip, "diabetes", RestoreImported},
+		{"new id, overwrite", "diabetes", ConflictOverwrite, "diabetes", RestoreImported},
+		{"new id, rename", "diabetes", ConflictRename, "diabetes", RestoreImported},
+		{"registered id, skip", "iris", ConflictSkip, "iris", RestoreSkipped},
+		{"registered id, overwrite", "iris", ConflictOverwrite, "iris", RestoreOverwritten},
+		{"registered id, rename past taken ids", "iris", ConflictRename, "iris-3", RestoreRenamed},
+		{"longest id, rename", long, ConflictRename, long[:domain.MaxModelIDLength-2] + "-2", RestoreRenamed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, status := restoreTarget(tt.id, tt.policy, exists)
+			if got != tt.want || status != tt.status {
+				t.Fatalf("restoreTarget = %s, %s; want %s, %s", got, status, tt.want, tt.status)
+			}
+			if err := domain.ValidateModelID("id", got); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestRestoreResolve(t *testing.T) {
+	st := &restore{
+		ids:    map[string]string{"iris": "iris-2", "diabetes": "diabetes"},
+		failed: map[string]bool{"broken": true},
+	}
+
+	tests := []struct {
+		name string
+		ids  []string
+		want string // "" if resolving fails
+	}{
+		{"renamed and kept ids", []string{"iris", "diabetes"}, "iris-2,diabetes"},
+		{"ids not in the archive", []string{"registered", "iris"}, "registered,iris-2"},
+		{"failed model", []string{"iris", "broken"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := st.resolve(tt.ids)
+			if tt.want == "" {
+				if err == nil {
+					t.Fatalf("resolve = %v, want an error", got)
+				}
+				return
+			}
+			if err != nil || strings.Join(got, ",") != tt.want {
+				t.Fatalf("resolve = %v, %v; want %s", got, err, tt.want)
+			}
+		})
+	}
+}
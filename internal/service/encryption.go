@@ -0,0 +1,378 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/kevo-1/model-nexus/internal/backend"
+	"github.com/kevo-1/model-nexus/internal/domain"
+	"github.com/kevo-1/model-nexus/internal/encryption"
+	"github.com/kevo-1/model-nexus/internal/logger"
+	"github.com/kevo-1/model-nexus/internal/storage"
+)
+
+// SetEncryption encrypts model artifacts stored from now on with the
+// current key of keys. Artifacts stored in plaintext or under one of the
+// previous keys stay readable until RotateEncryptionKeys rewrites them. It
+// must be called before the service is used.
+func (s *ModelService) SetEncryption(keys *encryption.Keyring) {
+	s.encryption = keys
+}
+
+// checkEncryptable rejects artifacts that cannot be stored encrypted:
+// sessions of encrypted artifacts are created from memory, which only works
+// for single-file artifacts of backends that can load them from there.
+// Bundles are a hard limitation: ONNX Runtime reads external data from
+// files next to the model, and the Go binding cannot hand it the data from
+// memory, so their plaintext would have to be written to disk.
+func (s *ModelService) checkEncryptable(a *artifact) error {
+	if s.encryption == nil {
+		return nil
+	}
+	if a.bundle {
+		return &domain.ValidationError{Field: "file", Message: "bundles cannot be stored encrypted, because external data is loaded from plaintext files; upload a single model file with the tensors embedded"}
+	}
+	if _, ok := a.backend.(backend.MemoryLoader); !ok {
+		return &domain.ValidationError{Field: "file", Message: fmt.Sprintf("the %s backend cannot load encrypted artifacts", a.backend.Format())}
+	}
+	return nil
+}
+
+// loadPredictor creates a predictor from a staged model file. With
+// encryption on, the model is loaded from memory as it will be once stored
+// encrypted, and its data is returned for reloading it.
+func (s *ModelService) loadPredictor(b backend.Backend, req RegisterModelRequest, path string) (domain.ModelPredictor, []byte, error) {
+	loader, ok := b.(backend.MemoryLoader)
+	if s.encryption == nil || !ok {
+		predictor, err := b.NewPredictor(req.ID, req.Name, req.Version, path)
+		return predictor, nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	predictor, err := loader.NewPredictorFromData(req.ID, req.Name, req.Version, path, data, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	return predictor, data, nil
+}
+
+// loadArtifact creates a predictor from a committed model file. Encrypted
+// files, and models whose sidecar is encrypted, are decrypted into memory.
+func (s *ModelService) loadArtifact(b backend.Backend, id, name, version, path string) (domain.ModelPredictor, error) {
+	data, err := s.readEncrypted(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read model file: %w", err)
+	}
+	info, err := s.readEncrypted(sidecarPath(path, b.Extension()))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read model info sidecar: %w", err)
+	}
+	if data == nil && info == nil {
+		return b.NewPredictor(id, name, version, path)
+	}
+
+	loader, ok := b.(backend.MemoryLoader)
+	if !ok {
+		return nil, fmt.Errorf("the %s backend cannot load encrypted artifacts", b.Format())
+	}
+	if data == nil {
+		// Sealed by a rotation that has not reached the model file yet
+		if data, err = os.ReadFile(path); err != nil {
+			return nil, fmt.Errorf("failed to read model file: %w", err)
+		}
+	}
+	return loader.NewPredictorFromData(id, name, version, path, data, info)
+}
+
+// sealStaged replaces the staged model file of a and its sidecar with their
+// ciphertext, so only the ciphertext is committed to storage and the cache.
+func (s *ModelService) sealStaged(a *artifact) error {
+	if err := s.sealFile(a.work, a.model); err != nil {
+		return err
+	}
+	return s.sealFile(a.work, filepath.Join(a.work, filepath.FromSlash(a.sidecarKey)))
+}
+
+// sealFile replaces path with its ciphertext, written to work first.
+func (s *ModelService) sealFile(work, path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	sealed := filepath.Join(work, "sealed")
+	dst, err := os.OpenFile(sealed, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+	err = s.encryption.Encrypt(dst, bufio.NewReader(src))
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(sealed)
+		return err
+	}
+	return os.Rename(sealed, path)
+}
+
+// errNoEncryptionKey is returned for encrypted objects read while
+// encryption is not configured.
+var errNoEncryptionKey = errors.New("artifact is encrypted but no encryption key is configured")
+
+// unreadable reports whether err means an object is encrypted with a key
+// that is not configured, rather than corrupted.
+func unreadable(err error) bool {
+	return errors.Is(err, errNoEncryptionKey) || errors.Is(err, encryption.ErrUnknownKey)
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// decrypt returns the plaintext of an object read from rc. Objects stored
+// in plaintext are returned as they are.
+func (s *ModelService) decrypt(rc io.ReadCloser) (io.ReadCloser, error) {
+	br := bufio.NewReaderSize(rc, encryption.HeaderSize)
+	header, _ := br.Peek(encryption.HeaderSize)
+	if !encryption.IsEncrypted(header) {
+		return readCloser{br, rc}, nil
+	}
+	if s.encryption == nil {
+		rc.Close()
+		return nil, errNoEncryptionKey
+	}
+	r, err := s.encryption.Decrypt(br)
+	if err != nil {
+		rc.Close()
+		return nil, err
+	}
+	return readCloser{r, rc}, nil
+}
+
+// decrypting wraps open so that encrypted objects read as their plaintext.
+func (s *ModelService) decrypting(open func(ctx context.Context, key string) (io.ReadCloser, error)) func(ctx context.Context, key string) (io.ReadCloser, error) {
+	return func(ctx context.Context, key string) (io.ReadCloser, error) {
+		rc, err := open(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		return s.decrypt(rc)
+	}
+}
+
+// readEncrypted decrypts a local encrypted model file into memory. It
+// returns nil for files stored in plaintext.
+func (s *ModelService) readEncrypted(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	header := make([]byte, encryption.HeaderSize)
+	n, _ := io.ReadFull(f, header)
+	if !encryption.IsEncrypted(header[:n]) {
+		return nil, nil
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	r, err := s.decrypt(f)
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(r)
+}
+
+// RotationReport is the outcome of re-encrypting the artifact store.
+type RotationReport struct {
+	// KeyID is the id of the key every artifact is now encrypted with.
+	KeyID string `json:"key_id"`
+	// Reencrypted counts artifacts, sidecars, model records and background
+	// datasets moved from a previous key.
+	Reencrypted int `json:"reencrypted"`
+	// Encrypted counts model artifacts, sidecars, records and background
+	// datasets that were stored in plaintext.
+	Encrypted int `json:"encrypted"`
+	// Unchanged counts artifacts already encrypted with the current key.
+	Unchanged int `json:"unchanged"`
+	// Failed lists artifacts that could not be rewritten; they keep their
+	// previous content.
+	Failed []string `json:"failed,omitempty"`
+}
+
+// RotateEncryptionKeys rewrites every encrypted object in storage that is
+// not encrypted with the current key, and encrypts the model artifacts,
+// sidecars and records of registered models that are stored in plaintext.
+// Objects are decrypted and encrypted again as a stream and replaced
+// atomically, so an object that fails authentication or, for model
+// artifacts, its digest is left as it is and reported. Once no object
+// fails, previous keys can be dropped.
+func (s *ModelService) RotateEncryptionKeys(ctx context.Context) (*RotationReport, error) {
+	if s.encryption == nil {
+		return nil, &domain.ValidationError{Field: "encryption", Message: "encryption at rest is not configured"}
+	}
+
+	s.rotateMu.Lock()
+	defer s.rotateMu.Unlock()
+
+	store := s.cache.Store()
+	objects, err := store.List(ctx, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list stored artifacts: %w", err)
+	}
+
+	current := s.encryption.Current().ID()
+	report := &RotationReport{KeyID: current}
+	for _, o := range objects {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		// Hold the lock per object, so commits and rollbacks of the same
+		// key are not interleaved with its rewrite
+		s.storeMu.Lock()
+		action, err := s.rotateObject(ctx, store, o.Key, current)
+		s.storeMu.Unlock()
+
+		switch {
+		case err != nil:
+			logger.Error("failed to re-encrypt stored artifact", "key", o.Key, "error", err)
+			report.Failed = append(report.Failed, fmt.Sprintf("%s: %v", o.Key, err))
+		case action == rotateReencrypted:
+			report.Reencrypted++
+		case action == rotateEncrypted:
+			report.Encrypted++
+		case action == rotateUnchanged:
+			report.Unchanged++
+		}
+	}
+
+	logger.Info("artifact encryption keys rotated",
+		"key_id", current,
+		"reencrypted", report.Reencrypted,
+		"encrypted", report.Encrypted,
+		"unchanged", report.Unchanged,
+		"failed", len(report.Failed),
+	)
+	return report, nil
+}
+
+type rotateAction int
+
+const (
+	rotateSkipped rotateAction = iota
+	rotateUnchanged
+	rotateReencrypted
+	rotateEncrypted
+)
+
+// rotateObject rewrites one stored object under the current key if needed.
+// The caller must hold storeMu.
+func (s *ModelService) rotateObject(ctx context.Context, store storage.Storage, key, current string) (rotateAction, error) {
+	// Single-file model artifacts, their sidecars, records and background
+	// datasets are encrypted; the digest artifacts are stored under guards
+	// their rewrite
+	var want string
+	sidecar := false
+	for _, a := range s.stored {
+		if !a.Bundle && a.Key == key {
+			want = a.Digest
+			break
+		}
+		if !a.Bundle && a.SidecarKey == key {
+			sidecar = true
+		}
+	}
+
+	stat, err := store.Stat(ctx, key)
+	if errors.Is(err, storage.ErrNotFound) {
+		return rotateSkipped, nil // removed since it was listed
+	}
+	if err != nil {
+		return rotateSkipped, err
+	}
+
+	rc, err := store.Open(ctx, key)
+	if err != nil {
+		return rotateSkipped, err
+	}
+	defer rc.Close()
+
+	br := bufio.NewReaderSize(rc, encryption.HeaderSize)
+	header, _ := br.Peek(encryption.HeaderSize)
+	action, size := rotateEncrypted, stat.Size
+	if encryption.IsEncrypted(header) {
+		keyID, err := encryption.KeyID(header)
+		if err != nil {
+			return rotateSkipped, err
+		}
+		if keyID == current {
+			return rotateUnchanged, nil
+		}
+		if size, err = encryption.PlaintextSize(stat.Size); err != nil {
+			return rotateSkipped, err
+		}
+		action = rotateReencrypted
+	} else if want == "" && !sidecar && !strings.HasPrefix(key, recordsPrefix) && !strings.HasPrefix(key, backgroundsPrefix) {
+		return rotateSkipped, nil // bundle or unused artifact
+	}
+
+	plain, err := s.decrypt(readCloser{br, rc})
+	if err != nil {
+		return rotateSkipped, err
+	}
+	if want != "" {
+		plain = readCloser{&digestReader{r: plain, h: sha256.New(), want: want}, plain}
+	}
+
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(s.encryption.Encrypt(pw, plain))
+	}()
+	err = store.Put(ctx, key, pr, encryption.EncryptedSize(size))
+	pr.CloseWithError(err) // unblocks the encryption if Put gave up early
+	if err != nil {
+		return rotateSkipped, err
+	}
+
+	// The cached copy is under the previous key or in plaintext
+	if err := s.cache.Evict(key); err != nil {
+		logger.Warn("failed to evict cached model file", "key", key, "error", err)
+	}
+	return action, nil
+}
+
+// digestReader fails at the end of r if its content does not have the
+// digest want, instead of returning io.EOF.
+type digestReader struct {
+	r    io.Reader
+	h    hash.Hash
+	want string
+}
+
+func (d *digestReader) Read(p []byte) (int, error) {
+	n, err := d.r.Read(p)
+	d.h.Write(p[:n])
+	if errors.Is(err, io.EOF) {
+		if sum := hex.EncodeToString(d.h.Sum(nil)); sum != d.want {
+			return n, fmt.Errorf("content digest is %s, expected %s", sum, d.want)
+		}
+	}
+	return n, err
+}
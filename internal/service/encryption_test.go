@@ -0,0 +1,121 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/kevo-1/model-nexus/internal/backend"
+	"github.com/kevo-1/model-nexus/internal/encryption"
+	"github.com/kevo-1/model-nexus/internal/repository"
+	"github.com/kevo-1/model-nexus/internal/storage"
+)
+
+func TestRotateEncryptionKeys(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	store, err := storage.NewLocal(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cache, err := storage.NewCache(store, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	newService := func(keys *encryption.Keyring) *ModelService {
+		s := NewModelService(repository.NewModelRegistry(), repository.NewPipelineRegistry(), backend.NewRegistry(), cache)
+		if keys != nil {
+			s.SetEncryption(keys)
+		}
+		return s
+	}
+	key := func() *encryption.Key {
+		secret := make([]byte, encryption.KeySize)
+		rand.Read(secret)
+		k, err := encryption.NewKey(secret)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return k
+	}
+
+	// A record and a background dataset stored in plaintext, and an object
+	// no model uses, which rotation leaves alone
+	objects := map[string][]byte{
+		recordKey("iris"):     []byte(`{"id":"iris"}`),
+		backgroundKey("iris"): []byte(`[[1,2,3,4]]`),
+	}
+	plain := newService(nil)
+	for key, data := range objects {
+		if err := plain.putSealed(ctx, key, data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	unused := []byte("unused artifact")
+	if err := store.Put(ctx, "unused.onnx", bytes.NewReader(unused), int64(len(unused))); err != nil {
+		t.Fatal(err)
+	}
+
+	k1, k2, k3 := key(), key(), key()
+	tests := []struct {
+		name string
+		keys *encryption.Keyring
+		want RotationReport
+	}{
+		{"encrypt plaintext", encryption.NewKeyring(k1), RotationReport{Encrypted: 2}},
+		{"re-encrypt", encryption.NewKeyring(k2, k1), RotationReport{Reencrypted: 2}},
+		{"already rotated", encryption.NewKeyring(k2, k1), RotationReport{Unchanged: 2}},
+		{"re-encrypt rotated objects", encryption.NewKeyring(k3, k2), RotationReport{Reencrypted: 2}},
+		{"already rotated again", encryption.NewKeyring(k3), RotationReport{Unchanged: 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newService(tt.keys)
+			report, err := s.RotateEncryptionKeys(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.want.KeyID = tt.keys.Current().ID()
+			if report.KeyID != tt.want.KeyID || report.Encrypted != tt.want.Encrypted ||
+				report.Reencrypted != tt.want.Reencrypted || report.Unchanged != tt.want.Unchanged || len(report.Failed) > 0 {
+				t.Fatalf("report %+v, want %+v", *report, tt.want)
+			}
+
+			// Every object is under the current key and reads as before
+			for key, data := range objects {
+				raw, _ := os.ReadFile(store.Path(key))
+				if id, err := encryption.KeyID(raw); err != nil || id != tt.want.KeyID {
+					t.Fatalf("%s sealed by %s, %v; want %s", key, id, err, tt.want.KeyID)
+				}
+				rc, err := s.decrypting(store.Open)(ctx, key)
+				if err != nil {
+					t.Fatal(err)
+				}
+				got, err := io.ReadAll(rc)
+				rc.Close()
+				if err != nil || !bytes.Equal(got, data) {
+					t.Fatalf("%s reads %q, %v; want %q", key, got, err, data)
+				}
+			}
+			if raw, _ := os.ReadFile(store.Path("unused.onnx")); !bytes.Equal(raw, unused) {
+				t.Fatal("unused artifact was rewritten")
+			}
+		})
+	}
+
+	// Objects under a key that is no longer configured fail and are kept
+	before, _ := os.ReadFile(store.Path(recordKey("iris")))
+	report, err := newService(encryption.NewKeyring(key())).RotateEncryptionKeys(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Failed) != 2 {
+		t.Fatalf("%d failures, want 2: %v", len(report.Failed), report.Failed)
+	}
+	if after, _ := os.ReadFile(store.Path(recordKey("iris"))); !bytes.Equal(before, after) {
+		t.Fatal("object under an unknown key was rewritten")
+	}
+}
@@ -0,0 +1,265 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/kevo-1/model-nexus/internal/domain"
+	"github.com/kevo-1/model-nexus/internal/explain"
+	"github.com/kevo-1/model-nexus/internal/logger"
+	"github.com/kevo-1/model-nexus/internal/repository"
+	"github.com/kevo-1/model-nexus/internal/storage"
+)
+
+const (
+	defaultSampleBudget = 2048
+	maxSampleBudget     = 100000
+	defaultTimeLimit    = 10 * time.Second
+	maxTimeLimit        = 60 * time.Second
+	maxBackgroundRows   = 10000
+)
+
+type ExplainService struct {
+	registry *repository.ModelRegistry
+	models   *ModelService
+
+	mu          sync.RWMutex
+	backgrounds map[string][][]float64
+}
+
+// NewExplainService creates an explain service that stores background
+// datasets with the artifacts of models, encrypted like them.
+func NewExplainService(registry *repository.ModelRegistry, models *ModelService) *ExplainService {
+	return &ExplainService{
+		registry:    registry,
+		models:      models,
+		backgrounds: make(map[string][][]float64),
+	}
+}
+
+// SetBackground stores the reference dataset for a model alongside the
+// model artifacts so it survives restarts. It is encrypted when encryption
+// at rest is on.
+func (s *ExplainService) SetBackground(ctx context.Context, req domain.BackgroundRequest) error {
+	if err := domain.ValidateModelID("model_id", req.ModelID); err != nil {
+		return err
+	}
+	if len(req.Rows) == 0 {
+		return &domain.ValidationError{Field: "rows", Message: "rows cannot be empty"}
+	}
+	if len(req.Rows) > maxBackgroundRows {
+		return &domain.ValidationError{Field: "rows", Message: fmt.Sprintf("at most %d rows are allowed", maxBackgroundRows)}
+	}
+
+	width := len(req.Rows[0])
+	for i, row := range req.Rows {
+		if len(row) == 0 || len(row) != width {
+			return &domain.ValidationError{
+				Field:   fmt.Sprintf("rows[%d]", i),
+				Message: fmt.Sprintf("every row must have the same non-zero length (%d)", width),
+			}
+		}
+	}
+
+	if _, err := s.registry.Lookup(req.ModelID); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(req.Rows)
+	if err != nil {
+		return err
+	}
+	// A key rotation must not rewrite the dataset with its previous content
+	s.models.storeMu.Lock()
+	err = s.models.putSealed(ctx, backgroundKey(req.ModelID), data)
+	s.models.storeMu.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to save background dataset: %w", err)
+	}
+
+	s.mu.Lock()
+	s.backgrounds[req.ModelID] = req.Rows
+	s.mu.Unlock()
+
+	logger.Info("background dataset saved", "model_id", req.ModelID, "rows", len(req.Rows))
+	return nil
+}
+
+func (s *ExplainService) background(ctx context.Context, modelID string) ([][]float64, error) {
+	s.mu.RLock()
+	rows, ok := s.backgrounds[modelID]
+	s.mu.RUnlock()
+	if ok {
+		return rows, nil
+	}
+
+	r, err := s.models.decrypting(s.models.cache.Store().Open)(ctx, backgroundKey(modelID))
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, &domain.BackgroundNotFoundError{ModelID: modelID}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read background dataset: %w", err)
+	}
+	data, err := io.ReadAll(r)
+	r.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read background dataset: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &rows); err != nil {
+		return nil, fmt.Errorf("failed to parse background dataset: %w", err)
+	}
+
+	s.mu.Lock()
+	s.backgrounds[modelID] = rows
+	s.mu.Unlock()
+
+	return rows, nil
+}
+
+// clearBackground drops the background dataset of a model that is being
+// replaced.
+func (s *ExplainService) clearBackground(ctx context.Context, modelID string) error {
+	s.models.storeMu.Lock()
+	err := s.models.cache.Store().Delete(ctx, backgroundKey(modelID))
+	s.models.storeMu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	delete(s.backgrounds, modelID)
+	s.mu.Unlock()
+	return nil
+}
+
+// backgroundsPrefix is where background datasets are stored.
+const backgroundsPrefix = "backgrounds/"
+
+// backgroundKey names the stored dataset after a hash of the model id, so
+// the id never becomes part of a storage key.
+func backgroundKey(modelID string) string {
+	sum := sha256.Sum256([]byte(modelID))
+	return backgroundsPrefix + hex.EncodeToString(sum[:]) + ".json"
+}
+
+func (s *ExplainService) Explain(ctx context.Context, req domain.ExplainRequest) (domain.ExplainResponse, error) {
+	if req.Method == "" {
+		req.Method = domain.ExplainKernelSHAP
+	}
+	if err := req.Validate(); err != nil {
+		return domain.ExplainResponse{}, err
+	}
+
+	if req.RequestID == "" {
+		req.RequestID = logger.GetRequestID(ctx)
+	}
+
+	model, err := s.registry.Get(req.ModelID)
+	if err != nil {
+		return domain.ExplainResponse{}, err
+	}
+
+	background, err := s.background(ctx, req.ModelID)
+	if err != nil {
+		return domain.ExplainResponse{}, err
+	}
+	if len(background[0]) != len(req.Features) {
+		return domain.ExplainResponse{}, &domain.InvalidInputError{Expected: len(background[0]), Got: len(req.Features)}
+	}
+
+	budget := req.SampleBudget
+	if budget == 0 {
+		budget = defaultSampleBudget
+	}
+	budget = min(budget, maxSampleBudget)
+
+	limit := time.Duration(req.TimeLimitMs) * time.Millisecond
+	if limit == 0 {
+		limit = defaultTimeLimit
+	}
+	limit = min(limit, maxTimeLimit)
+
+	ctx, cancel := context.WithTimeout(ctx, limit)
+	defer cancel()
+
+	f := func(ctx context.Context, features []float64) (float64, error) {
+		out, err := model.Predict(ctx, features)
+		if err != nil {
+			return 0, err
+		}
+		if req.OutputIndex >= len(out) {
+			return 0, &domain.ValidationError{
+				Field:   "output_index",
+				Message: fmt.Sprintf("model returns %d values", len(out)),
+			}
+		}
+		return out[req.OutputIndex], nil
+	}
+
+	cfg := explain.Config{Budget: budget, Seed: req.Seed}
+
+	logger.Info("explanation started",
+		"request_id", req.RequestID,
+		"model_id", req.ModelID,
+		"method", req.Method,
+		"sample_budget", budget,
+	)
+	start := time.Now()
+
+	var result *explain.Result
+	switch req.Method {
+	case domain.ExplainKernelSHAP:
+		result, err = explain.KernelSHAP(ctx, f, req.Features, background, cfg)
+	case domain.ExplainPermutation:
+		result, err = explain.Permutation(ctx, f, req.Features, background, cfg)
+	}
+	if errors.Is(err, explain.ErrBudgetTooSmall) {
+		return domain.ExplainResponse{}, &domain.ValidationError{Field: "sample_budget", Message: err.Error()}
+	}
+	if err != nil {
+		logger.Error("explanation failed",
+			"request_id", req.RequestID,
+			"model_id", req.ModelID,
+			"error", err,
+		)
+		return domain.ExplainResponse{}, err
+	}
+
+	latency := time.Since(start).Seconds() * 1000
+
+	logger.Info("explanation completed",
+		"request_id", req.RequestID,
+		"model_id", req.ModelID,
+		"evaluations", result.Evaluations,
+		"truncated", result.Truncated,
+		"latency_ms", latency,
+	)
+
+	var featureNames []string
+	if fn, ok := model.(domain.FeatureNamer); ok && len(fn.FeatureNames()) == len(req.Features) {
+		featureNames = fn.FeatureNames()
+	}
+
+	return domain.ExplainResponse{
+		ModelID:      req.ModelID,
+		RequestID:    req.RequestID,
+		Method:       req.Method,
+		OutputIndex:  req.OutputIndex,
+		Prediction:   result.Value,
+		BaseValue:    result.BaseValue,
+		Attributions: result.Attributions,
+		FeatureNames: featureNames,
+		Evaluations:  result.Evaluations,
+		Truncated:    result.Truncated,
+		LatencyMs:    latency,
+		Timestamp:    time.Now(),
+	}, nil
+}
@@ -0,0 +1,226 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/kevo-1/model-nexus/internal/digest"
+	"github.com/kevo-1/model-nexus/internal/logger"
+	"github.com/kevo-1/model-nexus/internal/mnx"
+	"github.com/kevo-1/model-nexus/internal/storage"
+)
+
+// storedArtifact records the storage keys of a registered model's content
+// and the digest it was stored under.
+type storedArtifact struct {
+	Digest     string `json:"digest"`
+	Key        string `json:"key"` // <digest><ext> object or <digest> bundle prefix
+	Bundle     bool   `json:"bundle,omitempty"`
+	ModelKey   string `json:"model_key"`
+	SidecarKey string `json:"sidecar_key"` // excluded from the digest
+}
+
+// storedKeys lists the content keys of a in store, without the sidecar.
+// It returns nothing if the artifact is not stored.
+func storedKeys(ctx context.Context, store storage.Storage, a storedArtifact) ([]string, error) {
+	if !a.Bundle {
+		_, err := store.Stat(ctx, a.Key)
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		return []string{a.Key}, nil
+	}
+
+	objects, err := store.List(ctx, a.Key+"/")
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]string, 0, len(objects))
+	for _, o := range objects {
+		if o.Key != a.SidecarKey {
+			keys = append(keys, o.Key)
+		}
+	}
+	return keys, nil
+}
+
+// verifyContent re-hashes the content keys of a, read through open, and
+// compares the result with its recorded digest.
+func verifyContent(ctx context.Context, a storedArtifact, keys []string, open func(ctx context.Context, key string) (io.ReadCloser, error)) error {
+	if len(keys) == 0 {
+		return fmt.Errorf("%s: %w", a.Key, storage.ErrNotFound)
+	}
+
+	files := make(map[string]string, len(keys))
+	for _, key := range keys {
+		r, err := open(ctx, key)
+		if err != nil {
+			return err
+		}
+		sum, err := digest.Reader(r)
+		r.Close()
+		if err != nil {
+			return err
+		}
+		files[strings.TrimPrefix(key, a.Key+"/")] = sum
+	}
+
+	sum := files[a.Key]
+	if a.Bundle {
+		sum = digest.Combine(files)
+	}
+	if sum != a.Digest {
+		return fmt.Errorf("content digest is %s, expected %s", sum, a.Digest)
+	}
+	return nil
+}
+
+// verifyArtifact checks a's content in storage. Encrypted content is
+// authenticated as it is decrypted, then hashed like plaintext.
+func (s *ModelService) verifyArtifact(ctx context.Context, a storedArtifact) error {
+	store := s.cache.Store()
+	keys, err := storedKeys(ctx, store, a)
+	if err != nil {
+		return err
+	}
+	return verifyContent(ctx, a, keys, s.decrypting(store.Open))
+}
+
+// verifyModel checks the stored artifact of the model registered under id.
+func (s *ModelService) verifyModel(ctx context.Context, id string) error {
+	s.storeMu.Lock()
+	a, ok := s.stored[id]
+	s.storeMu.Unlock()
+	if !ok {
+		return fmt.Errorf("model %s has no stored artifact", id)
+	}
+	return s.verifyArtifact(ctx, a)
+}
+
+// refreshCache checks the cached copy of a, which sessions are loaded
+// from, and downloads it again if it was modified. A copy that is not
+// cached is left to be fetched when needed.
+func (s *ModelService) refreshCache(ctx context.Context, a storedArtifact) {
+	if _, ok := s.cache.Store().(storage.FileStorage); ok {
+		return
+	}
+
+	keys, err := storedKeys(ctx, s.cache.Store(), a)
+	if err != nil {
+		return
+	}
+	err = verifyContent(ctx, a, keys, s.decrypting(func(ctx context.Context, key string) (io.ReadCloser, error) {
+		return os.Open(s.cache.Path(key))
+	}))
+	if err == nil || errors.Is(err, os.ErrNotExist) {
+		return
+	}
+
+	logger.Warn("refreshing corrupted cached artifact", "key", a.Key, "digest", a.Digest, "error", err)
+	for _, key := range append(keys, a.SidecarKey) {
+		if err := s.cache.Evict(key); err != nil {
+			logger.Warn("failed to evict cached model file", "key", key, "error", err)
+			continue
+		}
+		if _, err := s.cache.Fetch(ctx, key); err != nil && !errors.Is(err, storage.ErrNotFound) {
+			logger.Warn("failed to fetch model file", "key", key, "error", err)
+		}
+	}
+}
+
+// Digest returns the digest a model's artifact is stored under, or "" for
+// models without one.
+func (s *ModelService) Digest(id string) string {
+	s.storeMu.Lock()
+	defer s.storeMu.Unlock()
+
+	return s.stored[id].Digest
+}
+
+// VerifyArtifacts re-hashes every stored artifact in use and re-verifies
+// the signatures of the models using it. Models whose artifact is missing
+// or modified in storage, or whose signature the policy does not accept,
+// are marked unhealthy, so they are no longer served; models that verify
+// again are marked healthy. A modified cached copy is replaced from
+// storage. It returns the number of corrupted artifacts.
+func (s *ModelService) VerifyArtifacts(ctx context.Context) int {
+	type user struct {
+		id        string
+		signature *modelSignature
+		pkg       *mnx.Manifest
+	}
+	s.storeMu.Lock()
+	users := make(map[storedArtifact][]user)
+	for id, a := range s.stored {
+		u := user{id: id, pkg: s.packages[id]}
+		if sig, ok := s.signatures[id]; ok {
+			u.signature = &sig
+		}
+		users[a] = append(users[a], u)
+	}
+	s.storeMu.Unlock()
+
+	corrupted := 0
+	for a, models := range users {
+		err := s.verifyArtifact(ctx, a)
+		content := ""
+		if err != nil {
+			corrupted++
+			content = fmt.Sprintf("stored artifact failed verification: %v", err)
+		} else {
+			s.refreshCache(ctx, a)
+		}
+
+		// Log transitions only, not every failed pass
+		for _, m := range models {
+			reason := content
+			if reason == "" && s.signaturesChecked() {
+				_, problem := s.recheckSignature(a.Digest, m.signature, m.pkg)
+				reason = s.untrusted(problem)
+			}
+
+			switch prev := s.registry.Health(m.id); {
+			case prev == "" && reason != "":
+				logger.Error("model marked unhealthy",
+					"model_id", m.id,
+					"key", a.Key,
+					"digest", a.Digest,
+					"reason", reason,
+				)
+			case prev != "" && reason == "":
+				logger.Info("model healthy again", "model_id", m.id, "digest", a.Digest)
+			}
+			s.registry.SetHealth(m.id, reason)
+		}
+	}
+	return corrupted
+}
+
+// RunIntegrityChecks verifies stored artifacts every interval until ctx is
+// done.
+func (s *ModelService) RunIntegrityChecks(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			start := time.Now()
+			corrupted := s.VerifyArtifacts(ctx)
+			logger.Info("artifact integrity check finished",
+				"corrupted", corrupted,
+				"duration_ms", time.Since(start).Milliseconds(),
+			)
+		}
+	}
+}
@@ -0,0 +1,13 @@
+package service
+
+import (
+	"os"
+	"testing"
+
+	"github.com/kevo-1/model-nexus/internal/logger"
+)
+
+func TestMain(m *testing.M) {
+	logger.Init()
+	os.Exit(m.Run())
+}
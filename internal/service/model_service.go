@@ -1,133 +1,898 @@
 package service
 
 import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 
+	"github.com/kevo-1/model-nexus/internal/backend"
+	"github.com/kevo-1/model-nexus/internal/bundle"
+	"github.com/kevo-1/model-nexus/internal/digest"
 	"github.com/kevo-1/model-nexus/internal/domain"
+	"github.com/kevo-1/model-nexus/internal/encryption"
+	"github.com/kevo-1/model-nexus/internal/ensemble"
 	"github.com/kevo-1/model-nexus/internal/logger"
+	"github.com/kevo-1/model-nexus/internal/mnx"
+	"github.com/kevo-1/model-nexus/internal/pipeline"
 	"github.com/kevo-1/model-nexus/internal/repository"
+	"github.com/kevo-1/model-nexus/internal/signing"
+	"github.com/kevo-1/model-nexus/internal/storage"
 	"github.com/kevo-1/model-nexus/pkg/onnx"
 )
 
 type ModelService struct {
 	registry  *repository.ModelRegistry
-	modelsDir string
+	pipelines *repository.PipelineRegistry
+	backends  *backend.Registry
+	cache     *storage.Cache
+	staging   string // where uploads are staged until they are committed
+
+	mu      sync.Mutex
+	pending map[string]bool // ids with an upload in flight
+
+	// ensembleMu serialises ensemble registrations.
+	ensembleMu sync.Mutex
+
+	// storeMu serialises commits to storage with the bookkeeping of which
+	// models use which stored artifact.
+	storeMu sync.Mutex
+	stored  map[string]storedArtifact // model id -> artifact
+	// packages holds the manifests of models uploaded as packages.
+	packages map[string]*mnx.Manifest
+	// signatures holds the verified signatures of signed models.
+	signatures map[string]modelSignature
+
+	sources *modelSources // where RegisterFromSource may read from
+	signing SigningConfig
+
+	// encryption seals stored model artifacts when set; rotateMu
+	// serialises key rotations.
+	encryption *encryption.Keyring
+	rotateMu   sync.Mutex
 }
 
-func NewModelService(registry *repository.ModelRegistry, modelsDir string) *ModelService {
+func NewModelService(registry *repository.ModelRegistry, pipelines *repository.PipelineRegistry, backends *backend.Registry, cache *storage.Cache) *ModelService {
+	removeStaleUploads(cache.Dir())
+
 	return &ModelService{
-		registry:  registry,
-		modelsDir: modelsDir,
+		registry:   registry,
+		pipelines:  pipelines,
+		backends:   backends,
+		cache:      cache,
+		staging:    cache.Dir(),
+		pending:    make(map[string]bool),
+		stored:     make(map[string]storedArtifact),
+		packages:   make(map[string]*mnx.Manifest),
+		signatures: make(map[string]modelSignature),
+		signing:    SigningConfig{Policy: signing.PolicyOff},
 	}
 }
 
 type RegisterModelRequest struct {
-	ID      string
-	Name    string
-	Version string
-	File    io.Reader
+	ID       string
+	Name     string
+	Version  string
+	Filename string
+	File     io.Reader
+	// SHA256 is the optional hex digest the uploaded bytes must match.
+	SHA256 string
+	// Signature is the base64 ed25519 signature over the artifact digest,
+	// made by the trusted key SigningKeyID (any trusted key when empty).
+	Signature    string
+	SigningKeyID string
+
+	// replace registers the model in place of one already registered
+	// under ID; restores set it for the overwrite policy.
+	replace bool
 }
 
 type RegisterModelResponse struct {
-	Model domain.ModelMetadata `json:"model"`
-	Info  *onnx.ModelInfo      `json:"info"`
+	Model   domain.ModelMetadata `json:"model"`
+	Info    *domain.ModelInfo    `json:"info"`
+	Package *mnx.Manifest        `json:"package,omitempty"`
 }
 
-func (s *ModelService) RegisterModel(req RegisterModelRequest) (*RegisterModelResponse, error) {
-	// 1. Validate request fields
-	if req.ID == "" || req.Name == "" || req.Version == "" {
+func (s *ModelService) RegisterModel(ctx context.Context, req RegisterModelRequest) (*RegisterModelResponse, error) {
+	// 1. Validate request fields; packages carry their name and version
+	if req.ID == "" || (!mnx.IsPackage(req.Filename) && (req.Name == "" || req.Version == "")) {
 		return nil, &domain.ValidationError{
 			Field:   "id/name/version",
 			Message: "id, name, and version are required",
 		}
 	}
+	if err := domain.ValidateModelID("id", req.ID); err != nil {
+		return nil, err
+	}
+	if err := normalizeDigest(&req); err != nil {
+		return nil, err
+	}
 
-	// 2. Build file paths
-	onnxPath := filepath.Join(s.modelsDir, req.ID+".onnx")
-	infoPath := filepath.Join(s.modelsDir, req.ID+".model_info.json")
+	// 2. Claim the id before reading the upload, so a conflicting upload
+	// never writes anything and concurrent uploads of one id cannot race
+	if err := s.reserve(req.ID, req.replace); err != nil {
+		return nil, err
+	}
+	defer s.release(req.ID)
 
-	// 3. Save the .onnx file to disk
-	if err := saveFile(req.File, onnxPath); err != nil {
-		return nil, fmt.Errorf("failed to save model file: %w", err)
+	// 3. Peek at the header to tell packages and bundles from single-file
+	// artifacts and pick the backend that owns them
+	file := bufio.NewReaderSize(req.File, backend.HeaderSize)
+	header, err := file.Peek(backend.HeaderSize)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to read model file: %w", err)
 	}
-	logger.Info("model file saved", "path", onnxPath)
 
-	// 4. Extract model info using pure Go protobuf parser — no Python needed
-	info, err := onnx.ExtractModelInfo(onnxPath)
+	// 4. Stage the artifact in a private directory, in the local cache
+	// unless a staging directory is set; nothing is stored until the
+	// commit. Artifacts are stored under their SHA-256 digest, never under
+	// the id; bundles are unpacked and stored under a per-model prefix.
+	a, pkg, err := s.stage(s.staging, req.Filename, header, file)
 	if err != nil {
-		// Clean up the saved file if parsing fails
-		if rmErr := os.Remove(onnxPath); rmErr != nil {
-			logger.Warn("failed to cleanup model file", "path", onnxPath, "error", rmErr)
+		return nil, err
+	}
+	defer os.RemoveAll(a.work) // only leftovers remain after commit
+
+	if err := s.checkEncryptable(a); err != nil {
+		return nil, err
+	}
+	if err := a.checkDigest(req.SHA256); err != nil {
+		return nil, err
+	}
+	if pkg != nil {
+		if err := pkg.checkArtifact(a, &req); err != nil {
+			return nil, err
+		}
+	}
+	keyID, err := s.checkSignature(&req, a.digest)
+	if err != nil {
+		return nil, err
+	}
+
+	b := a.backend
+
+	// 5. Extract model info through the owning backend
+	info, err := b.ExtractInfo(a.model)
+	if err != nil {
+		var verr *domain.ValidationError
+		if errors.As(err, &verr) {
+			return nil, verr
+		}
+		var perr *onnx.ParseError
+		if errors.As(err, &perr) {
+			return nil, perr
 		}
 		return nil, fmt.Errorf("failed to extract model info: %w", err)
 	}
 
-	// 5. Persist the sidecar JSON so LoadModelInfo can read it on restart
-	if err := saveModelInfoJSON(info, infoPath); err != nil {
-		if rmErr := os.Remove(onnxPath); rmErr != nil {
-			logger.Warn("failed to cleanup model file", "path", onnxPath, "error", rmErr)
+	// 6. Reject operators or opsets the runtime cannot load before they
+	// surface as an opaque session creation error
+	if len(info.Incompatible) > 0 {
+		return nil, &domain.IncompatibleModelError{ModelID: req.ID, Problems: info.Incompatible}
+	}
+
+	if n := len(info.FeatureNames); n > 0 && info.InputSize() > 0 && n != info.InputSize() {
+		logger.Warn("feature_names metadata does not match input size, ignoring it",
+			"model_id", req.ID,
+			"feature_names", n,
+			"input_size", info.InputSize(),
+		)
+		info.SetLabels(info.ClassLabels, nil)
+	}
+
+	// Lint errors describe graphs the runtime cannot load either, so they
+	// fail the upload the same way they fail /models/validate
+	var lintErrors []string
+	for _, f := range info.Lint {
+		if f.Severity == domain.LintError {
+			lintErrors = append(lintErrors, f.Message)
 		}
+	}
+	if len(lintErrors) > 0 {
+		return nil, &domain.IncompatibleModelError{ModelID: req.ID, Problems: lintErrors}
+	}
+
+	// 7. Persist the sidecar JSON next to the staged model so the predictor
+	// and LoadModelInfo find it; it is committed together with the model.
+	// It describes the model file, shared by every model with the same
+	// artifact, so package labels are only applied afterwards.
+	if err := saveModelInfoJSON(info, sidecarPath(a.model, b.Extension())); err != nil {
 		return nil, fmt.Errorf("failed to save model info sidecar: %w", err)
 	}
-	logger.Info("model info sidecar saved", "path", infoPath)
+	if pkg != nil {
+		if err := pkg.apply(info); err != nil {
+			return nil, err
+		}
+	}
 
-	// 6. Create the predictor (reads sidecar internally via LoadModelInfo)
-	predictor, err := onnx.NewONNXPredictor(req.ID, req.Name, req.Version, onnxPath)
+	// 8. Create the predictor from the staged copy, in memory when the
+	// artifact is stored encrypted
+	predictor, data, err := s.loadPredictor(b, req, a.model)
 	if err != nil {
-		if rmErr := os.Remove(onnxPath); rmErr != nil {
-			logger.Warn("failed to cleanup model file", "path", onnxPath, "error", rmErr)
+		return nil, fmt.Errorf("failed to initialize model predictor: %w", err)
+	}
+
+	// Packaged golden outputs must be reproduced before anything is stored
+	if pkg != nil {
+		if err := pkg.checkExamples(ctx, predictor); err != nil {
+			predictor.Close()
+			return nil, err
 		}
-		if rmErr := os.Remove(infoPath); rmErr != nil {
-			logger.Warn("failed to cleanup model info sidecar", "path", infoPath, "error", rmErr)
+	}
+
+	if data != nil {
+		if err := s.sealStaged(a); err != nil {
+			predictor.Close()
+			return nil, fmt.Errorf("failed to encrypt model file: %w", err)
 		}
+	}
+
+	// 9. Commit: write the staged files to storage, keeping them as the
+	// cached copy, or reuse an identical stored artifact. The lock keeps a
+	// concurrent upload of the same bytes from reusing an artifact this
+	// one may still roll back.
+	s.storeMu.Lock()
+	defer s.storeMu.Unlock()
+
+	committed, reused, err := s.commit(ctx, a)
+	if err != nil {
+		predictor.Close()
+		return nil, fmt.Errorf("failed to save model file: %w", err)
+	}
+	rollback := func() { s.discard(committed) }
+
+	modelPath := s.cache.Path(a.modelKey)
+	predictor, err = s.relocate(predictor, b, req, modelPath)
+	if err != nil {
+		rollback()
 		return nil, fmt.Errorf("failed to initialize model predictor: %w", err)
 	}
+	if pkg != nil {
+		applyPackage(predictor, pkg.Manifest)
+	}
+	logger.Info("model file saved", "path", modelPath, "format", b.Format(), "digest", a.digest, "deduplicated", reused)
 
-	// 7. Register in the registry
-	if err := s.registry.Register(req.ID, predictor); err != nil {
+	// 10. Record the model in storage, so it is loaded again at startup
+	rec := &modelRecord{
+		ID:       req.ID,
+		Name:     req.Name,
+		Version:  req.Version,
+		Format:   b.Format(),
+		Artifact: a.stored(),
+	}
+	if pkg != nil {
+		rec.Package = pkg.Manifest
+	}
+	if keyID != "" {
+		rec.Signature = &modelSignature{KeyID: keyID, Value: req.Signature}
+	}
+	if err := s.saveRecord(ctx, rec); err != nil {
 		predictor.Close()
-		if rmErr := os.Remove(onnxPath); rmErr != nil {
-			logger.Warn("failed to cleanup model file", "path", onnxPath, "error", rmErr)
-		}
-		if rmErr := os.Remove(infoPath); rmErr != nil {
-			logger.Warn("failed to cleanup model info sidecar", "path", infoPath, "error", rmErr)
-		}
+		rollback()
+		return nil, fmt.Errorf("failed to save model record: %w", err)
+	}
+
+	// 11. Register in the registry
+	previous, replaced := s.stored[req.ID]
+	if req.replace {
+		s.unload(s.registry.Replace(req.ID, predictor))
+		s.forget(req.ID)
+	} else if err := s.registry.Register(req.ID, predictor); err != nil {
+		predictor.Close()
+		s.deleteRecord(ctx, req.ID)
+		rollback()
 		return nil, err // already typed (ModelAlreadyExistsError)
 	}
+	s.stored[req.ID] = rec.Artifact
+	if rec.Package != nil {
+		s.packages[req.ID] = rec.Package
+	}
+	if rec.Signature != nil {
+		s.signatures[req.ID] = *rec.Signature
+	}
+	if req.replace && replaced {
+		s.dropUnused(previous)
+	}
 
 	logger.Info("model registered successfully",
 		"model_id", req.ID,
 		"name", req.Name,
 		"version", req.Version,
+		"format", b.Format(),
 		"inputs", len(info.Inputs),
 		"outputs", len(info.Outputs),
+		"external_data_files", info.SupportingFiles,
+		"lint_findings", len(info.Lint),
+		"signing_key_id", keyID,
 	)
 
-	return &RegisterModelResponse{
-		Model: predictor.Metadata(),
+	meta := predictor.Metadata()
+	meta.Digest = a.digest
+	meta.SigningKeyID = keyID
+	res := &RegisterModelResponse{
+		Model: meta,
 		Info:  info,
+	}
+	if pkg != nil {
+		res.Package = pkg.Manifest
+	}
+	return res, nil
+}
+
+// reserve claims an id for an in-flight upload. It fails if the id is
+// already being uploaded, or registered unless the upload replaces it.
+func (s *ModelService) reserve(id string, replace bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.registry.Lookup(id); (err == nil && !replace) || s.pending[id] {
+		return &domain.ModelAlreadyExistsError{ModelID: id}
+	}
+	s.pending[id] = true
+	return nil
+}
+
+func (s *ModelService) release(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.pending, id)
+}
+
+// forget drops what is recorded about the model registered under id before
+// it is replaced. The caller must hold storeMu.
+func (s *ModelService) forget(id string) {
+	delete(s.stored, id)
+	delete(s.packages, id)
+	delete(s.signatures, id)
+}
+
+// dropUnused deletes the stored artifact of a replaced model, with its
+// sidecar and cached copies, unless another model still uses it: one
+// registered here, or one recorded in storage, e.g. by another server
+// sharing it. The caller must hold storeMu, so no commit can reuse it
+// meanwhile.
+func (s *ModelService) dropUnused(a storedArtifact) {
+	for _, other := range s.stored {
+		if other.Key == a.Key {
+			return
+		}
+	}
+	ctx := context.Background()
+	if used, err := s.recorded(ctx, a.Key); used || err != nil {
+		if err != nil {
+			logger.Warn("keeping stored artifact that may still be used", "key", a.Key, "error", err)
+		}
+		return
+	}
+
+	keys, err := storedKeys(ctx, s.cache.Store(), a)
+	if err != nil {
+		logger.Warn("failed to list unused stored artifact", "key", a.Key, "error", err)
+		return
+	}
+	s.discard(append(keys, a.SidecarKey))
+	logger.Info("unused stored artifact deleted", "key", a.Key, "digest", a.Digest)
+}
+
+// unload closes a model replaced in the registry. Ensembles do not own
+// their members, so closing one does not affect them.
+func (s *ModelService) unload(old domain.ModelPredictor) {
+	if old == nil {
+		return
+	}
+	if err := old.Close(); err != nil {
+		logger.Warn("failed to close replaced model", "model_id", old.Metadata().ID, "error", err)
+	}
+}
+
+// relocate points a predictor created from the staged copy at the
+// committed artifact. Predictors that cannot be relocated are reloaded
+// from it, decrypting it into memory if it was sealed.
+func (s *ModelService) relocate(predictor domain.ModelPredictor, b backend.Backend, req RegisterModelRequest, path string) (domain.ModelPredictor, error) {
+	if r, ok := predictor.(domain.Relocatable); ok {
+		r.Relocate(path)
+		return predictor, nil
+	}
+	predictor.Close()
+	return s.loadArtifact(b, req.ID, req.Name, req.Version, path)
+}
+
+// artifact is an upload staged in a private work directory.
+type artifact struct {
+	backend    backend.Backend
+	work       string // staging directory
+	root       string // name in work and root storage key: <digest><ext> file or <digest> bundle prefix
+	bundle     bool
+	model      string // staged model file
+	modelKey   string // storage key of the model file
+	sidecarKey string // storage key of the model info sidecar
+	digest     string // content digest the artifact is stored under
+	upload     string // digest of the uploaded bytes
+}
+
+// checkDigest compares the uploaded bytes with the digest the client
+// expects, if any.
+func (a *artifact) checkDigest(want string) error {
+	if want != "" && want != a.upload {
+		return &domain.ValidationError{
+			Field:   "sha256",
+			Message: fmt.Sprintf("upload has digest %s, expected %s", a.upload, want),
+		}
+	}
+	return nil
+}
+
+func (a *artifact) stored() storedArtifact {
+	return storedArtifact{
+		Digest:     a.digest,
+		Key:        a.root,
+		Bundle:     a.bundle,
+		ModelKey:   a.modelKey,
+		SidecarKey: a.sidecarKey,
+	}
+}
+
+// stagedFile is one file of a staged artifact.
+type stagedFile struct {
+	Key  string
+	Path string
+}
+
+// files lists the staged files of a with their storage keys.
+func (a *artifact) files() ([]stagedFile, error) {
+	if !a.bundle {
+		files := []stagedFile{{Key: a.root, Path: filepath.Join(a.work, a.root)}}
+		sidecar := filepath.Join(a.work, filepath.FromSlash(a.sidecarKey))
+		if _, err := os.Stat(sidecar); err == nil {
+			files = append(files, stagedFile{Key: a.sidecarKey, Path: sidecar})
+		}
+		return files, nil
+	}
+
+	var files []stagedFile
+	err := filepath.WalkDir(filepath.Join(a.work, a.root), func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(a.work, path)
+		if err != nil {
+			return err
+		}
+		files = append(files, stagedFile{Key: filepath.ToSlash(rel), Path: path})
+		return nil
+	})
+	return files, err
+}
+
+// normalizeDigest validates the optional expected digest of a request.
+func normalizeDigest(req *RegisterModelRequest) error {
+	if req.SHA256 == "" {
+		return nil
+	}
+	d, err := digest.Normalize(req.SHA256)
+	if err != nil {
+		return &domain.ValidationError{Field: "sha256", Message: err.Error()}
+	}
+	req.SHA256 = d
+	return nil
+}
+
+// stageSingle stores a single-file artifact as <digest><ext>, the digest
+// being that of the file itself.
+func (s *ModelService) stageSingle(root, filename string, header []byte, file io.Reader) (*artifact, error) {
+	b, err := s.backends.Detect(filename, header)
+	if err != nil {
+		return nil, err // already typed (UnsupportedFormatError)
+	}
+
+	work, err := os.MkdirTemp(root, ".upload-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to stage model file: %w", err)
+	}
+
+	upload := filepath.Join(work, "upload")
+	sum, err := writeFile(upload, file)
+	if err != nil {
+		os.RemoveAll(work)
+		return nil, fmt.Errorf("failed to stage model file: %w", err)
+	}
+
+	name := sum + b.Extension()
+	model := filepath.Join(work, name)
+	if err := os.Rename(upload, model); err != nil {
+		os.RemoveAll(work)
+		return nil, fmt.Errorf("failed to stage model file: %w", err)
+	}
+
+	return &artifact{
+		backend:    b,
+		work:       work,
+		root:       name,
+		model:      model,
+		modelKey:   name,
+		sidecarKey: sidecarPath(name, b.Extension()),
+		digest:     sum,
+		upload:     sum,
 	}, nil
 }
 
-func saveFile(src io.Reader, dst string) error {
-	f, err := os.Create(dst)
+// stageBundle unpacks a zip or tar upload and picks the single model file
+// it contains; every other file (e.g. external tensor data) is kept
+// alongside it. The bundle is stored under a prefix named after the tree
+// digest of its files, so the same files archived differently share one
+// copy.
+func (s *ModelService) stageBundle(root, filename string, file io.Reader) (*artifact, error) {
+	work, err := os.MkdirTemp(root, ".upload-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to stage model bundle: %w", err)
+	}
+	fail := func(err error) (*artifact, error) {
+		os.RemoveAll(work)
+		return nil, err
+	}
+
+	archive := filepath.Join(work, "archive")
+	upload, err := writeFile(archive, file)
+	if err != nil {
+		return fail(fmt.Errorf("failed to stage model bundle: %w", err))
+	}
+
+	tree := filepath.Join(work, "tree")
+	files, err := bundle.Unpack(archive, filename, tree)
+	if err != nil {
+		return fail(err)
+	}
+	if err := os.Remove(archive); err != nil {
+		return fail(fmt.Errorf("failed to stage model bundle: %w", err))
+	}
+
+	a, err := s.bundleArtifact(work, tree, files, upload)
+	if err != nil {
+		return fail(err)
+	}
+	return a, nil
+}
+
+// bundleArtifact picks the single model file among the files unpacked into
+// tree and moves tree to its digest in work.
+func (s *ModelService) bundleArtifact(work, tree string, files []string, upload string) (*artifact, error) {
+	var b backend.Backend
+	var model string
+	for _, f := range files {
+		fb, err := s.backends.Detect(f, nil)
+		if err != nil {
+			continue // external data or other supporting file
+		}
+		if model != "" {
+			return nil, &domain.ValidationError{Field: "file", Message: fmt.Sprintf("bundle contains several models (%s, %s)", model, f)}
+		}
+		b, model = fb, f
+	}
+	if model == "" {
+		return nil, &domain.ValidationError{Field: "file", Message: "bundle contains no model file"}
+	}
+
+	sum, err := digest.Tree(tree, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stage model bundle: %w", err)
+	}
+	dir := filepath.Join(work, sum)
+	if err := os.Rename(tree, dir); err != nil {
+		return nil, fmt.Errorf("failed to stage model bundle: %w", err)
+	}
+
+	return &artifact{
+		backend:    b,
+		work:       work,
+		root:       sum,
+		bundle:     true,
+		model:      filepath.Join(dir, filepath.FromSlash(model)),
+		modelKey:   sum + "/" + model,
+		sidecarKey: sum + "/" + sidecarPath(model, b.Extension()),
+		digest:     sum,
+		upload:     upload,
+	}, nil
+}
+
+// RegisterEnsemble registers a composite model that fans out to already
+// registered members. Ensembles hold no artifacts, so nothing is written to
+// the models directory.
+func (s *ModelService) RegisterEnsemble(config ensemble.Config) (domain.ModelMetadata, error) {
+	return s.registerEnsemble(config, false)
+}
+
+// registerEnsemble is RegisterEnsemble, replacing the model registered
+// under the ensemble's id if replace is set.
+func (s *ModelService) registerEnsemble(config ensemble.Config, replace bool) (domain.ModelMetadata, error) {
+	if err := s.reserve(config.ID, replace); err != nil {
+		return domain.ModelMetadata{}, err
+	}
+	defer s.release(config.ID)
+
+	// Registrations are serialised, so the cycle check of one sees every
+	// ensemble registered before it
+	s.ensembleMu.Lock()
+	defer s.ensembleMu.Unlock()
+
+	e, err := ensemble.New(config, s.registry)
 	if err != nil {
+		return domain.ModelMetadata{}, err
+	}
+
+	if replace {
+		s.storeMu.Lock()
+		previous, replaced := s.stored[config.ID]
+		s.unload(s.registry.Replace(config.ID, e))
+		s.forget(config.ID)
+		s.deleteRecord(context.Background(), config.ID)
+		if replaced {
+			s.dropUnused(previous)
+		}
+		s.storeMu.Unlock()
+	} else if err := s.registry.Register(config.ID, e); err != nil {
+		return domain.ModelMetadata{}, err
+	}
+
+	logger.Info("ensemble registered successfully",
+		"model_id", config.ID,
+		"strategy", config.Strategy,
+		"members", len(config.Members),
+	)
+
+	return e.Metadata(), nil
+}
+
+// RegisterPipeline stores a pipeline definition after checking that every
+// step references a registered model.
+func (s *ModelService) RegisterPipeline(def pipeline.Definition) error {
+	return s.registerPipeline(def, false)
+}
+
+// registerPipeline is RegisterPipeline, replacing the pipeline with the
+// same id if replace is set.
+func (s *ModelService) registerPipeline(def pipeline.Definition, replace bool) error {
+	if err := def.Validate(); err != nil {
 		return err
 	}
-	defer f.Close()
 
-	if _, err := io.Copy(f, src); err != nil {
-		os.Remove(dst) // cleanup partial file
+	for _, id := range def.ModelIDs() {
+		if _, err := s.registry.Lookup(id); err != nil {
+			return err
+		}
+	}
+	if err := def.CheckOutputs(s.outputNames); err != nil {
 		return err
 	}
+
+	if replace {
+		s.pipelines.Replace(def)
+	} else if err := s.pipelines.Register(def); err != nil {
+		return err
+	}
+
+	logger.Info("pipeline registered successfully",
+		"pipeline_id", def.ID,
+		"steps", len(def.Steps),
+	)
+
 	return nil
 }
 
-func saveModelInfoJSON(info *onnx.ModelInfo, path string) error {
+// outputNames returns the output names of a registered model, or nil if it
+// does not name its outputs.
+func (s *ModelService) outputNames(id string) []string {
+	predictor, err := s.registry.Lookup(id)
+	if err != nil {
+		return nil
+	}
+	if mp, ok := predictor.(domain.MultiOutputPredictor); ok {
+		return mp.OutputNames()
+	}
+	return nil
+}
+
+// Graph describes the computation graph of a registered model. Only models
+// backed by a stored artifact of an inspectable format have one.
+func (s *ModelService) Graph(ctx context.Context, id string) (*onnx.GraphSummary, error) {
+	if err := domain.ValidateModelID("id", id); err != nil {
+		return nil, err
+	}
+
+	predictor, err := s.registry.Lookup(id)
+	if err != nil {
+		return nil, err
+	}
+
+	meta := predictor.Metadata()
+	noGraph := &domain.ValidationError{Field: "id", Message: fmt.Sprintf("model %s has no inspectable graph", id)}
+
+	if meta.Path == "" {
+		return nil, noGraph
+	}
+	b, err := s.backends.Get(meta.Format)
+	if err != nil {
+		return nil, noGraph
+	}
+	inspector, ok := b.(backend.GraphInspector)
+	if !ok {
+		return nil, noGraph
+	}
+
+	s.storeMu.Lock()
+	stored, ok := s.stored[id]
+	s.storeMu.Unlock()
+	if ok {
+		// The cached copy may have been evicted
+		if _, err := s.cache.Fetch(ctx, stored.ModelKey); err != nil {
+			return nil, fmt.Errorf("failed to fetch model file: %w", err)
+		}
+	}
+
+	// Encrypted artifacts are only ever decrypted into memory
+	data, err := s.readEncrypted(meta.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read model file: %w", err)
+	}
+	var graph *onnx.GraphSummary
+	if data != nil {
+		graph, err = inspector.ExtractGraphData(data)
+	} else {
+		graph, err = inspector.ExtractGraph(meta.Path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract model graph: %w", err)
+	}
+	return graph, nil
+}
+
+// SetStagingDir stages uploads, resumable uploads and restores in dir
+// instead of the local cache, e.g. so the plaintext of artifacts stored
+// encrypted never reaches the shared models directory. dir is created
+// private to the server, and staging directories left behind in it are
+// removed. It must be called before the service is used.
+func (s *ModelService) SetStagingDir(dir string) error {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	if err := os.Chmod(dir, 0700); err != nil {
+		return err
+	}
+	removeStaleUploads(dir)
+	s.staging = dir
+	return nil
+}
+
+// removeStaleUploads deletes staging directories left behind by uploads
+// interrupted by a crash.
+func removeStaleUploads(dir string) {
+	stale, _ := filepath.Glob(filepath.Join(dir, ".upload-*"))
+	for _, dir := range stale {
+		if err := os.RemoveAll(dir); err != nil {
+			logger.Warn("failed to remove stale upload", "path", dir, "error", err)
+		}
+	}
+}
+
+// writeFile copies an upload to path and returns the digest of the bytes
+// written.
+func writeFile(path string, src io.Reader) (string, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return "", err
+	}
+
+	h := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(f, h), src); err != nil {
+		f.Close()
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// commit writes the staged files of a to storage, keeping them as the
+// cached copy, and returns the keys it created. If storage already holds
+// an intact artifact with the same digest, it is reused and reported; one
+// that fails verification is replaced, unless it is encrypted with a key
+// that is not configured. The sidecar of a reused artifact is only stored
+// if it is missing. On failure the keys created so far are deleted again.
+func (s *ModelService) commit(ctx context.Context, a *artifact) (created []string, reused bool, err error) {
+	store := s.cache.Store()
+	stored := a.stored()
+
+	files, err := a.files()
+	if err != nil {
+		return nil, false, err
+	}
+
+	existing, err := storedKeys(ctx, store, stored)
+	if err != nil {
+		return nil, false, err
+	}
+	if len(existing) > 0 {
+		err := verifyContent(ctx, stored, existing, s.decrypting(store.Open))
+		switch {
+		case err == nil:
+			reused = true
+		case unreadable(err):
+			// Replacing it would lose an artifact another key can read
+			return nil, false, err
+		default:
+			logger.Warn("replacing corrupted stored artifact", "key", stored.Key, "digest", a.digest)
+			// Drop objects the staged bundle does not have, so the
+			// replaced artifact verifies again
+			staged := make(map[string]bool, len(files))
+			for _, f := range files {
+				staged[f.Key] = true
+			}
+			for _, key := range existing {
+				if !staged[key] {
+					if err := store.Delete(ctx, key); err != nil {
+						return nil, false, err
+					}
+				}
+			}
+		}
+	}
+
+	for _, f := range files {
+		// A reused artifact keeps its stored files, and its sidecar
+		// unless that is missing
+		_, statErr := store.Stat(ctx, f.Key)
+		if reused && (f.Key != a.sidecarKey || statErr == nil) {
+			s.cacheStaged(f)
+			continue
+		}
+
+		if err := s.cache.Commit(ctx, f.Key, f.Path); err != nil {
+			s.discard(created)
+			return nil, false, err
+		}
+		if errors.Is(statErr, storage.ErrNotFound) {
+			created = append(created, f.Key)
+		}
+	}
+	return created, reused, nil
+}
+
+// cacheStaged keeps a staged file as the cached copy of a reused artifact
+// if the cache lacks one.
+func (s *ModelService) cacheStaged(f stagedFile) {
+	if err := s.cache.Keep(f.Key, f.Path); err != nil {
+		logger.Warn("failed to cache staged model file", "key", f.Key, "error", err)
+	}
+}
+
+// discard deletes keys created by a commit that is being rolled back.
+func (s *ModelService) discard(keys []string) {
+	for _, key := range keys {
+		if err := s.cache.Store().Delete(context.Background(), key); err != nil {
+			logger.Warn("failed to cleanup stored model file", "key", key, "error", err)
+		}
+		if err := s.cache.Evict(key); err != nil {
+			logger.Warn("failed to cleanup cached model file", "key", key, "error", err)
+		}
+	}
+}
+
+// sidecarPath is where the model info JSON of a model file is stored.
+func sidecarPath(modelPath, ext string) string {
+	return strings.TrimSuffix(modelPath, ext) + ".model_info.json"
+}
+
+func saveModelInfoJSON(info *domain.ModelInfo, path string) error {
 	data, err := json.MarshalIndent(info, "", "  ")
 	if err != nil {
 		return err
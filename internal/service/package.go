@@ -0,0 +1,348 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/kevo-1/model-nexus/internal/bundle"
+	"github.com/kevo-1/model-nexus/internal/digest"
+	"github.com/kevo-1/model-nexus/internal/domain"
+	"github.com/kevo-1/model-nexus/internal/mnx"
+	"github.com/kevo-1/model-nexus/pkg/onnx"
+)
+
+const exampleTimeout = 30 * time.Second
+
+// modelPackage is the manifest of a staged .mnx upload.
+type modelPackage struct {
+	*mnx.Manifest
+}
+
+// stage writes an upload to a private staging directory under root as a
+// package, bundle or single-file artifact. The package manifest is
+// returned for packages only.
+func (s *ModelService) stage(root, filename string, header []byte, file io.Reader) (*artifact, *modelPackage, error) {
+	switch {
+	case mnx.IsPackage(filename):
+		return s.stagePackage(root, filename, file)
+	case bundle.Detect(filename, header):
+		a, err := s.stageBundle(root, filename, file)
+		return a, nil, err
+	default:
+		a, err := s.stageSingle(root, filename, header, file)
+		return a, nil, err
+	}
+}
+
+// stagePackage unpacks a .mnx upload and stages the files under model/.
+// A lone model file is staged like a plain upload, so both share one
+// stored copy; a model with external data is staged as a bundle.
+func (s *ModelService) stagePackage(root, filename string, file io.Reader) (*artifact, *modelPackage, error) {
+	work, err := os.MkdirTemp(root, ".upload-*")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to stage model package: %w", err)
+	}
+	fail := func(err error) (*artifact, *modelPackage, error) {
+		os.RemoveAll(work)
+		return nil, nil, err
+	}
+
+	archive := filepath.Join(work, "archive")
+	upload, err := writeFile(archive, file)
+	if err != nil {
+		return fail(fmt.Errorf("failed to stage model package: %w", err))
+	}
+
+	tree := filepath.Join(work, "tree")
+	files, err := bundle.Unpack(archive, filename, tree)
+	if err != nil {
+		return fail(err)
+	}
+	if err := os.Remove(archive); err != nil {
+		return fail(fmt.Errorf("failed to stage model package: %w", err))
+	}
+
+	manifest, err := mnx.Read(tree)
+	if err != nil {
+		return fail(err)
+	}
+
+	var modelFiles []string
+	for _, f := range files {
+		if f == mnx.ManifestFile || f == mnx.InfoFile {
+			continue
+		}
+		rel, ok := strings.CutPrefix(f, mnx.ModelDir+"/")
+		if !ok {
+			return fail(&domain.ValidationError{Field: "file", Message: fmt.Sprintf("package entry %q is outside %s/", f, mnx.ModelDir)})
+		}
+		modelFiles = append(modelFiles, rel)
+	}
+	model := strings.TrimPrefix(manifest.Model, mnx.ModelDir+"/")
+	if !slices.Contains(modelFiles, model) {
+		return fail(&domain.ValidationError{Field: "model", Message: fmt.Sprintf("package has no model file %s", manifest.Model)})
+	}
+
+	dir := filepath.Join(tree, mnx.ModelDir)
+	if len(modelFiles) > 1 {
+		a, err := s.bundleArtifact(work, dir, modelFiles, upload)
+		if err != nil {
+			return fail(err)
+		}
+		if a.modelKey != a.root+"/"+model {
+			return fail(&domain.ValidationError{Field: "model", Message: fmt.Sprintf("%s is not the model file of the package", manifest.Model)})
+		}
+		return a, &modelPackage{manifest}, nil
+	}
+
+	b, err := s.backends.Detect(model, nil)
+	if err != nil {
+		return fail(err)
+	}
+	sum, err := digest.File(filepath.Join(dir, filepath.FromSlash(model)))
+	if err != nil {
+		return fail(fmt.Errorf("failed to stage model package: %w", err))
+	}
+	name := sum + b.Extension()
+	if err := os.Rename(filepath.Join(dir, filepath.FromSlash(model)), filepath.Join(work, name)); err != nil {
+		return fail(fmt.Errorf("failed to stage model package: %w", err))
+	}
+	os.RemoveAll(tree)
+
+	return &artifact{
+		backend:    b,
+		work:       work,
+		root:       name,
+		model:      filepath.Join(work, name),
+		modelKey:   name,
+		sidecarKey: sidecarPath(name, b.Extension()),
+		digest:     sum,
+		upload:     upload,
+	}, &modelPackage{manifest}, nil
+}
+
+// checkArtifact compares the staged artifact with the digest recorded in
+// the manifest and fills in the name, version and signature the request
+// leaves out.
+func (p *modelPackage) checkArtifact(a *artifact, req *RegisterModelRequest) error {
+	if p.Digest != "" && p.Digest != a.digest {
+		return &domain.ValidationError{
+			Field:   "digest",
+			Message: fmt.Sprintf("package model has digest %s, manifest records %s", a.digest, p.Digest),
+		}
+	}
+	if req.Name == "" {
+		req.Name = p.Name
+	}
+	if req.Version == "" {
+		req.Version = p.Version
+	}
+	if req.Signature == "" && p.Signature != nil {
+		req.Signature = p.Signature.Value
+		req.SigningKeyID = p.Signature.KeyID
+	}
+	return nil
+}
+
+// apply stores the declared class labels and feature names in the model
+// info returned for the upload. The predictor gets them from
+// applyPackage; the sidecar is shared by every model with the same
+// artifact, so it keeps the labels of the model file itself.
+func (p *modelPackage) apply(info *domain.ModelInfo) error {
+	labels, names := info.ClassLabels, info.FeatureNames
+	if len(p.ClassLabels) > 0 {
+		labels = p.ClassLabels
+	}
+	if declared := p.FeatureNames(); declared != nil {
+		if n := info.InputSize(); n > 0 && len(declared) != n {
+			return &domain.ValidationError{
+				Field:   "features",
+				Message: fmt.Sprintf("manifest declares %d features, the model takes %d", len(declared), n),
+			}
+		}
+		names = declared
+	}
+	info.SetLabels(labels, names)
+	return nil
+}
+
+// applyPackage serves predictor with the class labels and feature names
+// declared by the package it was uploaded as, if any.
+func applyPackage(predictor domain.ModelPredictor, m *mnx.Manifest) {
+	l, ok := predictor.(domain.Labeler)
+	if m == nil || !ok {
+		return
+	}
+	var labels, names []string
+	if lp, ok := predictor.(domain.LabeledPredictor); ok {
+		labels = lp.ClassLabels()
+	}
+	if fn, ok := predictor.(domain.FeatureNamer); ok {
+		names = fn.FeatureNames()
+	}
+	if len(m.ClassLabels) > 0 {
+		labels = m.ClassLabels
+	}
+	if declared := m.FeatureNames(); declared != nil {
+		names = declared
+	}
+	l.SetLabels(labels, names)
+}
+
+// checkExamples runs every packaged example through the predictor and
+// compares the results with their golden outputs.
+func (p *modelPackage) checkExamples(ctx context.Context, predictor domain.ModelPredictor) error {
+	if len(p.Examples) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, exampleTimeout)
+	defer cancel()
+
+	var problems []string
+	for i, e := range p.Examples {
+		name := e.Name
+		if name == "" {
+			name = fmt.Sprintf("examples[%d]", i)
+		}
+		out, err := predictor.Predict(ctx, e.Features)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", name, err))
+			continue
+		}
+		if msg := e.Compare(out); msg != "" {
+			problems = append(problems, fmt.Sprintf("%s: %s", name, msg))
+		}
+	}
+	if len(problems) > 0 {
+		return &domain.ValidationError{Field: "examples", Message: strings.Join(problems, "; ")}
+	}
+	return nil
+}
+
+// Package returns the manifest a model was uploaded with, or nil. The
+// manifest must not be modified.
+func (s *ModelService) Package(id string) *mnx.Manifest {
+	s.storeMu.Lock()
+	defer s.storeMu.Unlock()
+
+	return s.packages[id]
+}
+
+// PackageExport is a model package ready to be written.
+type PackageExport struct {
+	id       string
+	manifest *mnx.Manifest
+	info     []byte
+	files    []mnx.File
+}
+
+// Filename is the suggested name of the package file.
+func (p *PackageExport) Filename() string {
+	return p.id + mnx.Extension
+}
+
+// Write streams the package to w, reading the model files from storage.
+func (p *PackageExport) Write(w io.Writer) error {
+	return mnx.Write(w, p.manifest, p.info, p.files)
+}
+
+// ExportPackage collects what is needed to write a registered model as a
+// package: its manifest (rebuilt from the served configuration for models
+// not uploaded as packages), model info and stored artifact. Uploading
+// the package again configures an identical model.
+func (s *ModelService) ExportPackage(ctx context.Context, id string) (*PackageExport, error) {
+	if err := domain.ValidateModelID("id", id); err != nil {
+		return nil, err
+	}
+	predictor, err := s.registry.Get(id)
+	if err != nil {
+		return nil, err
+	}
+
+	s.storeMu.Lock()
+	stored, ok := s.stored[id]
+	pkg := s.packages[id]
+	sig, signed := s.signatures[id]
+	s.storeMu.Unlock()
+	if !ok {
+		return nil, &domain.ValidationError{Field: "id", Message: fmt.Sprintf("model %s has no stored artifact to export", id)}
+	}
+
+	meta := predictor.Metadata()
+	m := mnx.Manifest{
+		FormatVersion: mnx.FormatVersion,
+		Name:          meta.Name,
+		Version:       meta.Version,
+		Digest:        stored.Digest,
+	}
+	if signed {
+		m.Signature = &mnx.Signature{KeyID: sig.KeyID, Value: sig.Value}
+	}
+	if pkg != nil {
+		if m.Signature == nil {
+			// Unverified, e.g. uploaded with signatures off; kept for the
+			// server importing the package
+			m.Signature = pkg.Signature
+		}
+		m.Description = pkg.Description
+		m.Tags = pkg.Tags
+		m.Features = pkg.Features
+		m.Preprocessing = pkg.Preprocessing
+		m.Examples = pkg.Examples
+	}
+	if lp, ok := predictor.(domain.LabeledPredictor); ok {
+		m.ClassLabels = lp.ClassLabels()
+	}
+	if fn, ok := predictor.(domain.FeatureNamer); ok && m.Features == nil {
+		for _, name := range fn.FeatureNames() {
+			m.Features = append(m.Features, mnx.Feature{Name: name})
+		}
+	}
+
+	export := &PackageExport{id: id, manifest: &m}
+
+	type infoProvider interface {
+		ModelInfo() *onnx.ModelInfo
+	}
+	if ip, ok := predictor.(infoProvider); ok && ip.ModelInfo() != nil {
+		if export.info, err = json.MarshalIndent(ip.ModelInfo(), "", "  "); err != nil {
+			return nil, err
+		}
+	}
+
+	// Packages are exported in plaintext, like they were uploaded
+	store := s.cache.Store()
+	open := func(key string) func() (io.ReadCloser, error) {
+		return func() (io.ReadCloser, error) { return s.decrypting(store.Open)(ctx, key) }
+	}
+
+	if !stored.Bundle {
+		// Restore the packaged file name; plain uploads are named after the id
+		name := id + path.Ext(stored.Key)
+		if pkg != nil {
+			name = strings.TrimPrefix(pkg.Model, mnx.ModelDir+"/")
+		}
+		m.Model = mnx.ModelDir + "/" + name
+		export.files = []mnx.File{{Path: name, Open: open(stored.Key)}}
+		return export, nil
+	}
+
+	keys, err := storedKeys(ctx, store, stored)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list model files: %w", err)
+	}
+	for _, key := range keys {
+		export.files = append(export.files, mnx.File{Path: strings.TrimPrefix(key, stored.Key+"/"), Open: open(key)})
+	}
+	m.Model = mnx.ModelDir + "/" + strings.TrimPrefix(stored.ModelKey, stored.Key+"/")
+	return export, nil
+}
@@ -0,0 +1,191 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/kevo-1/model-nexus/internal/domain"
+	"github.com/kevo-1/model-nexus/internal/logger"
+	"github.com/kevo-1/model-nexus/internal/metrics"
+	"github.com/kevo-1/model-nexus/internal/pipeline"
+)
+
+// PredictPipeline runs a registered pipeline step by step. Each step is a
+// regular model prediction, so per-model metrics keep working unchanged.
+func (s *PredictionService) PredictPipeline(ctx context.Context, req domain.PipelineRequest) (domain.PipelineResponse, error) {
+	if err := req.Validate(); err != nil {
+		return domain.PipelineResponse{}, err
+	}
+
+	if req.RequestID == "" {
+		req.RequestID = logger.GetRequestID(ctx)
+	}
+
+	def, err := s.pipelines.Get(req.PipelineID)
+	if err != nil {
+		return domain.PipelineResponse{}, err
+	}
+
+	logger.Info("pipeline started", "request_id", req.RequestID, "pipeline_id", req.PipelineID)
+	start := time.Now()
+
+	outputs := map[string]pipeline.Values{pipeline.InputStep: {Flat: req.Features}}
+	trace := make([]domain.StepTrace, 0, len(def.Steps))
+	lastRan := ""
+
+	for i, step := range def.Steps {
+		st := domain.StepTrace{Step: step.Name, ModelID: step.ModelID}
+
+		// Outputs or indices the request's values do not have are the
+		// caller's problem, not the model's
+		invalid := func(err error) error {
+			return &domain.ValidationError{Field: fmt.Sprintf("steps[%d]", i), Message: err.Error()}
+		}
+
+		run, err := shouldRun(step, outputs)
+		if err != nil {
+			return domain.PipelineResponse{}, invalid(err)
+		}
+		if !run {
+			st.Skipped = true
+			trace = append(trace, st)
+			continue
+		}
+
+		features, err := stepFeatures(step, outputs)
+		if err != nil {
+			return domain.PipelineResponse{}, invalid(err)
+		}
+
+		model, err := s.registry.Get(step.ModelID)
+		if err != nil {
+			return domain.PipelineResponse{}, err
+		}
+
+		stepStart := time.Now()
+		values, err := predictValues(ctx, model, features)
+		stepDuration := time.Since(stepStart).Seconds()
+		metrics.RecordPrediction(step.ModelID, err == nil, stepDuration)
+
+		if err != nil {
+			logger.Error("pipeline step failed",
+				"request_id", req.RequestID,
+				"pipeline_id", def.ID,
+				"step", step.Name,
+				"model_id", step.ModelID,
+				"error", err,
+			)
+			return domain.PipelineResponse{}, err
+		}
+
+		outputs[step.Name] = values
+		lastRan = step.Name
+
+		st.Input = features
+		st.Output = values.Flat
+		st.Outputs = values.Named
+		st.LatencyMs = stepDuration * 1000
+		trace = append(trace, st)
+	}
+
+	outputStep := pickOutput(def, outputs, lastRan)
+	if outputStep == "" {
+		return domain.PipelineResponse{}, &domain.PredictionError{
+			ModelID: def.ID,
+			Cause:   fmt.Errorf("no output step ran"),
+		}
+	}
+
+	totalLatency := time.Since(start).Seconds() * 1000
+
+	logger.Info("pipeline completed",
+		"request_id", req.RequestID,
+		"pipeline_id", def.ID,
+		"output_step", outputStep,
+		"latency_ms", totalLatency,
+	)
+
+	response := domain.PipelineResponse{
+		PipelineID: def.ID,
+		RequestID:  req.RequestID,
+		LatencyMs:  totalLatency,
+		OutputStep: outputStep,
+		Prediction: outputs[outputStep].Flat,
+		Timestamp:  time.Now(),
+	}
+	if req.Trace {
+		response.Trace = trace
+	}
+
+	return response, nil
+}
+
+// shouldRun evaluates the step condition and propagates skips: a step whose
+// condition or inputs depend on a skipped step is skipped too.
+func shouldRun(step pipeline.Step, outputs map[string]pipeline.Values) (bool, error) {
+	for _, src := range step.Inputs {
+		if _, ok := outputs[src.Step]; !ok {
+			return false, nil
+		}
+	}
+
+	if step.When == nil {
+		return true, nil
+	}
+
+	values, ok := outputs[step.When.Step]
+	if !ok {
+		return false, nil
+	}
+	return step.When.Eval(values)
+}
+
+func stepFeatures(step pipeline.Step, outputs map[string]pipeline.Values) ([]float64, error) {
+	if len(step.Inputs) == 0 {
+		return outputs[pipeline.InputStep].Flat, nil
+	}
+
+	var features []float64
+	for _, src := range step.Inputs {
+		values, err := src.Select(outputs[src.Step])
+		if err != nil {
+			return nil, fmt.Errorf("step %s: %w", step.Name, err)
+		}
+		features = append(features, values...)
+	}
+	return features, nil
+}
+
+// predictValues runs one step, keeping the outputs of models that name them
+// apart.
+func predictValues(ctx context.Context, model domain.ModelPredictor, features []float64) (pipeline.Values, error) {
+	mp, ok := model.(domain.MultiOutputPredictor)
+	if !ok {
+		prediction, err := model.Predict(ctx, features)
+		return pipeline.Values{Flat: prediction}, err
+	}
+
+	named, err := mp.PredictOutputs(ctx, features)
+	if err != nil {
+		return pipeline.Values{}, err
+	}
+	values := pipeline.Values{Named: named}
+	for _, name := range mp.OutputNames() {
+		values.Flat = append(values.Flat, named[name]...)
+	}
+	return values, nil
+}
+
+func pickOutput(def pipeline.Definition, outputs map[string]pipeline.Values, lastRan string) string {
+	if len(def.Outputs) == 0 {
+		return lastRan
+	}
+
+	for _, name := range def.Outputs {
+		if _, ok := outputs[name]; ok {
+			return name
+		}
+	}
+	return ""
+}
@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"github.com/kevo-1/model-nexus/internal/domain"
@@ -11,12 +12,14 @@ import (
 )
 
 type PredictionService struct {
-	registry *repository.ModelRegistry
+	registry  *repository.ModelRegistry
+	pipelines *repository.PipelineRegistry
 }
 
-func NewPredictionService(registry *repository.ModelRegistry) *PredictionService {
+func NewPredictionService(registry *repository.ModelRegistry, pipelines *repository.PipelineRegistry) *PredictionService {
 	return &PredictionService{
-		registry: registry,
+		registry:  registry,
+		pipelines: pipelines,
 	}
 }
 
@@ -37,7 +40,7 @@ func (s *PredictionService) Predict(ctx context.Context, req domain.PredictionRe
 	//get model from registry
 	model, err := s.registry.Get(req.ModelID)
 	if err != nil {
-		logger.Error("model not found in registry",
+		logger.Error("model not available for serving",
 			"request_id", req.RequestID,
 			"model_id", req.ModelID,
 			"error", err,
@@ -47,7 +50,22 @@ func (s *PredictionService) Predict(ctx context.Context, req domain.PredictionRe
 
 	logger.Info("prediction started", "request_id", req.RequestID, "model_id", req.ModelID)
 	inferenceStart := time.Now()
-	prediction, err := model.Predict(ctx, req.Features)
+	var prediction []float64
+	var members []domain.MemberResult
+	if len(req.Inputs) > 0 {
+		tp, ok := model.(domain.TensorPredictor)
+		if !ok {
+			return domain.PredictionResponse{}, &domain.ValidationError{
+				Field:   "inputs",
+				Message: fmt.Sprintf("model %s does not accept named inputs, use features", req.ModelID),
+			}
+		}
+		prediction, err = tp.PredictTensors(ctx, req.Inputs)
+	} else if dp, ok := model.(domain.DetailedPredictor); ok {
+		prediction, members, err = dp.PredictDetailed(ctx, req.Features)
+	} else {
+		prediction, err = model.Predict(ctx, req.Features)
+	}
 	inferenceDuration := time.Since(inferenceStart).Seconds()
 
 	// Record metrics
@@ -79,6 +97,11 @@ func (s *PredictionService) Predict(ctx context.Context, req domain.PredictionRe
 		LatencyMs:  totalLatency,
 		Prediction: prediction,
 		Timestamp:  time.Now(),
+		Members:    members,
+	}
+
+	if lp, ok := model.(domain.LabeledPredictor); ok {
+		response.Labels = domain.LabelsFor(prediction, lp.ClassLabels())
 	}
 
 	return response, nil
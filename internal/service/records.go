@@ -0,0 +1,250 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/kevo-1/model-nexus/internal/domain"
+	"github.com/kevo-1/model-nexus/internal/logger"
+	"github.com/kevo-1/model-nexus/internal/mnx"
+	"github.com/kevo-1/model-nexus/internal/storage"
+)
+
+// recordsPrefix is where model records are stored.
+const recordsPrefix = "records/"
+
+// maxRecordSize bounds a stored model record; the package manifest is the
+// only part of it that grows.
+const maxRecordSize = 4 << 20
+
+// modelRecord is what storage keeps about a registered model, so that it
+// can be registered again when the server starts.
+type modelRecord struct {
+	ID        string          `json:"id"`
+	Name      string          `json:"name"`
+	Version   string          `json:"version"`
+	Format    string          `json:"format"`
+	Artifact  storedArtifact  `json:"artifact"`
+	Signature *modelSignature `json:"signature,omitempty"`
+	Package   *mnx.Manifest   `json:"package,omitempty"`
+}
+
+// recordKey names a model's record after a hash of its id, so the id never
+// becomes part of a storage key.
+func recordKey(id string) string {
+	sum := sha256.Sum256([]byte(id))
+	return recordsPrefix + hex.EncodeToString(sum[:]) + ".json"
+}
+
+// saveRecord stores the record of a model, replacing any previous one.
+func (s *ModelService) saveRecord(ctx context.Context, rec *modelRecord) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	return s.putSealed(ctx, recordKey(rec.ID), data)
+}
+
+// deleteRecord drops the record of a model that is no longer backed by a
+// stored artifact.
+func (s *ModelService) deleteRecord(ctx context.Context, id string) {
+	if err := s.cache.Store().Delete(ctx, recordKey(id)); err != nil {
+		logger.Warn("failed to delete model record", "model_id", id, "error", err)
+	}
+}
+
+func (s *ModelService) readRecord(ctx context.Context, key string) (*modelRecord, error) {
+	r, err := s.decrypting(s.cache.Store().Open)(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	data, err := io.ReadAll(io.LimitReader(r, maxRecordSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxRecordSize {
+		return nil, fmt.Errorf("record exceeds %d bytes", maxRecordSize)
+	}
+
+	var rec modelRecord
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return nil, fmt.Errorf("invalid record: %w", err)
+	}
+	if err := domain.ValidateModelID("id", rec.ID); err != nil {
+		return nil, err
+	}
+	if key != recordKey(rec.ID) {
+		return nil, fmt.Errorf("record of %s is stored under %s", rec.ID, key)
+	}
+	return &rec, nil
+}
+
+// recorded reports whether a model record in storage uses the artifact
+// stored under key.
+func (s *ModelService) recorded(ctx context.Context, key string) (bool, error) {
+	objects, err := s.cache.Store().List(ctx, recordsPrefix)
+	if err != nil {
+		return false, err
+	}
+	for _, o := range objects {
+		rec, err := s.readRecord(ctx, o.Key)
+		if err != nil {
+			return false, err
+		}
+		if rec.Artifact.Key == key {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// LoadModels registers every model recorded in storage, e.g. after a
+// restart or on a new host sharing the store. Models are loaded from their
+// stored artifact like uploads are; one whose artifact fails verification,
+// or whose signature the policy no longer accepts, is registered but marked
+// unhealthy. Models that cannot be loaded at all are logged and left out,
+// keeping their record. It returns the number of models registered; only a
+// failure to list the records is an error.
+func (s *ModelService) LoadModels(ctx context.Context) (int, error) {
+	objects, err := s.cache.Store().List(ctx, recordsPrefix)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list model records: %w", err)
+	}
+
+	loaded := 0
+	for _, o := range objects {
+		if err := ctx.Err(); err != nil {
+			return loaded, err
+		}
+		rec, err := s.readRecord(ctx, o.Key)
+		if err != nil {
+			logger.Error("failed to read model record", "key", o.Key, "error", err)
+			continue
+		}
+		if err := s.loadModel(ctx, rec); err != nil {
+			logger.Error("failed to load stored model", "model_id", rec.ID, "digest", rec.Artifact.Digest, "error", err)
+			continue
+		}
+		loaded++
+	}
+	return loaded, nil
+}
+
+// loadModel registers a recorded model.
+func (s *ModelService) loadModel(ctx context.Context, rec *modelRecord) error {
+	if err := s.reserve(rec.ID, false); err != nil {
+		return err
+	}
+	defer s.release(rec.ID)
+
+	// Content is checked before the artifact is used, and the signature
+	// against the keys trusted now, as on upload
+	reason := ""
+	if err := s.verifyArtifact(ctx, rec.Artifact); err != nil {
+		if errors.Is(err, storage.ErrNotFound) || unreadable(err) {
+			return err
+		}
+		reason = fmt.Sprintf("stored artifact failed verification: %v", err)
+	}
+	if s.signaturesChecked() {
+		keyID, problem := s.recheckSignature(rec.Artifact.Digest, rec.Signature, rec.Package)
+		switch {
+		case problem != "":
+			logger.Warn("stored model has no trusted signature", "model_id", rec.ID, "digest", rec.Artifact.Digest, "reason", problem)
+			rec.Signature = nil
+			if reason == "" {
+				reason = s.untrusted(problem)
+			}
+		case rec.Signature == nil:
+			// Verified through the package signature
+			rec.Signature = &modelSignature{KeyID: keyID, Value: rec.Package.Signature.Value}
+		}
+	}
+
+	predictor, err := s.loadStored(ctx, rec)
+	if err != nil {
+		return err
+	}
+
+	s.storeMu.Lock()
+	defer s.storeMu.Unlock()
+
+	if err := s.registry.Register(rec.ID, predictor); err != nil {
+		predictor.Close()
+		return err
+	}
+	s.stored[rec.ID] = rec.Artifact
+	if rec.Package != nil {
+		s.packages[rec.ID] = rec.Package
+	}
+	if rec.Signature != nil {
+		s.signatures[rec.ID] = *rec.Signature
+	}
+	if reason != "" {
+		logger.Error("model marked unhealthy", "model_id", rec.ID, "digest", rec.Artifact.Digest, "error", reason)
+		s.registry.SetHealth(rec.ID, reason)
+	}
+
+	logger.Info("stored model loaded",
+		"model_id", rec.ID,
+		"name", rec.Name,
+		"version", rec.Version,
+		"format", rec.Format,
+		"digest", rec.Artifact.Digest,
+	)
+	return nil
+}
+
+// loadStored creates the predictor of a recorded model from its stored
+// artifact, fetching every file of it into the cache first.
+func (s *ModelService) loadStored(ctx context.Context, rec *modelRecord) (domain.ModelPredictor, error) {
+	b, err := s.backends.Get(rec.Format)
+	if err != nil {
+		return nil, err
+	}
+
+	a := rec.Artifact
+	keys := []string{a.ModelKey, a.SidecarKey}
+	if a.Bundle {
+		objects, err := s.cache.Store().List(ctx, a.Key+"/")
+		if err != nil {
+			return nil, err
+		}
+		keys = keys[:0]
+		for _, o := range objects {
+			keys = append(keys, o.Key)
+		}
+	}
+	for _, key := range keys {
+		if _, err := s.cache.Fetch(ctx, key); err != nil {
+			return nil, fmt.Errorf("failed to fetch %s: %w", key, err)
+		}
+	}
+
+	predictor, err := s.loadArtifact(b, rec.ID, rec.Name, rec.Version, s.cache.Path(a.ModelKey))
+	if err != nil {
+		return nil, err
+	}
+	applyPackage(predictor, rec.Package)
+	return predictor, nil
+}
+
+// putSealed stores data under key, encrypted when encryption is on.
+func (s *ModelService) putSealed(ctx context.Context, key string, data []byte) error {
+	if s.encryption != nil {
+		var buf bytes.Buffer
+		if err := s.encryption.Encrypt(&buf, bytes.NewReader(data)); err != nil {
+			return err
+		}
+		data = buf.Bytes()
+	}
+	return s.cache.Store().Put(ctx, key, bytes.NewReader(data), int64(len(data)))
+}
@@ -0,0 +1,125 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/kevo-1/model-nexus/internal/domain"
+	"github.com/kevo-1/model-nexus/internal/logger"
+	"github.com/kevo-1/model-nexus/internal/mnx"
+	"github.com/kevo-1/model-nexus/internal/signing"
+)
+
+// SigningConfig sets how detached signatures over artifact digests are
+// checked when models are registered.
+type SigningConfig struct {
+	Policy signing.Policy
+	Keys   *signing.Keyring
+}
+
+// modelSignature is a verified signature recorded for a model.
+type modelSignature struct {
+	KeyID string `json:"key_id"`
+	Value string `json:"value"`
+}
+
+// SetSigning sets the signature policy and trusted keys. Enforcing a
+// policy without trusted keys is refused, as it would reject every model.
+// It must be called before the service is used.
+func (s *ModelService) SetSigning(cfg SigningConfig) error {
+	switch cfg.Policy {
+	case "":
+		cfg.Policy = signing.PolicyOff
+	case signing.PolicyOff, signing.PolicyWarn, signing.PolicyEnforce:
+	default:
+		return fmt.Errorf("unknown signature policy %q", cfg.Policy)
+	}
+	if cfg.Policy == signing.PolicyEnforce && cfg.Keys.Len() == 0 {
+		return fmt.Errorf("signature policy %s requires at least one trusted key", cfg.Policy)
+	}
+
+	s.signing = cfg
+	return nil
+}
+
+func (s *ModelService) signaturesChecked() bool {
+	return s.signing.Policy == signing.PolicyWarn || s.signing.Policy == signing.PolicyEnforce
+}
+
+// verifySignature checks the request's signature over the artifact digest
+// against the trusted keys. It returns the id of the key that made it, or
+// why the model has no trusted signature. Only a malformed signature is an
+// error.
+func (s *ModelService) verifySignature(req *RegisterModelRequest, digest string) (keyID, problem string, err error) {
+	if !s.signaturesChecked() {
+		return "", "", nil
+	}
+	if req.Signature == "" {
+		return "", "model is unsigned", nil
+	}
+	sig, err := signing.DecodeSignature(req.Signature)
+	if err != nil {
+		return "", "", &domain.ValidationError{Field: "signature", Message: err.Error()}
+	}
+	keyID, err = s.signing.Keys.Verify(digest, req.SigningKeyID, sig)
+	if err != nil {
+		return "", err.Error(), nil
+	}
+	return keyID, "", nil
+}
+
+// checkSignature applies the signature policy to a model about to be
+// registered and returns the id of the key that signed it, if any.
+func (s *ModelService) checkSignature(req *RegisterModelRequest, digest string) (string, error) {
+	keyID, problem, err := s.verifySignature(req, digest)
+	if err != nil {
+		return "", err
+	}
+	if problem == "" {
+		return keyID, nil
+	}
+
+	if s.signing.Policy == signing.PolicyEnforce {
+		logger.Warn("model signature rejected", "model_id", req.ID, "digest", digest, "reason", problem)
+		return "", &domain.SignatureError{ModelID: req.ID, Reason: problem}
+	}
+	logger.Warn("model accepted without a trusted signature", "model_id", req.ID, "digest", digest, "reason", problem)
+	return "", nil
+}
+
+// recheckSignature verifies the signature a stored model was registered
+// with against the trusted keys, which may have changed since. Models
+// registered without a verified signature are checked against the
+// signature of their package, if any. It returns the id of the key that
+// made it, or why the model has no trusted signature.
+func (s *ModelService) recheckSignature(digest string, sig *modelSignature, pkg *mnx.Manifest) (keyID, problem string) {
+	req := &RegisterModelRequest{}
+	switch {
+	case sig != nil:
+		req.Signature, req.SigningKeyID = sig.Value, sig.KeyID
+	case pkg != nil && pkg.Signature != nil:
+		req.Signature, req.SigningKeyID = pkg.Signature.Value, pkg.Signature.KeyID
+	}
+	keyID, problem, err := s.verifySignature(req, digest)
+	if err != nil {
+		return "", err.Error()
+	}
+	return keyID, problem
+}
+
+// untrusted returns why a stored model must not be served because of its
+// signature problem, or "" if the policy allows it.
+func (s *ModelService) untrusted(problem string) string {
+	if problem == "" || s.signing.Policy != signing.PolicyEnforce {
+		return ""
+	}
+	return "signature no longer trusted: " + problem
+}
+
+// SigningKey returns the id of the trusted key that signed a model's
+// artifact, or "".
+func (s *ModelService) SigningKey(id string) string {
+	s.storeMu.Lock()
+	defer s.storeMu.Unlock()
+
+	return s.signatures[id].KeyID
+}
@@ -0,0 +1,116 @@
+package service
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/kevo-1/model-nexus/internal/domain"
+	"github.com/kevo-1/model-nexus/internal/signing"
+)
+
+func TestCheckSignature(t *testing.T) {
+	const digest = "9f9ea1f085db4baf102ef8a473a5be2712cfc8051cadfc430bcd7774a2084abf"
+	pub, priv, _ := ed25519.GenerateKey(rand.Reader)
+	_, untrustedPriv, _ := ed25519.GenerateKey(rand.Reader)
+	keys, err := signing.ParseKeyring(strings.NewReader("release " + base64.StdEncoding.EncodeToString(pub)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sign := func(priv ed25519.PrivateKey) string {
+		return base64.StdEncoding.EncodeToString(ed25519.Sign(priv, signing.Message(digest)))
+	}
+
+	type outcome int
+	const (
+		accepted outcome = iota // accepted without a trusted key
+		trusted                 // accepted, signed by the trusted key
+		rejected                // SignatureError
+		invalid                 // ValidationError
+	)
+	signatures := []struct {
+		name string
+		req  RegisterModelRequest
+	}{
+		{"unsigned", RegisterModelRequest{}},
+		{"trusted", RegisterModelRequest{Signature: sign(priv)}},
+		{"trusted, named key", RegisterModelRequest{Signature: sign(priv), SigningKeyID: "release"}},
+		{"untrusted key", RegisterModelRequest{Signature: sign(untrustedPriv)}},
+		{"unknown key id", RegisterModelRequest{Signature: sign(priv), SigningKeyID: "other"}},
+		{"malformed", RegisterModelRequest{Signature: "not base64!"}},
+	}
+	tests := map[signing.Policy][]outcome{
+		signing.PolicyOff:     {accepted, accepted, accepted, accepted, accepted, accepted},
+		signing.PolicyWarn:    {accepted, trusted, trusted, accepted, accepted, invalid},
+		signing.PolicyEnforce: {rejected, trusted, trusted, rejected, rejected, invalid},
+	}
+
+	for policy, outcomes := range tests {
+		s := &ModelService{}
+		if err := s.SetSigning(SigningConfig{Policy: policy, Keys: keys}); err != nil {
+			t.Fatal(err)
+		}
+		for i, sig := range signatures {
+			t.Run(string(policy)+"/"+sig.name, func(t *testing.T) {
+				req := sig.req
+				req.ID = "iris"
+				keyID, err := s.checkSignature(&req, digest)
+
+				var got outcome
+				var sigErr *domain.SignatureError
+				var valErr *domain.ValidationError
+				switch {
+				case errors.As(err, &sigErr):
+					got = rejected
+				case errors.As(err, &valErr):
+					got = invalid
+				case err != nil:
+					t.Fatal(err)
+				case keyID == "release":
+					got = trusted
+				case keyID != "":
+					t.Fatalf("signed by unexpected key %q", keyID)
+				}
+				if got != outcomes[i] {
+					t.Fatalf("outcome %d (key %q, error %v), want %d", got, keyID, err, outcomes[i])
+				}
+			})
+		}
+	}
+}
+
+func TestStoredSignaturePolicy(t *testing.T) {
+	tests := []struct {
+		policy  signing.Policy
+		problem string
+		served  bool
+	}{
+		{signing.PolicyOff, "model is unsigned", true},
+		{signing.PolicyWarn, "model is unsigned", true},
+		{signing.PolicyEnforce, "model is unsigned", false},
+		{signing.PolicyEnforce, "", true},
+	}
+	keys, _ := signing.ParseKeyring(strings.NewReader("release " + base64.StdEncoding.EncodeToString(make([]byte, ed25519.PublicKeySize))))
+	for _, tt := range tests {
+		s := &ModelService{}
+		if err := s.SetSigning(SigningConfig{Policy: tt.policy, Keys: keys}); err != nil {
+			t.Fatal(err)
+		}
+		if reason := s.untrusted(tt.problem); (reason == "") != tt.served {
+			t.Errorf("%s, problem %q: untrusted = %q, served %v", tt.policy, tt.problem, reason, tt.served)
+		}
+	}
+}
+
+func TestSetSigningRequiresKeysToEnforce(t *testing.T) {
+	s := &ModelService{}
+	if err := s.SetSigning(SigningConfig{Policy: signing.PolicyEnforce}); err == nil {
+		t.Fatal("enforce accepted without trusted keys")
+	}
+	if err := s.SetSigning(SigningConfig{Policy: signing.PolicyWarn}); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,280 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/kevo-1/model-nexus/internal/domain"
+	"github.com/kevo-1/model-nexus/internal/logger"
+)
+
+// SourceConfig restricts where models may be registered from by URI.
+type SourceConfig struct {
+	// Roots are the directories file:// sources must lie in.
+	Roots []string
+	// Hosts are the host names http(s):// sources may be fetched from,
+	// including redirects; "*" allows any host.
+	Hosts []string
+	// Client fetches http(s):// sources; http.DefaultClient's transport
+	// is used when nil.
+	Client *http.Client
+}
+
+type sourceRoot struct {
+	path string
+	root *os.Root
+}
+
+type modelSources struct {
+	roots   []sourceRoot
+	hosts   map[string]bool
+	anyHost bool
+	client  *http.Client
+}
+
+// RegisterSourceRequest registers a model read from a server-side URI
+// instead of an upload. SHA256 is required for http(s):// sources.
+type RegisterSourceRequest struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	Source  string `json:"source"`
+	SHA256  string `json:"sha256,omitempty"`
+
+	Signature    string `json:"signature,omitempty"`
+	SigningKeyID string `json:"signing_key_id,omitempty"`
+}
+
+// SetSources allows registering models from the given roots and hosts.
+// Without it every source is rejected. It must be called before the
+// service is used.
+func (s *ModelService) SetSources(cfg SourceConfig) error {
+	src := &modelSources{hosts: make(map[string]bool)}
+
+	for _, dir := range cfg.Roots {
+		if !filepath.IsAbs(dir) {
+			return fmt.Errorf("source root %q must be an absolute path", dir)
+		}
+		root, err := os.OpenRoot(dir)
+		if err != nil {
+			return fmt.Errorf("failed to open source root: %w", err)
+		}
+		src.roots = append(src.roots, sourceRoot{path: filepath.Clean(dir), root: root})
+	}
+
+	for _, host := range cfg.Hosts {
+		if host == "*" {
+			src.anyHost = true
+			continue
+		}
+		src.hosts[strings.ToLower(host)] = true
+	}
+
+	client := http.Client{}
+	if cfg.Client != nil {
+		client = *cfg.Client
+	}
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if len(via) >= 10 {
+			return errors.New("stopped after 10 redirects")
+		}
+		if !src.allowedURL(req.URL) {
+			return fmt.Errorf("redirect to %s is not allowed", req.URL.Redacted())
+		}
+		return nil
+	}
+	src.client = &client
+
+	s.sources = src
+	return nil
+}
+
+func (m *modelSources) allowedURL(u *url.URL) bool {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return false
+	}
+	return m.anyHost || m.hosts[strings.ToLower(u.Hostname())]
+}
+
+// RegisterFromSource reads a model from a file:// path under an allowed
+// root or an http(s):// URL on an allowed host and registers it exactly
+// like an upload through RegisterModel.
+func (s *ModelService) RegisterFromSource(ctx context.Context, req RegisterSourceRequest) (*RegisterModelResponse, error) {
+	if req.ID == "" || req.Name == "" || req.Version == "" || req.Source == "" {
+		return nil, &domain.ValidationError{
+			Field:   "id/name/version/source",
+			Message: "id, name, version and source are required",
+		}
+	}
+	if err := domain.ValidateModelID("id", req.ID); err != nil {
+		return nil, err
+	}
+	// Fail before reading anything; RegisterModel claims the id
+	if _, err := s.registry.Lookup(req.ID); err == nil {
+		return nil, &domain.ModelAlreadyExistsError{ModelID: req.ID}
+	}
+
+	u, err := url.Parse(req.Source)
+	if err != nil {
+		return nil, &domain.ValidationError{Field: "source", Message: "source must be a file://, http:// or https:// URI"}
+	}
+
+	src := s.sources
+	if src == nil {
+		src = &modelSources{}
+	}
+
+	var (
+		file     io.ReadCloser
+		filename string
+	)
+	switch u.Scheme {
+	case "file":
+		file, filename, err = src.openFile(u)
+	case "http", "https":
+		if strings.TrimSpace(req.SHA256) == "" {
+			return nil, &domain.ValidationError{Field: "sha256", Message: "sha256 is required for http(s) sources"}
+		}
+		file, filename, err = src.fetch(ctx, u)
+	default:
+		err = &domain.ValidationError{Field: "source", Message: "source must be a file://, http:// or https:// URI"}
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	logger.Info("registering model from source",
+		"model_id", req.ID,
+		"source", u.Redacted(),
+	)
+
+	res, err := s.RegisterModel(ctx, RegisterModelRequest{
+		ID:           req.ID,
+		Name:         req.Name,
+		Version:      req.Version,
+		Filename:     filename,
+		File:         file,
+		SHA256:       req.SHA256,
+		Signature:    req.Signature,
+		SigningKeyID: req.SigningKeyID,
+	})
+
+	// Report a source that failed mid-read as such, not as a staging error
+	var fetchErr *domain.SourceFetchError
+	if errors.As(err, &fetchErr) {
+		return nil, fetchErr
+	}
+	return res, err
+}
+
+// openFile opens a file:// source through the root it lies in, so
+// symlinks cannot lead outside the root.
+func (m *modelSources) openFile(u *url.URL) (io.ReadCloser, string, error) {
+	if u.Opaque != "" || (u.Host != "" && u.Host != "localhost") {
+		return nil, "", &domain.ValidationError{Field: "source", Message: "file sources must be absolute local paths (file:///path)"}
+	}
+	p := filepath.Clean(filepath.FromSlash(u.Path))
+	if !filepath.IsAbs(p) {
+		return nil, "", &domain.ValidationError{Field: "source", Message: "file sources must be absolute local paths (file:///path)"}
+	}
+
+	for _, r := range m.roots {
+		rel, err := filepath.Rel(r.path, p)
+		if err != nil || !filepath.IsLocal(rel) {
+			continue
+		}
+
+		f, err := r.root.Open(rel)
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, "", &domain.ValidationError{Field: "source", Message: fmt.Sprintf("%s does not exist", p)}
+		}
+		if err != nil {
+			return nil, "", &domain.ValidationError{Field: "source", Message: fmt.Sprintf("cannot open %s: %v", p, err)}
+		}
+		stat, err := f.Stat()
+		if err != nil || !stat.Mode().IsRegular() {
+			f.Close()
+			return nil, "", &domain.ValidationError{Field: "source", Message: fmt.Sprintf("%s is not a regular file", p)}
+		}
+		if stat.Size() > maxArtifactSize {
+			f.Close()
+			return nil, "", &domain.ValidationError{Field: "source", Message: fmt.Sprintf("%s is larger than %d bytes", p, int64(maxArtifactSize))}
+		}
+		return f, filepath.Base(p), nil
+	}
+
+	return nil, "", &domain.ValidationError{Field: "source", Message: fmt.Sprintf("%s is not under an allowed source root", p)}
+}
+
+// fetch starts downloading an http(s):// source. Reading the body fails
+// with a SourceFetchError if the transfer breaks or exceeds the size
+// limit.
+func (m *modelSources) fetch(ctx context.Context, u *url.URL) (io.ReadCloser, string, error) {
+	if !m.allowedURL(u) {
+		return nil, "", &domain.ValidationError{Field: "source", Message: fmt.Sprintf("host %q is not an allowed source", u.Hostname())}
+	}
+	source := u.Redacted()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, "", &domain.ValidationError{Field: "source", Message: err.Error()}
+	}
+	resp, err := m.client.Do(req)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err // the URL may hold credentials
+		}
+		return nil, "", &domain.SourceFetchError{Source: source, Reason: err.Error()}
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, "", &domain.SourceFetchError{Source: source, Reason: resp.Status}
+	}
+	if resp.ContentLength > maxArtifactSize {
+		resp.Body.Close()
+		return nil, "", &domain.ValidationError{Field: "source", Message: fmt.Sprintf("source is larger than %d bytes", int64(maxArtifactSize))}
+	}
+
+	// Name the file after the final URL so bundles are recognised by
+	// their extension
+	filename := path.Base(resp.Request.URL.Path)
+	return &sourceBody{body: resp.Body, source: source, left: maxArtifactSize}, filename, nil
+}
+
+// sourceBody turns read errors of a fetched source into SourceFetchErrors
+// and enforces the size limit.
+type sourceBody struct {
+	body   io.ReadCloser
+	source string
+	left   int64
+}
+
+func (b *sourceBody) Read(p []byte) (int, error) {
+	if b.left <= 0 {
+		return 0, &domain.SourceFetchError{Source: b.source, Reason: fmt.Sprintf("source is larger than %d bytes", int64(maxArtifactSize))}
+	}
+	if int64(len(p)) > b.left {
+		p = p[:b.left]
+	}
+	n, err := b.body.Read(p)
+	b.left -= int64(n)
+	if err != nil && !errors.Is(err, io.EOF) {
+		err = &domain.SourceFetchError{Source: b.source, Reason: err.Error()}
+	}
+	return n, err
+}
+
+func (b *sourceBody) Close() error {
+	return b.body.Close()
+}
@@ -0,0 +1,120 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/kevo-1/model-nexus/internal/domain"
+	"github.com/kevo-1/model-nexus/internal/logger"
+	"github.com/kevo-1/model-nexus/internal/metrics"
+)
+
+// sweepBatchSize bounds how many rows are handed to a BatchPredictor at
+// once, so a large grid does not hold the model lock for too long.
+const sweepBatchSize = 256
+
+// Sweep evaluates the model over a one- or two-dimensional grid around a
+// base feature vector and returns the response curve or surface.
+func (s *PredictionService) Sweep(ctx context.Context, req domain.SweepRequest) (domain.SweepResponse, error) {
+	if err := req.Validate(); err != nil {
+		return domain.SweepResponse{}, err
+	}
+
+	if req.RequestID == "" {
+		req.RequestID = logger.GetRequestID(ctx)
+	}
+
+	model, err := s.registry.Get(req.ModelID)
+	if err != nil {
+		return domain.SweepResponse{}, err
+	}
+
+	axes := make([]domain.SweepAxis, len(req.Vary))
+	for i, axis := range req.Vary {
+		axes[i] = domain.SweepAxis{Index: axis.Index, Values: axis.Grid()}
+	}
+
+	// Build every grid point; the first axis varies slowest.
+	var coords [][]float64
+	var rows [][]float64
+	var walk func(axis int, coord []float64, row []float64)
+	walk = func(axis int, coord []float64, row []float64) {
+		if axis == len(axes) {
+			coords = append(coords, append([]float64{}, coord...))
+			rows = append(rows, append([]float64{}, row...))
+			return
+		}
+		for _, v := range axes[axis].Values {
+			row[axes[axis].Index] = v
+			walk(axis+1, append(coord, v), row)
+		}
+	}
+	walk(0, nil, append([]float64{}, req.Features...))
+
+	logger.Info("sweep started",
+		"request_id", req.RequestID,
+		"model_id", req.ModelID,
+		"points", len(rows),
+	)
+	start := time.Now()
+
+	predictions, err := predictRows(ctx, model, rows)
+	duration := time.Since(start).Seconds()
+	metrics.RecordPrediction(req.ModelID, err == nil, duration)
+
+	if err != nil {
+		logger.Error("sweep failed",
+			"request_id", req.RequestID,
+			"model_id", req.ModelID,
+			"error", err,
+		)
+		return domain.SweepResponse{}, err
+	}
+
+	points := make([]domain.SweepPoint, len(rows))
+	for i := range rows {
+		points[i] = domain.SweepPoint{Values: coords[i], Prediction: predictions[i]}
+	}
+
+	logger.Info("sweep completed",
+		"request_id", req.RequestID,
+		"model_id", req.ModelID,
+		"latency_ms", duration*1000,
+	)
+
+	return domain.SweepResponse{
+		ModelID:   req.ModelID,
+		RequestID: req.RequestID,
+		Axes:      axes,
+		Points:    points,
+		LatencyMs: duration * 1000,
+		Timestamp: time.Now(),
+	}, nil
+}
+
+// predictRows scores rows in batches when the model supports it, falling
+// back to one Predict call per row.
+func predictRows(ctx context.Context, model domain.ModelPredictor, rows [][]float64) ([][]float64, error) {
+	results := make([][]float64, 0, len(rows))
+
+	if bp, ok := model.(domain.BatchPredictor); ok {
+		for start := 0; start < len(rows); start += sweepBatchSize {
+			end := min(start+sweepBatchSize, len(rows))
+			batch, err := bp.PredictBatch(ctx, rows[start:end])
+			if err != nil {
+				return nil, err
+			}
+			results = append(results, batch...)
+		}
+		return results, nil
+	}
+
+	for _, row := range rows {
+		prediction, err := model.Predict(ctx, row)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, prediction)
+	}
+	return results, nil
+}
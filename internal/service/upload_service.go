@@ -0,0 +1,345 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/kevo-1/model-nexus/internal/domain"
+	"github.com/kevo-1/model-nexus/internal/logger"
+	"github.com/kevo-1/model-nexus/internal/mnx"
+	"github.com/kevo-1/model-nexus/pkg/onnx"
+)
+
+const (
+	maxArtifactSize  = 64 << 30 // 64 GB, the unpacked bundle limit
+	maxOpenUploads   = 16
+	uploadSessionTTL = 24 * time.Hour
+)
+
+// CreateUploadRequest starts a resumable upload of a model file of Size
+// bytes. SHA256 may be given here or when the upload is finalized.
+type CreateUploadRequest struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Version  string `json:"version"`
+	Filename string `json:"filename"`
+	Size     int64  `json:"size"`
+	SHA256   string `json:"sha256,omitempty"`
+
+	Signature    string `json:"signature,omitempty"`
+	SigningKeyID string `json:"signing_key_id,omitempty"`
+}
+
+// UploadStatus reports the progress of a resumable upload. The next chunk
+// must start at Offset.
+type UploadStatus struct {
+	UploadID  string    `json:"upload_id"`
+	ModelID   string    `json:"model_id"`
+	Filename  string    `json:"filename"`
+	Size      int64     `json:"size"`
+	Offset    int64     `json:"offset"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type uploadSession struct {
+	req      CreateUploadRequest
+	dir      string
+	data     string
+	received int64
+	touched  time.Time
+	busy     bool // a chunk or finalize request holds the session
+}
+
+func (u *uploadSession) status(id string) UploadStatus {
+	return UploadStatus{
+		UploadID:  id,
+		ModelID:   u.req.ID,
+		Filename:  u.req.Filename,
+		Size:      u.req.Size,
+		Offset:    u.received,
+		ExpiresAt: u.touched.Add(uploadSessionTTL),
+	}
+}
+
+// UploadService assembles large model files from chunks sent in separate
+// requests, so a dropped connection only costs the chunk in flight. The
+// file is kept in a staging directory, where ModelService stages uploads,
+// until it is finalized through ModelService.RegisterModel. Sessions live in memory:
+// they expire after a day without chunks and do not survive a restart.
+type UploadService struct {
+	models *ModelService
+
+	mu       sync.Mutex
+	sessions map[string]*uploadSession
+}
+
+func NewUploadService(models *ModelService) *UploadService {
+	return &UploadService{
+		models:   models,
+		sessions: make(map[string]*uploadSession),
+	}
+}
+
+// Create validates the model fields and opens an upload session. Name and
+// version may be left to the manifest of a .mnx package.
+func (s *UploadService) Create(req CreateUploadRequest) (*UploadStatus, error) {
+	if req.ID == "" || req.Filename == "" || (!mnx.IsPackage(req.Filename) && (req.Name == "" || req.Version == "")) {
+		return nil, &domain.ValidationError{
+			Field:   "id/name/version/filename",
+			Message: "id, name, version and filename are required",
+		}
+	}
+	if err := domain.ValidateModelID("id", req.ID); err != nil {
+		return nil, err
+	}
+	if req.Size <= 0 || req.Size > maxArtifactSize {
+		return nil, &domain.ValidationError{
+			Field:   "size",
+			Message: fmt.Sprintf("size must be between 1 and %d bytes", int64(maxArtifactSize)),
+		}
+	}
+	if req.SHA256 != "" {
+		r := RegisterModelRequest{SHA256: req.SHA256}
+		if err := normalizeDigest(&r); err != nil {
+			return nil, err
+		}
+		req.SHA256 = r.SHA256
+	}
+	// Fail early; the id is only claimed when the upload is finalized
+	if _, err := s.models.registry.Lookup(req.ID); err == nil {
+		return nil, &domain.ModelAlreadyExistsError{ModelID: req.ID}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.expire()
+	if len(s.sessions) >= maxOpenUploads {
+		return nil, &domain.TooManyUploadsError{Limit: maxOpenUploads}
+	}
+
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return nil, err
+	}
+	id := hex.EncodeToString(b[:])
+
+	// The staging directory matches the pattern removed at startup
+	dir, err := os.MkdirTemp(s.models.staging, ".upload-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create upload directory: %w", err)
+	}
+	data := filepath.Join(dir, "data")
+	f, err := os.OpenFile(data, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, fmt.Errorf("failed to create upload file: %w", err)
+	}
+	f.Close()
+
+	u := &uploadSession{req: req, dir: dir, data: data, touched: time.Now()}
+	s.sessions[id] = u
+
+	logger.Info("resumable upload created", "upload_id", id, "model_id", req.ID, "size", req.Size)
+	status := u.status(id)
+	return &status, nil
+}
+
+// Status reports how much of an upload has been received.
+func (s *UploadService) Status(id string) (*UploadStatus, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.expire()
+	u, ok := s.sessions[id]
+	if !ok {
+		return nil, &domain.UploadNotFoundError{UploadID: id}
+	}
+	status := u.status(id)
+	return &status, nil
+}
+
+// WriteChunk appends r to the upload. offset must equal the number of
+// bytes received so far. Bytes that arrive before r fails are kept, so
+// after any error the client asks for the status and resumes from there.
+func (s *UploadService) WriteChunk(id string, offset int64, r io.Reader) (*UploadStatus, error) {
+	u, err := s.acquire(id)
+	if err != nil {
+		return nil, err
+	}
+	defer s.releaseSession(u)
+
+	if offset != u.received {
+		return nil, &domain.UploadConflictError{
+			UploadID: id,
+			Offset:   u.received,
+			Reason:   fmt.Sprintf("chunk starts at offset %d, expected %d", offset, u.received),
+		}
+	}
+
+	f, err := os.OpenFile(u.data, os.O_WRONLY, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open upload file: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Seek(u.received, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	// Read one byte past the declared size to detect oversized chunks
+	remaining := u.req.Size - u.received
+	n, copyErr := io.Copy(f, io.LimitReader(r, remaining+1))
+	if n > remaining {
+		if err := f.Truncate(u.received); err != nil {
+			return nil, err
+		}
+		return nil, &domain.ValidationError{
+			Field:   "offset",
+			Message: fmt.Sprintf("chunk ends past the declared size of %d bytes", u.req.Size),
+		}
+	}
+
+	s.mu.Lock()
+	u.received += n
+	u.touched = time.Now()
+	status := u.status(id)
+	s.mu.Unlock()
+
+	if copyErr != nil {
+		return nil, fmt.Errorf("chunk interrupted at offset %d: %w", status.Offset, copyErr)
+	}
+	return &status, nil
+}
+
+// Finalize registers the assembled file through ModelService.RegisterModel,
+// checking it against sha256 or the digest given at creation; one of them
+// is required. The upload is discarded once the model is registered or
+// rejected; it is kept when the id is taken or registration failed for
+// another reason, so finalize can be retried.
+func (s *UploadService) Finalize(ctx context.Context, id, sha256 string) (*RegisterModelResponse, error) {
+	u, err := s.acquire(id)
+	if err != nil {
+		return nil, err
+	}
+	defer s.releaseSession(u)
+
+	if u.received != u.req.Size {
+		return nil, &domain.UploadConflictError{
+			UploadID: id,
+			Offset:   u.received,
+			Reason:   fmt.Sprintf("upload is incomplete: received %d of %d bytes", u.received, u.req.Size),
+		}
+	}
+
+	want := u.req.SHA256
+	if sha256 != "" {
+		r := RegisterModelRequest{SHA256: sha256}
+		if err := normalizeDigest(&r); err != nil {
+			return nil, err
+		}
+		if want != "" && r.SHA256 != want {
+			return nil, &domain.ValidationError{
+				Field:   "sha256",
+				Message: fmt.Sprintf("digest %s does not match %s given when the upload was created", r.SHA256, want),
+			}
+		}
+		want = r.SHA256
+	}
+	if want == "" {
+		return nil, &domain.ValidationError{Field: "sha256", Message: "sha256 is required to finalize an upload"}
+	}
+
+	f, err := os.Open(u.data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open upload file: %w", err)
+	}
+	defer f.Close()
+
+	res, err := s.models.RegisterModel(ctx, RegisterModelRequest{
+		ID:           u.req.ID,
+		Name:         u.req.Name,
+		Version:      u.req.Version,
+		Filename:     u.req.Filename,
+		File:         f,
+		SHA256:       want,
+		Signature:    u.req.Signature,
+		SigningKeyID: u.req.SigningKeyID,
+	})
+
+	switch err.(type) {
+	case nil, *domain.ValidationError, *onnx.ParseError, *domain.UnsupportedFormatError, *domain.IncompatibleModelError, *domain.SignatureError:
+		s.remove(id, u)
+		logger.Info("resumable upload finalized", "upload_id", id, "model_id", u.req.ID, "registered", err == nil)
+	}
+	return res, err
+}
+
+// Abort discards an upload and its data.
+func (s *UploadService) Abort(id string) error {
+	u, err := s.acquire(id)
+	if err != nil {
+		return err
+	}
+	defer s.releaseSession(u)
+
+	s.remove(id, u)
+	logger.Info("resumable upload aborted", "upload_id", id, "model_id", u.req.ID)
+	return nil
+}
+
+// acquire claims a session for one request at a time.
+func (s *UploadService) acquire(id string) (*uploadSession, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.expire()
+	u, ok := s.sessions[id]
+	if !ok {
+		return nil, &domain.UploadNotFoundError{UploadID: id}
+	}
+	if u.busy {
+		return nil, &domain.UploadConflictError{UploadID: id, Offset: u.received, Reason: "another request is writing to this upload"}
+	}
+	u.busy = true
+	return u, nil
+}
+
+func (s *UploadService) releaseSession(u *uploadSession) {
+	s.mu.Lock()
+	u.busy = false
+	u.touched = time.Now()
+	s.mu.Unlock()
+}
+
+// remove drops a session and its staging directory.
+func (s *UploadService) remove(id string, u *uploadSession) {
+	s.mu.Lock()
+	delete(s.sessions, id)
+	s.mu.Unlock()
+
+	if err := os.RemoveAll(u.dir); err != nil {
+		logger.Warn("failed to cleanup upload directory", "upload_id", id, "error", err)
+	}
+}
+
+// expire drops idle sessions past their TTL. s.mu must be held.
+func (s *UploadService) expire() {
+	now := time.Now()
+	for id, u := range s.sessions {
+		if u.busy || now.Sub(u.touched) < uploadSessionTTL {
+			continue
+		}
+		delete(s.sessions, id)
+		if err := os.RemoveAll(u.dir); err != nil {
+			logger.Warn("failed to cleanup upload directory", "upload_id", id, "error", err)
+		}
+		logger.Info("resumable upload expired", "upload_id", id, "model_id", u.req.ID, "received", u.received)
+	}
+}
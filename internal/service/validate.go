@@ -0,0 +1,314 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/kevo-1/model-nexus/internal/backend"
+	"github.com/kevo-1/model-nexus/internal/domain"
+	"github.com/kevo-1/model-nexus/internal/logger"
+	"github.com/kevo-1/model-nexus/internal/signing"
+)
+
+const (
+	syntheticInferenceTimeout = 10 * time.Second
+	maxSyntheticElements      = 1 << 24
+)
+
+type CheckStatus string
+
+const (
+	CheckPassed  CheckStatus = "passed"
+	CheckFailed  CheckStatus = "failed"
+	CheckSkipped CheckStatus = "skipped"
+)
+
+type ValidationCheck struct {
+	Name       string      `json:"name"`
+	Status     CheckStatus `json:"status"`
+	Message    string      `json:"message,omitempty"`
+	Details    []string    `json:"details,omitempty"`
+	DurationMs float64     `json:"duration_ms"`
+}
+
+// ValidationReport is the outcome of a dry-run upload. Deployable is true
+// only when every check passed.
+type ValidationReport struct {
+	Deployable bool              `json:"deployable"`
+	Format     string            `json:"format,omitempty"`
+	Digest     string            `json:"digest,omitempty"`
+	Info       *domain.ModelInfo `json:"info,omitempty"`
+	Checks     []ValidationCheck `json:"checks"`
+}
+
+func (r *ValidationReport) record(name string, start time.Time, status CheckStatus, message string, details []string) {
+	r.Checks = append(r.Checks, ValidationCheck{
+		Name:       name,
+		Status:     status,
+		Message:    message,
+		Details:    details,
+		DurationMs: time.Since(start).Seconds() * 1000,
+	})
+}
+
+func (r *ValidationReport) failed() bool {
+	for _, c := range r.Checks {
+		if c.Status == CheckFailed {
+			return true
+		}
+	}
+	return false
+}
+
+// skip records the remaining checks as skipped after a blocking failure.
+func (r *ValidationReport) skip(names ...string) {
+	for _, name := range names {
+		r.Checks = append(r.Checks, ValidationCheck{Name: name, Status: CheckSkipped})
+	}
+}
+
+// ValidateModel runs the upload pipeline against a temporary copy of the
+// artifact — format detection, encryption, signature, metadata extraction,
+// operator, dtype and lint checks, session creation and one synthetic
+// inference — without touching the registry or the models directory.
+// Problems with the model are reported as failed checks; only an invalid id
+// or I/O failures return an error.
+func (s *ModelService) ValidateModel(ctx context.Context, req RegisterModelRequest) (*ValidationReport, error) {
+	if req.ID == "" {
+		req.ID = "validate"
+	}
+	if err := domain.ValidateModelID("id", req.ID); err != nil {
+		return nil, err
+	}
+	if err := normalizeDigest(&req); err != nil {
+		return nil, err
+	}
+
+	report := &ValidationReport{}
+
+	// 1. Format detection and staging
+	start := time.Now()
+	file := bufio.NewReaderSize(req.File, backend.HeaderSize)
+	header, err := file.Peek(backend.HeaderSize)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to read model file: %w", err)
+	}
+
+	a, pkg, err := s.stage("", req.Filename, header, file)
+	if err != nil {
+		var verr *domain.ValidationError
+		var ferr *domain.UnsupportedFormatError
+		if !errors.As(err, &verr) && !errors.As(err, &ferr) {
+			return nil, err
+		}
+		report.record("format", start, CheckFailed, err.Error(), nil)
+		if s.encryption != nil {
+			report.skip("encryption")
+		}
+		report.skip("digest")
+		if s.signaturesChecked() {
+			report.skip("signature")
+		}
+		report.skip("metadata", "operators", "dtypes", "lint", "session", "inference")
+		return report, nil
+	}
+	defer os.RemoveAll(a.work)
+
+	// Packages add a check of their golden outputs
+	skip := func(names ...string) {
+		if pkg != nil {
+			names = append(names, "examples")
+		}
+		report.skip(names...)
+	}
+
+	b := a.backend
+	report.Format = b.Format()
+	report.Digest = a.digest
+	report.record("format", start, CheckPassed, fmt.Sprintf("handled by the %s backend", b.Format()), nil)
+
+	// Whether the artifact can be stored encrypted, when encryption is on;
+	// the remaining checks still run
+	if s.encryption != nil {
+		start = time.Now()
+		if err := s.checkEncryptable(a); err != nil {
+			report.record("encryption", start, CheckFailed, err.Error(), nil)
+		} else {
+			report.record("encryption", start, CheckPassed, "stored encrypted with key "+s.encryption.Current().ID(), nil)
+		}
+	}
+
+	// 2. Upload digest against the one the client expects
+	start = time.Now()
+	err = a.checkDigest(req.SHA256)
+	if err == nil && pkg != nil {
+		err = pkg.checkArtifact(a, &req)
+	}
+	if err != nil {
+		report.record("digest", start, CheckFailed, err.Error(), nil)
+		if s.signaturesChecked() {
+			report.skip("signature")
+		}
+		skip("metadata", "operators", "dtypes", "lint", "session", "inference")
+		return report, nil
+	}
+	report.record("digest", start, CheckPassed, "sha256 "+a.upload, nil)
+
+	// 3. Signature over the artifact digest, when signatures are checked;
+	// under the warn policy problems are reported but do not fail the check
+	if s.signaturesChecked() {
+		start = time.Now()
+		keyID, problem, err := s.verifySignature(&req, a.digest)
+		switch {
+		case err != nil:
+			report.record("signature", start, CheckFailed, err.Error(), nil)
+		case problem == "":
+			report.record("signature", start, CheckPassed, "signed by "+keyID, nil)
+		case s.signing.Policy == signing.PolicyEnforce:
+			report.record("signature", start, CheckFailed, problem, nil)
+		default:
+			report.record("signature", start, CheckPassed, problem+"; accepted under the warn policy", nil)
+		}
+	}
+
+	if req.Name == "" {
+		req.Name = req.Filename
+	}
+	if req.Version == "" {
+		req.Version = "dry-run"
+	}
+
+	// 4. Metadata extraction
+	start = time.Now()
+	info, err := b.ExtractInfo(a.model)
+	if err == nil && pkg != nil {
+		err = pkg.apply(info)
+	}
+	if err != nil {
+		report.record("metadata", start, CheckFailed, err.Error(), nil)
+		skip("operators", "dtypes", "lint", "session", "inference")
+		return report, nil
+	}
+	report.Info = info
+	report.record("metadata", start, CheckPassed,
+		fmt.Sprintf("%d inputs, %d outputs", len(info.Inputs), len(info.Outputs)), nil)
+
+	// 5. Operators and opsets against the runtime
+	start = time.Now()
+	if len(info.Incompatible) > 0 {
+		report.record("operators", start, CheckFailed, "runtime cannot load every operator", info.Incompatible)
+	} else {
+		report.record("operators", start, CheckPassed, "", nil)
+	}
+
+	// 6. Element types the predictor can bind
+	start = time.Now()
+	if len(info.Unbindable) > 0 {
+		report.record("dtypes", start, CheckFailed, "some inputs or outputs cannot be bound", info.Unbindable)
+	} else {
+		report.record("dtypes", start, CheckPassed, "", nil)
+	}
+
+	// 7. Structural lint; warnings are reported but do not fail the check
+	start = time.Now()
+	var lintErrors, lintWarnings []string
+	for _, f := range info.Lint {
+		if f.Severity == domain.LintError {
+			lintErrors = append(lintErrors, f.Message)
+		} else {
+			lintWarnings = append(lintWarnings, f.Message)
+		}
+	}
+	if len(lintErrors) > 0 {
+		report.record("lint", start, CheckFailed, fmt.Sprintf("%d errors", len(lintErrors)), append(lintErrors, lintWarnings...))
+	} else {
+		report.record("lint", start, CheckPassed, fmt.Sprintf("%d warnings", len(lintWarnings)), lintWarnings)
+	}
+
+	if report.failed() {
+		skip("session", "inference")
+		return report, nil
+	}
+
+	// 8. Session creation from the staged copy; the predictor reads its
+	// sidecar from next to the model file
+	start = time.Now()
+	if err := saveModelInfoJSON(info, sidecarPath(a.model, b.Extension())); err != nil {
+		return nil, fmt.Errorf("failed to stage model info sidecar: %w", err)
+	}
+
+	predictor, _, err := s.loadPredictor(b, req, a.model)
+	if err != nil {
+		report.record("session", start, CheckFailed, err.Error(), nil)
+		skip("inference")
+		return report, nil
+	}
+	defer predictor.Close()
+	report.record("session", start, CheckPassed, "", nil)
+
+	// 9. One inference on zero-filled inputs
+	start = time.Now()
+	inferCtx, cancel := context.WithTimeout(ctx, syntheticInferenceTimeout)
+	defer cancel()
+
+	out, err := syntheticInference(inferCtx, predictor, info)
+	if err != nil {
+		report.record("inference", start, CheckFailed, err.Error(), nil)
+	} else {
+		report.record("inference", start, CheckPassed, fmt.Sprintf("returned %d values", len(out)), nil)
+	}
+
+	// 10. Packaged examples against their golden outputs
+	if pkg != nil {
+		start = time.Now()
+		if err := pkg.checkExamples(ctx, predictor); err != nil {
+			report.record("examples", start, CheckFailed, err.Error(), nil)
+		} else {
+			report.record("examples", start, CheckPassed, fmt.Sprintf("%d examples", len(pkg.Examples)), nil)
+		}
+	}
+
+	report.Deployable = !report.failed()
+
+	logger.Info("model validated",
+		"filename", req.Filename,
+		"format", b.Format(),
+		"deployable", report.Deployable,
+	)
+
+	return report, nil
+}
+
+// syntheticInference runs the model once on zeros. Single-input models with
+// a fixed feature count use the flat Predict path that /predict uses; the
+// rest go through named tensors with dynamic axes set to 1.
+func syntheticInference(ctx context.Context, predictor domain.ModelPredictor, info *domain.ModelInfo) ([]float64, error) {
+	if len(info.Inputs) == 1 && info.InputSize() > 0 {
+		return predictor.Predict(ctx, make([]float64, info.InputSize()))
+	}
+
+	tp, ok := predictor.(domain.TensorPredictor)
+	if !ok {
+		return nil, fmt.Errorf("model needs named inputs, which the %s backend does not support", info.Format)
+	}
+
+	inputs := make([]domain.NamedTensor, len(info.Inputs))
+	for i, in := range info.Inputs {
+		shape := make([]int64, len(in.Shape))
+		size := int64(1)
+		for j, d := range in.Shape {
+			shape[j] = max(d, 1)
+			size *= shape[j]
+			if size > maxSyntheticElements {
+				return nil, fmt.Errorf("input %s is too large for a synthetic inference", in.Name)
+			}
+		}
+		inputs[i] = domain.NamedTensor{Name: in.Name, Shape: shape, Data: make([]float64, size)}
+	}
+	return tp.PredictTensors(ctx, inputs)
+}
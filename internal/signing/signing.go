@@ -0,0 +1,180 @@
+// Package signing verifies detached ed25519 signatures over model artifact
+// digests against a set of trusted public keys.
+package signing
+
+import (
+	"bufio"
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/kevo-1/model-nexus/internal/domain"
+)
+
+// Policy decides what happens to models without a trusted signature.
+type Policy string
+
+const (
+	// PolicyOff ignores signatures.
+	PolicyOff Policy = "off"
+	// PolicyWarn verifies signatures but accepts unsigned or untrusted
+	// models with a warning.
+	PolicyWarn Policy = "warn"
+	// PolicyEnforce rejects models without a valid signature from a
+	// trusted key.
+	PolicyEnforce Policy = "enforce"
+)
+
+// ParsePolicy parses a policy name; "" means PolicyOff.
+func ParsePolicy(s string) (Policy, error) {
+	switch p := Policy(strings.ToLower(strings.TrimSpace(s))); p {
+	case "":
+		return PolicyOff, nil
+	case PolicyOff, PolicyWarn, PolicyEnforce:
+		return p, nil
+	}
+	return "", fmt.Errorf("unknown signature policy %q (expected off, warn or enforce)", s)
+}
+
+// Message returns the bytes a signature covers: "sha256:" followed by the
+// hex digest the artifact is stored under.
+func Message(digest string) []byte {
+	return []byte("sha256:" + digest)
+}
+
+// Keyring holds the trusted public keys by key id.
+type Keyring struct {
+	keys map[string]ed25519.PublicKey
+}
+
+// LoadKeyring reads a keyring file; see ParseKeyring.
+func LoadKeyring(path string) (*Keyring, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return ParseKeyring(f)
+}
+
+// ParseKeyring reads one trusted key per line as "<key-id> <public-key>",
+// where the key is base64 of either the raw 32-byte ed25519 key or its
+// DER-encoded SubjectPublicKeyInfo (the body of a PEM "PUBLIC KEY").
+// Blank lines and lines starting with # are ignored.
+func ParseKeyring(r io.Reader) (*Keyring, error) {
+	k := &Keyring{keys: make(map[string]ed25519.PublicKey)}
+
+	sc := bufio.NewScanner(r)
+	for line := 1; sc.Scan(); line++ {
+		text := strings.TrimSpace(sc.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Fields(text)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("line %d: expected \"<key-id> <public-key>\"", line)
+		}
+		id := fields[0]
+		if err := domain.ValidateModelID("key_id", id); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if _, ok := k.keys[id]; ok {
+			return nil, fmt.Errorf("line %d: duplicate key id %q", line, id)
+		}
+		key, err := parsePublicKey(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: key %s: %w", line, id, err)
+		}
+		k.keys[id] = key
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return k, nil
+}
+
+func parsePublicKey(s string) (ed25519.PublicKey, error) {
+	der, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, errors.New("not base64 encoded")
+	}
+	if len(der) == ed25519.PublicKeySize {
+		return ed25519.PublicKey(der), nil
+	}
+	pub, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("not a raw or PKIX ed25519 public key: %w", err)
+	}
+	key, ok := pub.(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("%T is not an ed25519 public key", pub)
+	}
+	return key, nil
+}
+
+// Len returns the number of trusted keys.
+func (k *Keyring) Len() int {
+	if k == nil {
+		return 0
+	}
+	return len(k.keys)
+}
+
+// IDs returns the trusted key ids in order.
+func (k *Keyring) IDs() []string {
+	if k == nil {
+		return nil
+	}
+	ids := make([]string, 0, len(k.keys))
+	for id := range k.keys {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// DecodeSignature decodes a base64 signature.
+func DecodeSignature(s string) ([]byte, error) {
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+	if err != nil {
+		return nil, errors.New("signature must be base64 encoded")
+	}
+	if len(sig) != ed25519.SignatureSize {
+		return nil, fmt.Errorf("signature must be %d bytes, got %d", ed25519.SignatureSize, len(sig))
+	}
+	return sig, nil
+}
+
+// Verify checks sig over the artifact digest with the key keyID, or with
+// every trusted key when keyID is empty, and returns the id of the key
+// that made it.
+func (k *Keyring) Verify(digest, keyID string, sig []byte) (string, error) {
+	if k.Len() == 0 {
+		return "", errors.New("no trusted keys are configured")
+	}
+	msg := Message(digest)
+	if keyID != "" {
+		key, ok := k.keys[keyID]
+		if !ok {
+			return "", fmt.Errorf("key %q is not trusted", keyID)
+		}
+		if !ed25519.Verify(key, msg, sig) {
+			return "", fmt.Errorf("signature does not match digest %s for key %q", digest, keyID)
+		}
+		return keyID, nil
+	}
+
+	for _, id := range k.IDs() {
+		if ed25519.Verify(k.keys[id], msg, sig) {
+			return id, nil
+		}
+	}
+	return "", fmt.Errorf("signature does not match digest %s for any trusted key", digest)
+}
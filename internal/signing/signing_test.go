@@ -0,0 +1,145 @@
+package signing
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+const digest = "9f9ea1f085db4baf102ef8a473a5be2712cfc8051cadfc430bcd7774a2084abf"
+
+func newTestKey(t *testing.T) (ed25519.PublicKey, ed25519.PrivateKey) {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pub, priv
+}
+
+func TestParsePolicy(t *testing.T) {
+	tests := []struct {
+		input string
+		want  Policy
+		ok    bool
+	}{
+		{"", PolicyOff, true},
+		{"off", PolicyOff, true},
+		{"warn", PolicyWarn, true},
+		{" Enforce\n", PolicyEnforce, true},
+		{"strict", "", false},
+	}
+	for _, tt := range tests {
+		got, err := ParsePolicy(tt.input)
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("ParsePolicy(%q) = %q, %v; want %q, ok %v", tt.input, got, err, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestParseKeyring(t *testing.T) {
+	pub, _ := newTestKey(t)
+	raw := base64.StdEncoding.EncodeToString(pub)
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkix := base64.StdEncoding.EncodeToString(der)
+
+	tests := []struct {
+		name  string
+		input string
+		ids   []string
+		err   string // "" if the keyring is valid
+	}{
+		{"raw and pkix keys", "b " + raw + "\na " + pkix + "\n", []string{"a", "b"}, ""},
+		{"comments and blank lines", "# trusted keys\n\n  a " + raw + "  \n", []string{"a"}, ""},
+		{"empty", "", nil, ""},
+		{"missing key", "a\n", nil, "line 1"},
+		{"extra field", "a " + raw + " b\n", nil, "line 1"},
+		{"invalid key id", "a/b " + raw + "\n", nil, "line 1"},
+		{"duplicate key id", "a " + raw + "\na " + pkix + "\n", nil, "duplicate key id"},
+		{"not base64", "a not-base64!\n", nil, "not base64"},
+		{"not an ed25519 key", "a " + base64.StdEncoding.EncodeToString([]byte("short")) + "\n", nil, "not a raw or PKIX"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k, err := ParseKeyring(strings.NewReader(tt.input))
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("ParseKeyring = %v, want an error containing %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.Join(k.IDs(), ","); got != strings.Join(tt.ids, ",") {
+				t.Fatalf("key ids %s, want %s", got, strings.Join(tt.ids, ","))
+			}
+		})
+	}
+}
+
+func TestVerify(t *testing.T) {
+	pubA, privA := newTestKey(t)
+	pubB, privB := newTestKey(t)
+	_, privUntrusted := newTestKey(t)
+	keys := &Keyring{keys: map[string]ed25519.PublicKey{"a": pubA, "b": pubB}}
+
+	sign := func(priv ed25519.PrivateKey, digest string) []byte {
+		return ed25519.Sign(priv, Message(digest))
+	}
+
+	tests := []struct {
+		name  string
+		keys  *Keyring
+		keyID string
+		sig   []byte
+		want  string // key id that made the signature, "" if it is rejected
+	}{
+		{"named key", keys, "a", sign(privA, digest), "a"},
+		{"any trusted key", keys, "", sign(privB, digest), "b"},
+		{"signed by another trusted key", keys, "a", sign(privB, digest), ""},
+		{"untrusted key id", keys, "c", sign(privA, digest), ""},
+		{"untrusted signer", keys, "", sign(privUntrusted, digest), ""},
+		{"other digest", keys, "", sign(privA, strings.Repeat("0", 64)), ""},
+		{"no trusted keys", nil, "", sign(privA, digest), ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.keys.Verify(digest, tt.keyID, tt.sig)
+			if tt.want == "" {
+				if err == nil {
+					t.Fatalf("Verify accepted a signature by %s", got)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Fatalf("Verify = %s, %v; want %s", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeSignature(t *testing.T) {
+	valid := base64.StdEncoding.EncodeToString(make([]byte, ed25519.SignatureSize))
+	tests := []struct {
+		name  string
+		input string
+		ok    bool
+	}{
+		{"valid", valid, true},
+		{"surrounding whitespace", " " + valid + "\n", true},
+		{"not base64", "not base64!", false},
+		{"wrong size", base64.StdEncoding.EncodeToString(make([]byte, 32)), false},
+	}
+	for _, tt := range tests {
+		if _, err := DecodeSignature(tt.input); (err == nil) != tt.ok {
+			t.Errorf("%s: DecodeSignature = %v, want ok %v", tt.name, err, tt.ok)
+		}
+	}
+}
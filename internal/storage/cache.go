@@ -0,0 +1,182 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Cache keeps local copies of stored objects, because ONNX Runtime loads
+// sessions from files. Stores that already keep objects as local files are
+// used in place.
+type Cache struct {
+	store Storage
+	dir   string
+
+	mu sync.Mutex // serialises downloads
+}
+
+// NewCache caches objects of store under dir. Stores that keep local files
+// are their own cache and dir is ignored.
+func NewCache(store Storage, dir string) (*Cache, error) {
+	if fs, ok := store.(FileStorage); ok {
+		dir = fs.Dir()
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &Cache{store: store, dir: dir}, nil
+}
+
+func (c *Cache) Store() Storage {
+	return c.store
+}
+
+// Dir is the local directory cached files live in. Staging directories
+// created there can be moved into the cache with a rename; files staged
+// elsewhere are copied.
+func (c *Cache) Dir() string {
+	return c.dir
+}
+
+// Path returns the local file for key, whether or not it is cached yet.
+func (c *Cache) Path(key string) string {
+	if fs, ok := c.store.(FileStorage); ok {
+		return fs.Path(key)
+	}
+	return filepath.Join(c.dir, filepath.FromSlash(key))
+}
+
+// Commit stores the local file src under key and keeps it as the cached
+// copy.
+func (c *Cache) Commit(ctx context.Context, key, src string) error {
+	if fs, ok := c.store.(FileStorage); ok {
+		return fs.PutFile(ctx, key, src)
+	}
+
+	f, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	stat, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	err = c.store.Put(ctx, key, f, stat.Size())
+	f.Close()
+	if err != nil {
+		return err
+	}
+	return moveFile(src, c.Path(key))
+}
+
+// Keep moves the local file src into the cache as the copy of key, unless
+// one is cached already. Stores used in place are not modified.
+func (c *Cache) Keep(key, src string) error {
+	if _, ok := c.store.(FileStorage); ok {
+		return nil
+	}
+	dst := c.Path(key)
+	if _, err := os.Stat(dst); !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return moveFile(src, dst)
+}
+
+// Fetch makes sure key is cached and returns its local path. Cached files
+// are trusted; callers that suspect corruption call Evict first.
+func (c *Cache) Fetch(ctx context.Context, key string) (string, error) {
+	dst := c.Path(key)
+	if _, ok := c.store.(FileStorage); ok {
+		if _, err := c.store.Stat(ctx, key); err != nil {
+			return "", err
+		}
+		return dst, nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, err := os.Stat(dst); err == nil {
+		return dst, nil
+	}
+
+	r, err := c.store.Open(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return "", err
+	}
+	f, err := os.CreateTemp(filepath.Dir(dst), ".fetch-*")
+	if err != nil {
+		return "", err
+	}
+	_, err = io.Copy(f, r)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), dst)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return "", fmt.Errorf("failed to cache %s: %w", key, err)
+	}
+	return dst, nil
+}
+
+// Evict drops the cached copy of key. It is a no-op for stores used in
+// place.
+func (c *Cache) Evict(key string) error {
+	if _, ok := c.store.(FileStorage); ok {
+		return nil
+	}
+	err := os.Remove(c.Path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// moveFile renames src to dst, copying it when src is on another
+// filesystem. The copy is written next to dst and renamed, so dst appears
+// atomically.
+func moveFile(src, dst string) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	f, err := os.CreateTemp(filepath.Dir(dst), ".move-*")
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(f, in)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), dst)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return os.Remove(src)
+}
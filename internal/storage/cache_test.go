@@ -0,0 +1,184 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCacheFetch(t *testing.T) {
+	f := newFakeS3()
+	s := f.start(t, "")
+	ctx := context.Background()
+
+	data := []byte("model bytes")
+	if err := s.Put(ctx, "abc/model.onnx", bytes.NewReader(data), int64(len(data))); err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	c, err := NewCache(s, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path, err := c.Fetch(ctx, "abc/model.onnx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path != filepath.Join(dir, "abc", "model.onnx") || path != c.Path("abc/model.onnx") {
+		t.Fatalf("Fetch = %s, not under the cache directory", path)
+	}
+	if got, _ := os.ReadFile(path); !bytes.Equal(got, data) {
+		t.Fatalf("cached %q, want %q", got, data)
+	}
+
+	// Cached files are used as they are
+	if _, err := c.Fetch(ctx, "abc/model.onnx"); err != nil {
+		t.Fatal(err)
+	}
+	if n := f.count("get"); n != 1 {
+		t.Fatalf("%d downloads, want 1", n)
+	}
+
+	// An evicted file is downloaded again
+	if err := os.WriteFile(path, []byte("modified"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Evict("abc/model.onnx"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Fetch(ctx, "abc/model.onnx"); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := os.ReadFile(path); !bytes.Equal(got, data) || f.count("get") != 2 {
+		t.Fatalf("cached %q after %d downloads, want %q after 2", got, f.count("get"), data)
+	}
+	if err := c.Evict("abc/missing.onnx"); err != nil {
+		t.Fatalf("Evict of an uncached key: %v", err)
+	}
+
+	// A missing object leaves nothing behind
+	if _, err := c.Fetch(ctx, "abc/missing.onnx"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Fetch of a missing key: %v, want ErrNotFound", err)
+	}
+	entries, _ := os.ReadDir(filepath.Join(dir, "abc"))
+	for _, e := range entries {
+		if e.Name() != "model.onnx" {
+			t.Fatalf("unexpected cache entry %s", e.Name())
+		}
+	}
+}
+
+func TestCacheCommit(t *testing.T) {
+	f := newFakeS3()
+	s := f.start(t, "")
+	ctx := context.Background()
+
+	c, err := NewCache(s, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	src := filepath.Join(c.Dir(), ".upload-1")
+	if err := os.WriteFile(src, []byte("staged"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.Commit(ctx, "abc/model.onnx", src); err != nil {
+		t.Fatal(err)
+	}
+	if stored, _ := f.object("abc/model.onnx"); string(stored) != "staged" {
+		t.Fatalf("stored %q, want the staged file", stored)
+	}
+	if _, err := os.Stat(src); !errors.Is(err, os.ErrNotExist) {
+		t.Fatal("staged file was not moved into the cache")
+	}
+	if _, err := c.Fetch(ctx, "abc/model.onnx"); err != nil || f.count("get") != 0 {
+		t.Fatalf("committed file not cached: %v, %d downloads", err, f.count("get"))
+	}
+
+	// Files staged outside the cache directory are moved in as well
+	src = filepath.Join(t.TempDir(), "staged")
+	if err := os.WriteFile(src, []byte("elsewhere"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Commit(ctx, "abd/model.onnx", src); err != nil {
+		t.Fatal(err)
+	}
+	if cached, _ := os.ReadFile(c.Path("abd/model.onnx")); string(cached) != "elsewhere" {
+		t.Fatalf("cached %q, want the staged file", cached)
+	}
+	if _, err := os.Stat(src); !errors.Is(err, os.ErrNotExist) {
+		t.Fatal("staged file left behind")
+	}
+}
+
+func TestCacheKeep(t *testing.T) {
+	f := newFakeS3()
+	s := f.start(t, "")
+
+	c, err := NewCache(s, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	stage := func(data string) string {
+		src := filepath.Join(t.TempDir(), "staged")
+		if err := os.WriteFile(src, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return src
+	}
+
+	if err := c.Keep("abc/model.onnx", stage("first")); err != nil {
+		t.Fatal(err)
+	}
+	// A cached copy is kept; nothing is stored either way
+	if err := c.Keep("abc/model.onnx", stage("second")); err != nil {
+		t.Fatal(err)
+	}
+	if cached, _ := os.ReadFile(c.Path("abc/model.onnx")); string(cached) != "first" {
+		t.Fatalf("cached %q, want the first staged file", cached)
+	}
+	if n := f.count("put"); n != 0 {
+		t.Fatalf("%d uploads, want none", n)
+	}
+}
+
+func TestCacheFileStorage(t *testing.T) {
+	ctx := context.Background()
+	l, err := NewLocal(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Put(ctx, "abc/model.onnx", strings.NewReader("model"), 5); err != nil {
+		t.Fatal(err)
+	}
+
+	// Local stores are used in place; the cache directory is ignored
+	c, err := NewCache(l, filepath.Join(t.TempDir(), "cache"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Dir() != l.Dir() {
+		t.Fatalf("Dir = %s, want the store's %s", c.Dir(), l.Dir())
+	}
+	path, err := c.Fetch(ctx, "abc/model.onnx")
+	if err != nil || path != l.Path("abc/model.onnx") {
+		t.Fatalf("Fetch = %s, %v; want %s", path, err, l.Path("abc/model.onnx"))
+	}
+	if _, err := c.Fetch(ctx, "abc/missing.onnx"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Fetch of a missing key: %v, want ErrNotFound", err)
+	}
+
+	// Evict never deletes the stored file
+	if err := c.Evict("abc/model.onnx"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("Evict removed the stored file: %v", err)
+	}
+}
@@ -0,0 +1,102 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLocal(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	l, err := NewLocal(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, key := range []string{"abc/b.onnx", "abc/a/x.bin", "abd.onnx"} {
+		if err := l.Put(ctx, key, strings.NewReader(key), int64(len(key))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Short writes are not stored
+	if err := l.Put(ctx, "short.onnx", strings.NewReader("abc"), 4); err == nil {
+		t.Fatal("Put with a short reader succeeded")
+	}
+	// Staging entries are not objects
+	if err := os.MkdirAll(filepath.Join(dir, ".upload-1"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ".upload-1", "abc"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := l.Open(ctx, "abc/b.onnx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(r)
+	r.Close()
+	if string(data) != "abc/b.onnx" {
+		t.Fatalf("Open = %q", data)
+	}
+
+	objects, err := l.List(ctx, "ab")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := fmt.Sprint(objects), "[{abc/a/x.bin 11} {abc/b.onnx 10} {abd.onnx 8}]"; got != want {
+		t.Fatalf("List = %s, want %s", got, want)
+	}
+
+	// Deleting the last object of a directory removes the directory
+	if err := l.Delete(ctx, "abc/a/x.bin"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "abc", "a")); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("empty directory left behind: %v", err)
+	}
+	if _, err := l.Stat(ctx, "abc/a/x.bin"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Stat after Delete: %v, want ErrNotFound", err)
+	}
+	if _, err := l.Stat(ctx, "abc"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Stat of a directory: %v, want ErrNotFound", err)
+	}
+	if err := l.Delete(ctx, "abc/a/x.bin"); err != nil {
+		t.Fatalf("Delete of a missing key: %v", err)
+	}
+
+	for _, key := range []string{"", "/etc/passwd", "../x", "abc/../../x"} {
+		if _, err := l.Open(ctx, key); err == nil || errors.Is(err, ErrNotFound) {
+			t.Errorf("Open(%q) = %v, want an invalid key error", key, err)
+		}
+	}
+}
+
+func TestLocalPutFile(t *testing.T) {
+	ctx := context.Background()
+	l, err := NewLocal(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// From another directory, possibly on another filesystem
+	src := filepath.Join(t.TempDir(), "staged")
+	if err := os.WriteFile(src, []byte("staged"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.PutFile(ctx, "abc/model.onnx", src); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(l.Path("abc/model.onnx")); string(data) != "staged" {
+		t.Fatalf("stored %q", data)
+	}
+	if _, err := os.Stat(src); !errors.Is(err, os.ErrNotExist) {
+		t.Fatal("source file left behind")
+	}
+}
@@ -0,0 +1,347 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultPartSize is the part size of multipart uploads; objects up to
+// this size are uploaded with a single PUT.
+const defaultPartSize = 64 << 20 // 64 MB
+
+// S3Config configures an S3-compatible bucket.
+type S3Config struct {
+	// Endpoint is the service URL, e.g. http://localhost:9000 for MinIO.
+	// Empty means AWS S3 in Region.
+	Endpoint string
+	Region   string
+	Bucket   string
+	// Prefix is prepended to every key, e.g. "models/".
+	Prefix string
+	// PathStyle addresses the bucket as a path (endpoint/bucket/key)
+	// instead of a subdomain. MinIO and most S3-compatible stores need it.
+	PathStyle bool
+
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+
+	// Client is used for requests; nil means http.DefaultClient.
+	Client *http.Client
+}
+
+// S3 stores objects in an S3-compatible bucket, signing requests with AWS
+// Signature Version 4.
+type S3 struct {
+	cfg      S3Config
+	base     *url.URL
+	client   *http.Client
+	now      func() time.Time
+	partSize int64
+}
+
+func NewS3(cfg S3Config) (*S3, error) {
+	if cfg.Bucket == "" {
+		return nil, errors.New("s3: bucket is required")
+	}
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = "https://s3." + cfg.Region + ".amazonaws.com"
+	}
+	if cfg.AccessKeyID == "" || cfg.SecretAccessKey == "" {
+		return nil, errors.New("s3: access key id and secret access key are required")
+	}
+
+	base, err := url.Parse(strings.TrimSuffix(cfg.Endpoint, "/"))
+	if err != nil || base.Scheme == "" || base.Host == "" {
+		return nil, fmt.Errorf("s3: invalid endpoint %q", cfg.Endpoint)
+	}
+	if !cfg.PathStyle {
+		base.Host = cfg.Bucket + "." + base.Host
+	}
+
+	client := cfg.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &S3{cfg: cfg, base: base, client: client, now: time.Now, partSize: defaultPartSize}, nil
+}
+
+// objectURL returns the URL of a full (prefixed) object key, or of the
+// bucket when key is empty.
+func (s *S3) objectURL(key string, query url.Values) *url.URL {
+	u := *s.base
+	p := "/"
+	if s.cfg.PathStyle {
+		p += s.cfg.Bucket
+		if key != "" {
+			p += "/"
+		}
+	}
+	u.Path = u.Path + p + key
+
+	segments := strings.Split(key, "/")
+	for i, seg := range segments {
+		segments[i] = uriEncode(seg)
+	}
+	u.RawPath = s.base.EscapedPath() + p + strings.Join(segments, "/")
+
+	if query != nil {
+		u.RawQuery = query.Encode()
+	}
+	return &u
+}
+
+// do signs and sends a request. body is sent with contentLength bytes; a
+// nil payloadHash means the body is unsigned.
+func (s *S3) do(ctx context.Context, method string, u *url.URL, body io.Reader, contentLength int64, payloadHash string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	req.URL = u
+	if body != nil {
+		req.ContentLength = contentLength
+		if contentLength == 0 {
+			req.Body = http.NoBody
+		}
+	}
+
+	creds := credentials{
+		AccessKeyID:     s.cfg.AccessKeyID,
+		SecretAccessKey: s.cfg.SecretAccessKey,
+		SessionToken:    s.cfg.SessionToken,
+	}
+	signV4(req, creds, s.cfg.Region, payloadHash, s.now())
+
+	return s.client.Do(req)
+}
+
+// s3Error is the XML error body returned by S3.
+type s3Error struct {
+	Code    string `xml:"Code"`
+	Message string `xml:"Message"`
+}
+
+// check turns a non-2xx response into an error and closes its body.
+func check(resp *http.Response, op, key string) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return ErrNotFound
+	}
+	var e s3Error
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if xml.Unmarshal(data, &e) == nil && e.Code != "" {
+		return fmt.Errorf("s3 %s %s: %s: %s", op, key, e.Code, e.Message)
+	}
+	return fmt.Errorf("s3 %s %s: %s", op, key, resp.Status)
+}
+
+func (s *S3) Put(ctx context.Context, key string, r io.Reader, size int64) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+	if size > s.partSize {
+		return s.putMultipart(ctx, s.cfg.Prefix+key, r, size)
+	}
+
+	resp, err := s.do(ctx, http.MethodPut, s.objectURL(s.cfg.Prefix+key, nil), r, size, unsignedPayload)
+	if err != nil {
+		return err
+	}
+	if err := check(resp, "put", key); err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+type initiateMultipartResult struct {
+	UploadID string `xml:"UploadId"`
+}
+
+type completedPart struct {
+	PartNumber int    `xml:"PartNumber"`
+	ETag       string `xml:"ETag"`
+}
+
+type completeMultipartUpload struct {
+	XMLName xml.Name        `xml:"CompleteMultipartUpload"`
+	Parts   []completedPart `xml:"Part"`
+}
+
+// putMultipart uploads an object in s.partSize parts read sequentially from
+// r. A failed upload is aborted so no parts are left behind.
+func (s *S3) putMultipart(ctx context.Context, key string, r io.Reader, size int64) (err error) {
+	resp, err := s.do(ctx, http.MethodPost, s.objectURL(key, url.Values{"uploads": {""}}), nil, 0, emptyPayloadHash)
+	if err != nil {
+		return err
+	}
+	if err := check(resp, "create multipart upload", key); err != nil {
+		return err
+	}
+	var initiated initiateMultipartResult
+	err = xml.NewDecoder(resp.Body).Decode(&initiated)
+	resp.Body.Close()
+	if err != nil || initiated.UploadID == "" {
+		return fmt.Errorf("s3 create multipart upload %s: invalid response", key)
+	}
+
+	upload := url.Values{"uploadId": {initiated.UploadID}}
+	defer func() {
+		if err != nil {
+			// Use a fresh context: ctx may be the reason the upload failed
+			resp, abortErr := s.do(context.Background(), http.MethodDelete, s.objectURL(key, upload), nil, 0, emptyPayloadHash)
+			if abortErr == nil {
+				resp.Body.Close()
+			}
+		}
+	}()
+
+	var parts []completedPart
+	for n, remaining := 1, size; remaining > 0; n++ {
+		length := min(remaining, s.partSize)
+		query := url.Values{"partNumber": {strconv.Itoa(n)}, "uploadId": {initiated.UploadID}}
+
+		resp, err := s.do(ctx, http.MethodPut, s.objectURL(key, query), io.LimitReader(r, length), length, unsignedPayload)
+		if err != nil {
+			return err
+		}
+		if err := check(resp, "upload part", key); err != nil {
+			return err
+		}
+		resp.Body.Close()
+
+		parts = append(parts, completedPart{PartNumber: n, ETag: resp.Header.Get("ETag")})
+		remaining -= length
+	}
+
+	body, err := xml.Marshal(completeMultipartUpload{Parts: parts})
+	if err != nil {
+		return err
+	}
+	resp, err = s.do(ctx, http.MethodPost, s.objectURL(key, upload), bytes.NewReader(body), int64(len(body)), hexSHA256(body))
+	if err != nil {
+		return err
+	}
+	if err := check(resp, "complete multipart upload", key); err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// CompleteMultipartUpload can fail after a 200 status; the error is
+	// then in the body
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if err != nil {
+		return err
+	}
+	var e s3Error
+	if xml.Unmarshal(data, &e) == nil && e.Code != "" {
+		return fmt.Errorf("s3 complete multipart upload %s: %s: %s", key, e.Code, e.Message)
+	}
+	return nil
+}
+
+func (s *S3) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	if err := checkKey(key); err != nil {
+		return nil, err
+	}
+
+	resp, err := s.do(ctx, http.MethodGet, s.objectURL(s.cfg.Prefix+key, nil), nil, 0, emptyPayloadHash)
+	if err != nil {
+		return nil, err
+	}
+	if err := check(resp, "get", key); err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+func (s *S3) Stat(ctx context.Context, key string) (Object, error) {
+	if err := checkKey(key); err != nil {
+		return Object{}, err
+	}
+
+	resp, err := s.do(ctx, http.MethodHead, s.objectURL(s.cfg.Prefix+key, nil), nil, 0, emptyPayloadHash)
+	if err != nil {
+		return Object{}, err
+	}
+	if err := check(resp, "head", key); err != nil {
+		return Object{}, err
+	}
+	resp.Body.Close()
+	return Object{Key: key, Size: resp.ContentLength}, nil
+}
+
+type listBucketResult struct {
+	Contents []struct {
+		Key  string `xml:"Key"`
+		Size int64  `xml:"Size"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+func (s *S3) List(ctx context.Context, prefix string) ([]Object, error) {
+	var objects []Object
+	token := ""
+	for {
+		query := url.Values{"list-type": {"2"}, "prefix": {s.cfg.Prefix + prefix}}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+
+		resp, err := s.do(ctx, http.MethodGet, s.objectURL("", query), nil, 0, emptyPayloadHash)
+		if err != nil {
+			return nil, err
+		}
+		if err := check(resp, "list", prefix); err != nil {
+			return nil, err
+		}
+		var page listBucketResult
+		err = xml.NewDecoder(resp.Body).Decode(&page)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("s3 list %s: %w", prefix, err)
+		}
+
+		for _, c := range page.Contents {
+			objects = append(objects, Object{Key: strings.TrimPrefix(c.Key, s.cfg.Prefix), Size: c.Size})
+		}
+		if !page.IsTruncated || page.NextContinuationToken == "" {
+			return objects, nil // ListObjectsV2 returns keys in order
+		}
+		token = page.NextContinuationToken
+	}
+}
+
+func (s *S3) Delete(ctx context.Context, key string) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+
+	resp, err := s.do(ctx, http.MethodDelete, s.objectURL(s.cfg.Prefix+key, nil), nil, 0, emptyPayloadHash)
+	if err != nil {
+		return err
+	}
+	if err := check(resp, "delete", key); err != nil && !errors.Is(err, ErrNotFound) {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
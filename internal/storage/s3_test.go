@@ -0,0 +1,405 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+var testCreds = credentials{
+	AccessKeyID:     "AKIDEXAMPLE",
+	SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+	SessionToken:    "session-token",
+}
+
+// fakeS3 is an in-memory S3 bucket served over path-style URLs. It checks
+// the signature of every request and implements the calls S3 uses.
+type fakeS3 struct {
+	bucket string
+	creds  credentials
+	// pageSize is the number of keys per ListObjectsV2 page.
+	pageSize int
+	// failPart makes the upload of that part number fail.
+	failPart int
+
+	mu       sync.Mutex
+	objects  map[string][]byte
+	uploads  map[string]map[int][]byte
+	nextID   int
+	requests map[string]int // operation -> count
+}
+
+func newFakeS3() *fakeS3 {
+	return &fakeS3{
+		bucket:   "models",
+		creds:    testCreds,
+		pageSize: 1000,
+		objects:  make(map[string][]byte),
+		uploads:  make(map[string]map[int][]byte),
+		requests: make(map[string]int),
+	}
+}
+
+// start serves the bucket and returns a client for it with the given key
+// prefix.
+func (f *fakeS3) start(t *testing.T, prefix string) *S3 {
+	t.Helper()
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+
+	s, err := NewS3(S3Config{
+		Endpoint:        srv.URL,
+		Bucket:          f.bucket,
+		Prefix:          prefix,
+		PathStyle:       true,
+		AccessKeyID:     testCreds.AccessKeyID,
+		SecretAccessKey: testCreds.SecretAccessKey,
+		SessionToken:    testCreds.SessionToken,
+		Client:          srv.Client(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func (f *fakeS3) count(op string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.requests[op]
+}
+
+func (f *fakeS3) object(key string) ([]byte, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	data, ok := f.objects[key]
+	return data, ok
+}
+
+func (f *fakeS3) fail(w http.ResponseWriter, status int, code string) {
+	w.WriteHeader(status)
+	fmt.Fprintf(w, "<Error><Code>%s</Code><Message>%s</Message></Error>", code, code)
+}
+
+// verify signs a copy of the received request with the fake's credentials
+// and compares the result with the request's Authorization header.
+func (f *fakeS3) verify(r *http.Request) bool {
+	now, err := time.Parse(amzDateFormat, r.Header.Get("X-Amz-Date"))
+	if err != nil {
+		return false
+	}
+	u := *r.URL
+	u.Scheme, u.Host = "http", r.Host
+	req := &http.Request{Method: r.Method, URL: &u, Host: r.Host, Header: http.Header{}}
+	for name, v := range r.Header {
+		if name != "Authorization" {
+			req.Header[name] = v
+		}
+	}
+	signV4(req, f.creds, "us-east-1", r.Header.Get("X-Amz-Content-Sha256"), now)
+	return req.Header.Get("Authorization") == r.Header.Get("Authorization")
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !f.verify(r) {
+		f.fail(w, http.StatusForbidden, "SignatureDoesNotMatch")
+		return
+	}
+
+	key, ok := strings.CutPrefix(r.URL.Path, "/"+f.bucket)
+	if !ok {
+		f.fail(w, http.StatusNotFound, "NoSuchBucket")
+		return
+	}
+	key = strings.TrimPrefix(key, "/")
+	query := r.URL.Query()
+	body, _ := io.ReadAll(r.Body)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch {
+	case key == "" && r.Method == http.MethodGet:
+		f.requests["list"]++
+		f.list(w, query)
+
+	case r.Method == http.MethodPost && query.Has("uploads"):
+		f.requests["initiate"]++
+		f.nextID++
+		id := strconv.Itoa(f.nextID)
+		f.uploads[id] = make(map[int][]byte)
+		fmt.Fprintf(w, "<InitiateMultipartUploadResult><UploadId>%s</UploadId></InitiateMultipartUploadResult>", id)
+
+	case r.Method == http.MethodPut && query.Has("uploadId"):
+		f.requests["part"]++
+		parts, ok := f.uploads[query.Get("uploadId")]
+		n, _ := strconv.Atoi(query.Get("partNumber"))
+		switch {
+		case !ok:
+			f.fail(w, http.StatusNotFound, "NoSuchUpload")
+		case n == f.failPart:
+			f.fail(w, http.StatusInternalServerError, "InternalError")
+		default:
+			parts[n] = body
+			w.Header().Set("ETag", fmt.Sprintf(`"part-%d"`, n))
+		}
+
+	case r.Method == http.MethodPost && query.Has("uploadId"):
+		f.requests["complete"]++
+		parts, ok := f.uploads[query.Get("uploadId")]
+		var complete completeMultipartUpload
+		if !ok || xml.Unmarshal(body, &complete) != nil {
+			f.fail(w, http.StatusBadRequest, "InvalidRequest")
+			return
+		}
+		var data []byte
+		for i, p := range complete.Parts {
+			if p.PartNumber != i+1 || p.ETag != fmt.Sprintf(`"part-%d"`, i+1) || parts[p.PartNumber] == nil {
+				f.fail(w, http.StatusBadRequest, "InvalidPart")
+				return
+			}
+			data = append(data, parts[p.PartNumber]...)
+		}
+		delete(f.uploads, query.Get("uploadId"))
+		f.objects[key] = data
+		fmt.Fprint(w, "<CompleteMultipartUploadResult></CompleteMultipartUploadResult>")
+
+	case r.Method == http.MethodDelete && query.Has("uploadId"):
+		f.requests["abort"]++
+		delete(f.uploads, query.Get("uploadId"))
+		w.WriteHeader(http.StatusNoContent)
+
+	case r.Method == http.MethodPut:
+		f.requests["put"]++
+		if int64(len(body)) != r.ContentLength {
+			f.fail(w, http.StatusBadRequest, "IncompleteBody")
+			return
+		}
+		f.objects[key] = body
+
+	case r.Method == http.MethodGet, r.Method == http.MethodHead:
+		f.requests[strings.ToLower(r.Method)]++
+		data, ok := f.objects[key]
+		if !ok {
+			f.fail(w, http.StatusNotFound, "NoSuchKey")
+			return
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+		if r.Method == http.MethodGet {
+			w.Write(data)
+		}
+
+	case r.Method == http.MethodDelete:
+		f.requests["delete"]++
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		f.fail(w, http.StatusMethodNotAllowed, "MethodNotAllowed")
+	}
+}
+
+// list serves one ListObjectsV2 page. Continuation tokens are the last key
+// of the previous page.
+func (f *fakeS3) list(w http.ResponseWriter, query url.Values) {
+	if query.Get("list-type") != "2" {
+		f.fail(w, http.StatusBadRequest, "InvalidArgument")
+		return
+	}
+	var keys []string
+	for key := range f.objects {
+		if strings.HasPrefix(key, query.Get("prefix")) && key > query.Get("continuation-token") {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	var page listBucketResult
+	if len(keys) > f.pageSize {
+		keys = keys[:f.pageSize]
+		page.IsTruncated = true
+		page.NextContinuationToken = keys[len(keys)-1]
+	}
+	for _, key := range keys {
+		page.Contents = append(page.Contents, struct {
+			Key  string `xml:"Key"`
+			Size int64  `xml:"Size"`
+		}{key, int64(len(f.objects[key]))})
+	}
+	xml.NewEncoder(w).Encode(page)
+}
+
+func TestS3PutOpenDelete(t *testing.T) {
+	f := newFakeS3()
+	s := f.start(t, "prod/")
+	ctx := context.Background()
+
+	// Spaces and plus signs must be encoded the same way when signing
+	key := "abc/model v1+final.onnx"
+	data := []byte("model bytes")
+	if err := s.Put(ctx, key, bytes.NewReader(data), int64(len(data))); err != nil {
+		t.Fatal(err)
+	}
+	if stored, ok := f.object("prod/" + key); !ok || !bytes.Equal(stored, data) {
+		t.Fatalf("stored %q, want %q under the prefix", stored, data)
+	}
+
+	r, err := s.Open(ctx, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := io.ReadAll(r)
+	r.Close()
+	if err != nil || !bytes.Equal(got, data) {
+		t.Fatalf("Open = %q, %v; want %q", got, err, data)
+	}
+
+	obj, err := s.Stat(ctx, key)
+	if err != nil || obj != (Object{Key: key, Size: int64(len(data))}) {
+		t.Fatalf("Stat = %+v, %v", obj, err)
+	}
+
+	if err := s.Delete(ctx, key); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Open(ctx, key); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Open after Delete: %v, want ErrNotFound", err)
+	}
+	if _, err := s.Stat(ctx, key); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Stat after Delete: %v, want ErrNotFound", err)
+	}
+	if err := s.Delete(ctx, key); err != nil {
+		t.Fatalf("Delete of a missing key: %v", err)
+	}
+}
+
+func TestS3InvalidKeys(t *testing.T) {
+	f := newFakeS3()
+	s := f.start(t, "")
+	ctx := context.Background()
+
+	for _, key := range []string{"", "/abs", "../up", "a/../b", "a//b"} {
+		if err := s.Put(ctx, key, strings.NewReader("x"), 1); err == nil {
+			t.Errorf("Put(%q) succeeded", key)
+		}
+		if _, err := s.Open(ctx, key); err == nil {
+			t.Errorf("Open(%q) succeeded", key)
+		}
+	}
+	if n := f.count("put") + f.count("get"); n != 0 {
+		t.Fatalf("%d requests sent for invalid keys", n)
+	}
+}
+
+func TestS3PutMultipart(t *testing.T) {
+	f := newFakeS3()
+	s := f.start(t, "")
+	s.partSize = 4
+	ctx := context.Background()
+
+	data := []byte("0123456789")
+	if err := s.Put(ctx, "big.onnx", bytes.NewReader(data), int64(len(data))); err != nil {
+		t.Fatal(err)
+	}
+	if stored, _ := f.object("big.onnx"); !bytes.Equal(stored, data) {
+		t.Fatalf("stored %q, want %q", stored, data)
+	}
+	if f.count("initiate") != 1 || f.count("part") != 3 || f.count("complete") != 1 || f.count("put") != 0 {
+		t.Fatalf("requests = %v, want 1 initiate, 3 parts and 1 complete", f.requests)
+	}
+
+	// Objects up to the part size use a single PUT
+	if err := s.Put(ctx, "small.onnx", bytes.NewReader(data[:4]), 4); err != nil {
+		t.Fatal(err)
+	}
+	if f.count("put") != 1 || f.count("initiate") != 1 {
+		t.Fatalf("requests = %v, want a single PUT", f.requests)
+	}
+}
+
+func TestS3PutMultipartAbort(t *testing.T) {
+	f := newFakeS3()
+	f.failPart = 2
+	s := f.start(t, "")
+	s.partSize = 4
+	ctx := context.Background()
+
+	data := []byte("0123456789")
+	err := s.Put(ctx, "big.onnx", bytes.NewReader(data), int64(len(data)))
+	if err == nil || !strings.Contains(err.Error(), "InternalError") {
+		t.Fatalf("Put = %v, want the part failure", err)
+	}
+	if f.count("abort") != 1 || f.count("complete") != 0 {
+		t.Fatalf("requests = %v, want the upload aborted", f.requests)
+	}
+	if len(f.uploads) != 0 {
+		t.Fatalf("%d multipart uploads left behind", len(f.uploads))
+	}
+	if _, ok := f.object("big.onnx"); ok {
+		t.Fatal("object stored after a failed upload")
+	}
+}
+
+func TestS3ListPaging(t *testing.T) {
+	f := newFakeS3()
+	f.pageSize = 2
+	s := f.start(t, "prod/")
+	ctx := context.Background()
+
+	var want []Object
+	for _, key := range []string{"abc/e", "abc/a", "abc/d/x", "abc/b", "abc/c"} {
+		if err := s.Put(ctx, key, strings.NewReader(key), int64(len(key))); err != nil {
+			t.Fatal(err)
+		}
+		want = append(want, Object{Key: key, Size: int64(len(key))})
+	}
+	sort.Slice(want, func(i, j int) bool { return want[i].Key < want[j].Key })
+	for _, key := range []string{"abd", "records/x"} {
+		if err := s.Put(ctx, key, strings.NewReader(key), int64(len(key))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	f.objects["other/abc/z"] = []byte("outside the prefix")
+
+	got, err := s.List(ctx, "abc/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("List = %v, want %v", got, want)
+	}
+	if n := f.count("list"); n != 3 {
+		t.Fatalf("List made %d requests, want 3 pages", n)
+	}
+
+	all, err := s.List(ctx, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 7 {
+		t.Fatalf("List of the whole prefix returned %d objects, want 7: %v", len(all), all)
+	}
+}
+
+func TestS3SignatureRejected(t *testing.T) {
+	f := newFakeS3()
+	f.creds.SecretAccessKey = "another secret"
+	s := f.start(t, "")
+
+	_, err := s.Open(context.Background(), "a.onnx")
+	if err == nil || errors.Is(err, ErrNotFound) || !strings.Contains(err.Error(), "SignatureDoesNotMatch") {
+		t.Fatalf("Open = %v, want a signature error", err)
+	}
+}
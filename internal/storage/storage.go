@@ -0,0 +1,56 @@
+// Package storage keeps model artifacts in a durable object store: a local
+// directory or an S3-compatible bucket. Keys are slash-separated paths.
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+// ErrNotFound is returned for keys that do not exist.
+var ErrNotFound = errors.New("object not found")
+
+// Object describes one stored object.
+type Object struct {
+	Key  string `json:"key"`
+	Size int64  `json:"size"`
+}
+
+// Storage is a flat key/value store for artifact files.
+type Storage interface {
+	// Put stores size bytes read from r under key, replacing any existing
+	// object. Readers never observe a partially written object.
+	Put(ctx context.Context, key string, r io.Reader, size int64) error
+	// Open returns the contents of key.
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	// Stat describes key without reading it.
+	Stat(ctx context.Context, key string) (Object, error)
+	// List returns every object whose key starts with prefix, sorted by key.
+	List(ctx context.Context, prefix string) ([]Object, error)
+	// Delete removes key. Deleting a missing key is not an error.
+	Delete(ctx context.Context, key string) error
+}
+
+// FileStorage is implemented by stores that keep objects as local files,
+// which can then be used in place instead of being copied into a cache.
+type FileStorage interface {
+	// Dir is the directory objects are stored in.
+	Dir() string
+	// Path returns the file that holds key.
+	Path(key string) string
+	// PutFile moves the file at src into the store under key.
+	PutFile(ctx context.Context, key, src string) error
+}
+
+// checkKey rejects keys that are empty, absolute or not in clean form, so
+// that no key can address anything outside the store.
+func checkKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || path.Clean(key) != key || key == ".." || strings.HasPrefix(key, "../") {
+		return fmt.Errorf("invalid storage key %q", key)
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package onnx
+
+import (
+	"fmt"
+
+	"github.com/kevo-1/model-nexus/internal/domain"
+)
+
+// BindDims checks the shapes supplied for each named input against the
+// model's declared inputs. Concrete axes must match exactly, and every
+// symbolic axis name (e.g. "seq_len") must resolve to the same length
+// wherever it appears. It returns the resolved value of each symbol.
+func (m *ModelInfo) BindDims(shapes map[string][]int64) (map[string]int64, error) {
+	bound := make(map[string]int64)
+	boundBy := make(map[string]string)
+
+	for name := range shapes {
+		if _, ok := m.input(name); !ok {
+			return nil, &domain.ShapeMismatchError{Input: name, Reason: "model has no such input"}
+		}
+	}
+
+	for _, in := range m.Inputs {
+		shape, ok := shapes[in.Name]
+		if !ok {
+			return nil, &domain.ShapeMismatchError{Input: in.Name, Reason: "input is required"}
+		}
+
+		// Inputs without shape information accept anything.
+		if in.Shape == nil {
+			continue
+		}
+
+		if len(shape) != len(in.Shape) {
+			return nil, &domain.ShapeMismatchError{
+				Input:  in.Name,
+				Reason: fmt.Sprintf("expected rank %d, got %d", len(in.Shape), len(shape)),
+			}
+		}
+
+		for axis, want := range in.Shape {
+			got := shape[axis]
+			param := ""
+			if axis < len(in.DimParams) {
+				param = in.DimParams[axis]
+			}
+
+			switch {
+			case want > 0 && got != want:
+				return nil, &domain.ShapeMismatchError{
+					Input:  in.Name,
+					Reason: fmt.Sprintf("axis %d must be %d, got %d", axis, want, got),
+				}
+			case param != "":
+				if prev, ok := bound[param]; ok && prev != got {
+					return nil, &domain.ShapeMismatchError{
+						Input: in.Name,
+						Reason: fmt.Sprintf("axis %d (%s) is %d but %s bound %s to %d",
+							axis, param, got, boundBy[param], param, prev),
+					}
+				}
+				bound[param] = got
+				boundBy[param] = in.Name
+			}
+		}
+	}
+
+	return bound, nil
+}
+
+func (m *ModelInfo) input(name string) (TensorInfo, bool) {
+	for _, in := range m.Inputs {
+		if in.Name == name {
+			return in, true
+		}
+	}
+	return TensorInfo{}, false
+}
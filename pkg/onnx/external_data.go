@@ -0,0 +1,139 @@
+package onnx
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+
+	"github.com/kevo-1/model-nexus/internal/domain"
+)
+
+// Field numbers for external tensor data (see graph.go for the rest):
+//
+// TensorProto:
+//   field 13 = external_data (repeated StringStringEntryProto)
+//
+// Recognised external_data keys are "location" (path relative to the
+// model file), "offset" and "length" (bytes, decimal strings).
+
+const tensorFieldExternalData = 13
+
+// ExternalDataFile summarises one file holding external tensor data.
+type ExternalDataFile struct {
+	Location string `json:"location"`
+	Tensors  int    `json:"tensors"`
+	Bytes    int64  `json:"bytes"`
+}
+
+type externalRef struct {
+	Location string
+	Offset   int64
+	Length   int64 // 0 when not declared
+}
+
+// parseExternalRef reads the external_data entries of a TensorProto stored
+// outside the model file.
+func parseExternalRef(tensor []byte) (externalRef, error) {
+	var ref externalRef
+
+	err := forEachBytesField(tensor, tensorFieldExternalData, func(entry []byte) error {
+		key, value, err := parseStringEntry(entry)
+		if err != nil {
+			return err
+		}
+
+		switch key {
+		case "location":
+			ref.Location = value
+		case "offset", "length":
+			n, err := strconv.ParseInt(value, 10, 64)
+			if err != nil || n < 0 {
+				return fmt.Errorf("invalid external data %s %q", key, value)
+			}
+			if key == "offset" {
+				ref.Offset = n
+			} else {
+				ref.Length = n
+			}
+		}
+		return nil
+	})
+	return ref, err
+}
+
+// resolveExternalData checks that every initializer stored as external
+// data points at a file inside modelDir that is large enough to hold it.
+func resolveExternalData(graphData []byte, modelDir string) ([]ExternalDataFile, error) {
+	files := make(map[string]*ExternalDataFile)
+	sizes := make(map[string]int64)
+
+	err := forEachBytesField(graphData, graphFieldInitializer, func(tensor []byte) error {
+		info, err := parseInitializerInfo(tensor)
+		if err != nil || !info.External {
+			return err
+		}
+
+		ref, err := parseExternalRef(tensor)
+		if err != nil {
+			return fmt.Errorf("tensor %s: %w", info.Name, err)
+		}
+		if ref.Location == "" {
+			return &domain.ValidationError{Field: "file", Message: fmt.Sprintf("tensor %s has external data without a location", info.Name)}
+		}
+		if !filepath.IsLocal(filepath.FromSlash(ref.Location)) {
+			return &domain.ValidationError{Field: "file", Message: fmt.Sprintf("tensor %s: external data location %q escapes the model directory", info.Name, ref.Location)}
+		}
+
+		size, ok := sizes[ref.Location]
+		if !ok {
+			stat, err := os.Stat(filepath.Join(modelDir, filepath.FromSlash(ref.Location)))
+			if errors.Is(err, os.ErrNotExist) {
+				return &domain.ValidationError{
+					Field:   "file",
+					Message: fmt.Sprintf("tensor %s: external data file %q is missing; upload the model as a zip or tar bundle", info.Name, ref.Location),
+				}
+			}
+			if err != nil {
+				return err
+			}
+			if !stat.Mode().IsRegular() {
+				return &domain.ValidationError{Field: "file", Message: fmt.Sprintf("external data location %q is not a regular file", ref.Location)}
+			}
+			size = stat.Size()
+			sizes[ref.Location] = size
+		}
+
+		length := ref.Length
+		if length == 0 {
+			length = info.Bytes
+		}
+		if ref.Offset+length > size {
+			return &domain.ValidationError{
+				Field:   "file",
+				Message: fmt.Sprintf("tensor %s: external data %q is %d bytes, need %d", info.Name, ref.Location, size, ref.Offset+length),
+			}
+		}
+
+		f, ok := files[ref.Location]
+		if !ok {
+			f = &ExternalDataFile{Location: ref.Location}
+			files[ref.Location] = f
+		}
+		f.Tensors++
+		f.Bytes += length
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]ExternalDataFile, 0, len(files))
+	for _, f := range files {
+		out = append(out, *f)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Location < out[j].Location })
+	return out, nil
+}
@@ -0,0 +1,77 @@
+package onnx
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// The seed corpus is built from the models shipped in models/. Run with
+//
+//	go test ./pkg/onnx -fuzz=FuzzExtractModelInfo
+func addShippedModels(f *testing.F) {
+	paths, err := filepath.Glob(filepath.Join("..", "..", "models", "*.onnx"))
+	if err != nil {
+		f.Fatal(err)
+	}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(data)
+	}
+}
+
+func writeModel(t *testing.T, data []byte) string {
+	path := filepath.Join(t.TempDir(), "model.onnx")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func checkParseError(t *testing.T, err error, size int) {
+	var perr *ParseError
+	if errors.As(err, &perr) && (perr.Offset < 0 || perr.Offset > int64(size)) {
+		t.Fatalf("parse error offset %d outside file of %d bytes", perr.Offset, size)
+	}
+}
+
+func FuzzExtractModelInfo(f *testing.F) {
+	addShippedModels(f)
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		info, err := ExtractModelInfo(writeModel(t, data))
+		checkParseError(t, err, len(data))
+		if err != nil {
+			return
+		}
+		if len(info.Inputs) == 0 || len(info.Outputs) == 0 {
+			t.Fatalf("accepted model without inputs or outputs")
+		}
+		for _, in := range info.Inputs {
+			for _, d := range in.Shape {
+				if d < 0 {
+					t.Fatalf("input %s has negative dimension %d", in.Name, d)
+				}
+			}
+		}
+	})
+}
+
+func FuzzExtractGraph(f *testing.F) {
+	addShippedModels(f)
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		graph, err := ExtractGraph(writeModel(t, data))
+		checkParseError(t, err, len(data))
+		if err != nil {
+			return
+		}
+		if graph.ParameterCount < 0 || graph.ParameterBytes < 0 {
+			t.Fatalf("negative parameter totals %d/%d", graph.ParameterCount, graph.ParameterBytes)
+		}
+	})
+}
@@ -0,0 +1,494 @@
+package onnx
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Field numbers for graph introspection (see onnx_parser.go for the rest):
+//
+// GraphProto:
+//   field 2  = name               (string)
+//   field 13 = value_info         (repeated ValueInfoProto)
+//   field 15 = sparse_initializer (repeated SparseTensorProto)
+//
+// NodeProto:
+//   field 1 = input  (repeated string)
+//   field 2 = output (repeated string)
+//   field 3 = name   (string)
+//
+// AttributeProto:
+//   field 1  = name           (string)
+//   field 2  = f              (float)
+//   field 3  = i              (int64)
+//   field 4  = s              (bytes)
+//   field 5  = t              (TensorProto)
+//   field 7  = floats         (repeated float)
+//   field 8  = ints           (repeated int64)
+//   field 9  = strings        (repeated bytes)
+//   field 10 = tensors        (repeated TensorProto)
+//   field 20 = type           (AttributeType enum)
+//   field 22 = sparse_tensor  (SparseTensorProto)
+//   field 23 = sparse_tensors (repeated SparseTensorProto)
+//
+// TensorProto:
+//   field 1  = dims          (repeated int64)
+//   field 2  = data_type     (int32)
+//   field 6  = string_data   (repeated bytes)
+//   field 14 = data_location (enum, 1 = EXTERNAL)
+
+const (
+	graphFieldName              = 2
+	graphFieldValueInfo         = 13
+	graphFieldSparseInitializer = 15
+
+	nodeFieldInput  = 1
+	nodeFieldOutput = 2
+	nodeFieldName   = 3
+
+	attributeFieldName    = 1
+	attributeFieldFloat   = 2
+	attributeFieldInt     = 3
+	attributeFieldString  = 4
+	attributeFieldTensor  = 5
+	attributeFieldFloats  = 7
+	attributeFieldInts    = 8
+	attributeFieldStrings = 9
+	attributeFieldTensors = 10
+	attributeFieldType    = 20
+
+	attributeFieldSparseTensor  = 22
+	attributeFieldSparseTensors = 23
+
+	tensorFieldDims         = 1
+	tensorFieldDataType     = 2
+	tensorFieldStringData   = 6
+	tensorFieldDataLocation = 14
+
+	dataLocationExternal = 1
+)
+
+// maxAttributeValueLen bounds string values in attribute summaries.
+const maxAttributeValueLen = 64
+
+type AttributeSummary struct {
+	Name  string `json:"name"`
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+type NodeInfo struct {
+	Name       string             `json:"name,omitempty"`
+	OpType     string             `json:"op_type"`
+	Domain     string             `json:"domain"`
+	Inputs     []string           `json:"inputs"`
+	Outputs    []string           `json:"outputs"`
+	Attributes []AttributeSummary `json:"attributes,omitempty"`
+}
+
+type InitializerInfo struct {
+	Name     string    `json:"name"`
+	Dtype    ONNXDtype `json:"dtype"`
+	Shape    []int64   `json:"shape"`
+	Elements int64     `json:"elements"`
+	Bytes    int64     `json:"bytes"`
+	External bool      `json:"external,omitempty"`
+	Location string    `json:"location,omitempty"`
+}
+
+// GraphSummary describes the top-level graph of a model. Subgraphs of
+// If/Loop/Scan nodes are summarised in the owning node's attributes.
+type GraphSummary struct {
+	Name           string            `json:"name,omitempty"`
+	NodeCount      int               `json:"node_count"`
+	Nodes          []NodeInfo        `json:"nodes"`
+	Initializers   []InitializerInfo `json:"initializers"`
+	ParameterCount int64             `json:"parameter_count"`
+	ParameterBytes int64             `json:"parameter_bytes"`
+}
+
+// ExtractGraph parses an ONNX file and summarises its nodes and
+// initializers.
+func ExtractGraph(modelPath string) (*GraphSummary, error) {
+	data, err := readModel(modelPath)
+	if err != nil {
+		return nil, err
+	}
+	return extractGraph(data)
+}
+
+// ExtractGraphData is ExtractGraph for a model held in memory.
+func ExtractGraphData(model []byte) (*GraphSummary, error) {
+	data, err := readModelFrom(bytes.NewReader(model), int64(len(model)))
+	if err != nil {
+		return nil, err
+	}
+	return extractGraph(data)
+}
+
+func extractGraph(data []byte) (*GraphSummary, error) {
+	graphBytes, err := extractField(data, modelFieldGraph)
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract graph from model: %w", err)
+	}
+
+	return parseGraphSummary(graphBytes)
+}
+
+func parseGraphSummary(graphData []byte) (*GraphSummary, error) {
+	summary := &GraphSummary{
+		Nodes:        []NodeInfo{},
+		Initializers: []InitializerInfo{},
+	}
+
+	if name, err := extractStringField(graphData, graphFieldName); err == nil {
+		summary.Name = name
+	}
+
+	err := forEachBytesField(graphData, graphFieldNode, func(node []byte) error {
+		info, err := parseNodeInfo(node)
+		if err != nil {
+			return err
+		}
+		summary.Nodes = append(summary.Nodes, info)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse nodes: %w", err)
+	}
+	summary.NodeCount = len(summary.Nodes)
+
+	err = forEachBytesField(graphData, graphFieldInitializer, func(tensor []byte) error {
+		info, err := parseInitializerInfo(tensor)
+		if err != nil {
+			return err
+		}
+		summary.Initializers = append(summary.Initializers, info)
+		summary.ParameterCount += info.Elements
+		summary.ParameterBytes += info.Bytes
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse initializers: %w", err)
+	}
+
+	return summary, nil
+}
+
+func parseNodeInfo(data []byte) (NodeInfo, error) {
+	node := NodeInfo{Inputs: []string{}, Outputs: []string{}}
+
+	opType, err := extractStringField(data, nodeFieldOpType)
+	if err != nil {
+		return node, fmt.Errorf("node without op_type: %w", err)
+	}
+	node.OpType = opType
+
+	if name, err := extractStringField(data, nodeFieldName); err == nil {
+		node.Name = name
+	}
+	if domain, err := extractStringField(data, nodeFieldDomain); err == nil {
+		node.Domain = domain
+	}
+	node.Domain = displayDomain(canonicalDomain(node.Domain))
+
+	err = forEachBytesField(data, nodeFieldInput, func(v []byte) error {
+		node.Inputs = append(node.Inputs, string(v))
+		return nil
+	})
+	if err != nil {
+		return node, err
+	}
+
+	err = forEachBytesField(data, nodeFieldOutput, func(v []byte) error {
+		node.Outputs = append(node.Outputs, string(v))
+		return nil
+	})
+	if err != nil {
+		return node, err
+	}
+
+	err = forEachBytesField(data, nodeFieldAttribute, func(v []byte) error {
+		attr, err := summarizeAttribute(v)
+		if err != nil {
+			return err
+		}
+		node.Attributes = append(node.Attributes, attr)
+		return nil
+	})
+
+	return node, err
+}
+
+var attributeTypeNames = map[uint64]string{
+	1: "float", 2: "int", 3: "string", 4: "tensor", 5: "graph",
+	6: "floats", 7: "ints", 8: "strings", 9: "tensors", 10: "graphs",
+	11: "sparse_tensor", 12: "sparse_tensors", 13: "type_proto", 14: "type_protos",
+}
+
+// summarizeAttribute renders an AttributeProto as a short, human-readable
+// value. Tensors and graphs are described, never dumped.
+func summarizeAttribute(data []byte) (AttributeSummary, error) {
+	var attr AttributeSummary
+
+	name, err := extractStringField(data, attributeFieldName)
+	if err != nil {
+		return attr, fmt.Errorf("attribute without name: %w", err)
+	}
+	attr.Name = name
+
+	typ, err := extractVarintField(data, attributeFieldType)
+	if err != nil {
+		typ = 0
+	}
+	attr.Type = attributeTypeNames[typ]
+	if attr.Type == "" {
+		attr.Type = "undefined"
+	}
+
+	switch typ {
+	case 1:
+		if bits, err := extractFixed32Field(data, attributeFieldFloat); err == nil {
+			attr.Value = strconv.FormatFloat(float64(math.Float32frombits(bits)), 'g', -1, 32)
+		} else {
+			attr.Value = "0"
+		}
+	case 2:
+		v, err := extractVarintField(data, attributeFieldInt)
+		if err != nil {
+			v = 0
+		}
+		attr.Value = strconv.FormatInt(int64(v), 10)
+	case 3:
+		s, _ := extractStringField(data, attributeFieldString)
+		attr.Value = truncate(s)
+	case 4:
+		if t, err := extractField(data, attributeFieldTensor); err == nil {
+			info, err := parseInitializerInfo(t)
+			if err == nil {
+				attr.Value = fmt.Sprintf("tensor(%s)%v", info.Dtype, info.Shape)
+			}
+		}
+	case 5:
+		if g, err := extractField(data, attributeFieldGraph); err == nil {
+			attr.Value = fmt.Sprintf("graph(%d nodes)", countBytesFields(g, graphFieldNode))
+		}
+	case 6:
+		attr.Value = fmt.Sprintf("[%d floats]", countRepeatedFixed32(data, attributeFieldFloats))
+	case 7:
+		attr.Value = formatInts(repeatedVarints(data, attributeFieldInts))
+	case 8:
+		var items []string
+		forEachBytesField(data, attributeFieldStrings, func(v []byte) error {
+			items = append(items, string(v))
+			return nil
+		})
+		attr.Value = truncate("[" + strings.Join(items, ", ") + "]")
+	case 9:
+		attr.Value = fmt.Sprintf("[%d tensors]", countBytesFields(data, attributeFieldTensors))
+	case 10:
+		attr.Value = fmt.Sprintf("[%d graphs]", countBytesFields(data, attributeFieldGraphs))
+	}
+
+	return attr, nil
+}
+
+// parseInitializerInfo reads the name, dtype and shape of a TensorProto and
+// derives its element count and byte size without decoding the payload.
+func parseInitializerInfo(data []byte) (InitializerInfo, error) {
+	var info InitializerInfo
+
+	if name, err := extractStringField(data, initializerFieldName); err == nil {
+		info.Name = name
+	}
+
+	if dtype, err := extractVarintField(data, tensorFieldDataType); err == nil {
+		info.Dtype = ONNXDtype(dtype)
+	}
+
+	info.Shape = repeatedVarints(data, tensorFieldDims)
+	if info.Shape == nil {
+		info.Shape = []int64{}
+	}
+
+	info.Elements = 1
+	for _, d := range info.Shape {
+		info.Elements *= d
+	}
+
+	if loc, err := extractVarintField(data, tensorFieldDataLocation); err == nil && loc == dataLocationExternal {
+		info.External = true
+		if ref, err := parseExternalRef(data); err == nil {
+			info.Location = ref.Location
+		}
+	}
+
+	if size := dtypeSize(info.Dtype); size > 0 {
+		info.Bytes = info.Elements * size
+	} else {
+		forEachBytesField(data, tensorFieldStringData, func(v []byte) error {
+			info.Bytes += int64(len(v))
+			return nil
+		})
+	}
+
+	return info, nil
+}
+
+// dtypeSize returns the element size in bytes, or 0 for variable-size
+// types such as strings.
+func dtypeSize(d ONNXDtype) int64 {
+	switch d {
+	case DtypeUint8, DtypeInt8, DtypeBool:
+		return 1
+	case DtypeUint16, DtypeInt16, DtypeFloat16, DtypeBfloat16:
+		return 2
+	case DtypeFloat, DtypeInt32, DtypeUint32:
+		return 4
+	case DtypeDouble, DtypeInt64, DtypeUint64, DtypeComplex64:
+		return 8
+	case DtypeComplex128:
+		return 16
+	}
+	return 0
+}
+
+// repeatedVarints reads a repeated int64 field in either packed or
+// unpacked encoding.
+func repeatedVarints(data []byte, targetField protowire.Number) []int64 {
+	var out []int64
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return out
+		}
+		data = data[n:]
+
+		switch {
+		case num == targetField && typ == protowire.VarintType:
+			v, n := protowire.ConsumeVarint(data)
+			if n < 0 {
+				return out
+			}
+			out = append(out, int64(v))
+			data = data[n:]
+		case num == targetField && typ == protowire.BytesType:
+			packed, n := protowire.ConsumeBytes(data)
+			if n < 0 {
+				return out
+			}
+			for len(packed) > 0 {
+				v, m := protowire.ConsumeVarint(packed)
+				if m < 0 {
+					break
+				}
+				out = append(out, int64(v))
+				packed = packed[m:]
+			}
+			data = data[n:]
+		default:
+			n := protowire.ConsumeFieldValue(num, typ, data)
+			if n < 0 {
+				return out
+			}
+			data = data[n:]
+		}
+	}
+	return out
+}
+
+// countRepeatedFixed32 counts the values of a repeated float field in
+// either packed or unpacked encoding.
+func countRepeatedFixed32(data []byte, targetField protowire.Number) int {
+	count := 0
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return count
+		}
+		data = data[n:]
+
+		if num == targetField && typ == protowire.BytesType {
+			packed, m := protowire.ConsumeBytes(data)
+			if m < 0 {
+				return count
+			}
+			count += len(packed) / 4
+			n = m
+		} else {
+			if num == targetField && typ == protowire.Fixed32Type {
+				count++
+			}
+			n = protowire.ConsumeFieldValue(num, typ, data)
+			if n < 0 {
+				return count
+			}
+		}
+		data = data[n:]
+	}
+	return count
+}
+
+func countBytesFields(data []byte, targetField protowire.Number) int {
+	count := 0
+	forEachBytesField(data, targetField, func([]byte) error {
+		count++
+		return nil
+	})
+	return count
+}
+
+// extractFixed32Field finds a fixed32 field (e.g. a float) by number.
+func extractFixed32Field(data []byte, targetField protowire.Number) (uint32, error) {
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return 0, fmt.Errorf("invalid tag")
+		}
+		data = data[n:]
+
+		if typ == protowire.Fixed32Type {
+			if len(data) < 4 {
+				return 0, fmt.Errorf("invalid fixed32")
+			}
+			if num == targetField {
+				return binary.LittleEndian.Uint32(data), nil
+			}
+			data = data[4:]
+		} else {
+			n := protowire.ConsumeFieldValue(num, typ, data)
+			if n < 0 {
+				return 0, fmt.Errorf("invalid field")
+			}
+			data = data[n:]
+		}
+	}
+	return 0, fmt.Errorf("field %d not found", targetField)
+}
+
+// maxListItems bounds the number of values shown for ints attributes.
+const maxListItems = 8
+
+func formatInts(ints []int64) string {
+	parts := make([]string, 0, min(len(ints), maxListItems))
+	for _, v := range ints[:min(len(ints), maxListItems)] {
+		parts = append(parts, strconv.FormatInt(v, 10))
+	}
+	if len(ints) > maxListItems {
+		return fmt.Sprintf("[%s, …] (%d ints)", strings.Join(parts, ", "), len(ints))
+	}
+	return "[" + strings.Join(parts, ", ") + "]"
+}
+
+func truncate(s string) string {
+	if len(s) <= maxAttributeValueLen {
+		return s
+	}
+	return s[:maxAttributeValueLen] + "…"
+}
@@ -0,0 +1,214 @@
+package onnx
+
+import (
+	"fmt"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+type LintSeverity string
+
+const (
+	LintWarning LintSeverity = "warning"
+	LintError   LintSeverity = "error"
+)
+
+// LintFinding is one structural problem found in a model graph.
+type LintFinding struct {
+	Severity LintSeverity `json:"severity"`
+	Check    string       `json:"check"`
+	Name     string       `json:"name,omitempty"`
+	Message  string       `json:"message"`
+}
+
+// maxLintFindings bounds the report for badly broken graphs.
+const maxLintFindings = 200
+
+type linter struct {
+	findings []LintFinding
+	omitted  int
+}
+
+func (l *linter) add(severity LintSeverity, check, name, format string, args ...any) {
+	if len(l.findings) >= maxLintFindings {
+		l.omitted++
+		return
+	}
+	l.findings = append(l.findings, LintFinding{
+		Severity: severity,
+		Check:    check,
+		Name:     name,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// lintGraph runs structural checks on the top-level graph. inputs are the
+// graph inputs that are not initializers, as reported in ModelInfo.
+func lintGraph(graphData []byte, inputs []TensorInfo) ([]LintFinding, error) {
+	l := &linter{}
+
+	// Names available to nodes: graph inputs, initializers, node outputs.
+	available := make(map[string]bool)
+
+	graphInputs, err := collectNames(graphData, graphFieldInput, valueInfoFieldName)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range duplicates(graphInputs) {
+		l.add(LintError, "duplicate_name", name, "graph input %q is declared more than once", name)
+	}
+	for _, name := range graphInputs {
+		available[name] = true
+	}
+
+	initializers, err := collectNames(graphData, graphFieldInitializer, initializerFieldName)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range duplicates(initializers) {
+		l.add(LintError, "duplicate_name", name, "initializer %q is declared more than once", name)
+	}
+	for _, name := range initializers {
+		available[name] = true
+	}
+
+	// Node outputs must be unique (the graph is in SSA form); node names
+	// are optional but should not repeat.
+	produced := make(map[string]bool)
+	nodeNames := make(map[string]bool)
+	consumed := make(map[string]bool)
+
+	err = forEachBytesField(graphData, graphFieldNode, func(node []byte) error {
+		info, err := parseNodeInfo(node)
+		if err != nil {
+			return err
+		}
+
+		label := info.Name
+		if label == "" {
+			label = info.OpType
+		}
+
+		for _, in := range info.Inputs {
+			if in == "" {
+				continue // omitted optional input
+			}
+			consumed[in] = true
+			if !available[in] {
+				l.add(LintError, "dangling_input", in, "node %s reads %q, which no graph input, initializer or earlier node provides", label, in)
+			}
+		}
+
+		for _, out := range info.Outputs {
+			if out == "" {
+				continue
+			}
+			if produced[out] {
+				l.add(LintError, "duplicate_name", out, "value %q is produced by more than one node", out)
+			}
+			produced[out] = true
+			available[out] = true
+		}
+
+		if info.Name != "" {
+			if nodeNames[info.Name] {
+				l.add(LintWarning, "duplicate_name", info.Name, "node name %q is used more than once", info.Name)
+			}
+			nodeNames[info.Name] = true
+		}
+
+		// Subgraphs may read outer-scope values, initializers included.
+		return forEachBytesField(node, nodeFieldAttribute, func(attr []byte) error {
+			return forEachSubgraph(attr, func(graph []byte) error {
+				return collectConsumed(graph, consumed)
+			})
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	outputs, err := collectNames(graphData, graphFieldOutput, valueInfoFieldName)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range duplicates(outputs) {
+		l.add(LintError, "duplicate_name", name, "graph output %q is declared more than once", name)
+	}
+	for _, name := range outputs {
+		consumed[name] = true
+		if !available[name] {
+			l.add(LintError, "unproduced_output", name, "graph output %q is not produced by any node", name)
+		}
+	}
+
+	for _, name := range initializers {
+		if !consumed[name] {
+			l.add(LintWarning, "unused_initializer", name, "initializer %q is never used", name)
+		}
+	}
+
+	for _, in := range inputs {
+		if in.Type != nil && in.Type.Kind == KindTensor && in.Shape == nil {
+			l.add(LintWarning, "missing_shape", in.Name, "input %q has no shape information, so requests cannot be validated before inference", in.Name)
+		}
+		if in.Dtype == DtypeDouble {
+			l.add(LintWarning, "float64_input", in.Name, "input %q is float64; most kernels are optimised for float32", in.Name)
+		}
+	}
+
+	if l.omitted > 0 {
+		l.findings = append(l.findings, LintFinding{
+			Severity: LintWarning,
+			Check:    "truncated",
+			Message:  fmt.Sprintf("%d more findings omitted", l.omitted),
+		})
+	}
+
+	return l.findings, nil
+}
+
+// collectNames returns the name field of every occurrence of a repeated
+// message field.
+func collectNames(data []byte, field, nameField protowire.Number) ([]string, error) {
+	var names []string
+	err := forEachBytesField(data, field, func(v []byte) error {
+		name, err := extractStringField(v, nameField)
+		if err == nil && name != "" {
+			names = append(names, name)
+		}
+		return nil
+	})
+	return names, err
+}
+
+// collectConsumed records every value read by the nodes of a graph and its
+// nested subgraphs.
+func collectConsumed(graphData []byte, consumed map[string]bool) error {
+	return forEachBytesField(graphData, graphFieldNode, func(node []byte) error {
+		err := forEachBytesField(node, nodeFieldInput, func(v []byte) error {
+			consumed[string(v)] = true
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		return forEachBytesField(node, nodeFieldAttribute, func(attr []byte) error {
+			return forEachSubgraph(attr, func(graph []byte) error {
+				return collectConsumed(graph, consumed)
+			})
+		})
+	})
+}
+
+func duplicates(names []string) []string {
+	seen := make(map[string]int, len(names))
+	var dups []string
+	for _, name := range names {
+		seen[name]++
+		if seen[name] == 2 {
+			dups = append(dups, name)
+		}
+	}
+	return dups
+}
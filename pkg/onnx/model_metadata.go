@@ -7,30 +7,154 @@ import (
 	"strings"
 )
 
+// Format identifies ONNX artifacts in the backend registry and in stored
+// model metadata.
+const Format = "onnx"
+
 type ONNXDtype int
 
 const (
-	DtypeFloat  ONNXDtype = 1
-	DtypeUint8  ONNXDtype = 2
-	DtypeInt8   ONNXDtype = 3
-	DtypeUint16 ONNXDtype = 4
-	DtypeInt16  ONNXDtype = 5
-	DtypeInt32  ONNXDtype = 6
-	DtypeInt64  ONNXDtype = 7
-	DtypeString ONNXDtype = 8
-	DtypeBool   ONNXDtype = 9
-	DtypeDouble ONNXDtype = 11
+	DtypeFloat      ONNXDtype = 1
+	DtypeUint8      ONNXDtype = 2
+	DtypeInt8       ONNXDtype = 3
+	DtypeUint16     ONNXDtype = 4
+	DtypeInt16      ONNXDtype = 5
+	DtypeInt32      ONNXDtype = 6
+	DtypeInt64      ONNXDtype = 7
+	DtypeString     ONNXDtype = 8
+	DtypeBool       ONNXDtype = 9
+	DtypeFloat16    ONNXDtype = 10
+	DtypeDouble     ONNXDtype = 11
+	DtypeUint32     ONNXDtype = 12
+	DtypeUint64     ONNXDtype = 13
+	DtypeComplex64  ONNXDtype = 14
+	DtypeComplex128 ONNXDtype = 15
+	DtypeBfloat16   ONNXDtype = 16
 )
 
+var dtypeNames = map[ONNXDtype]string{
+	DtypeFloat:      "float",
+	DtypeUint8:      "uint8",
+	DtypeInt8:       "int8",
+	DtypeUint16:     "uint16",
+	DtypeInt16:      "int16",
+	DtypeInt32:      "int32",
+	DtypeInt64:      "int64",
+	DtypeString:     "string",
+	DtypeBool:       "bool",
+	DtypeFloat16:    "float16",
+	DtypeDouble:     "double",
+	DtypeUint32:     "uint32",
+	DtypeUint64:     "uint64",
+	DtypeComplex64:  "complex64",
+	DtypeComplex128: "complex128",
+	DtypeBfloat16:   "bfloat16",
+}
+
+// String returns the ONNX name of the dtype, e.g. "float" or "int64".
+func (d ONNXDtype) String() string {
+	if name, ok := dtypeNames[d]; ok {
+		return name
+	}
+	return fmt.Sprintf("dtype(%d)", int(d))
+}
+
+type TypeKind string
+
+const (
+	KindTensor       TypeKind = "tensor"
+	KindSparseTensor TypeKind = "sparse_tensor"
+	KindSequence     TypeKind = "sequence"
+	KindMap          TypeKind = "map"
+	KindOptional     TypeKind = "optional"
+)
+
+// TypeInfo is a recursive description of an ONNX TypeProto. Tensors carry
+// ElemType and Shape; sequences and optionals carry Elem; maps carry
+// KeyType and Elem (the value type).
+type TypeInfo struct {
+	Kind      TypeKind  `json:"kind"`
+	ElemType  ONNXDtype `json:"elem_type,omitempty"`
+	Shape     []int64   `json:"shape,omitempty"`
+	DimParams []string  `json:"dim_params,omitempty"`
+	KeyType   ONNXDtype `json:"key_type,omitempty"`
+	Elem      *TypeInfo `json:"elem,omitempty"`
+}
+
+// String renders the type compactly, e.g. "tensor(float)" or
+// "seq(map(int64, float))". Tensors nested in containers are written as
+// their element type alone.
+func (t *TypeInfo) String() string {
+	return t.format(true)
+}
+
+func (t *TypeInfo) format(top bool) string {
+	if t == nil {
+		return "unknown"
+	}
+
+	switch t.Kind {
+	case KindTensor:
+		if top {
+			return fmt.Sprintf("tensor(%s)", t.ElemType)
+		}
+		return t.ElemType.String()
+	case KindSparseTensor:
+		return fmt.Sprintf("sparse_tensor(%s)", t.ElemType)
+	case KindSequence:
+		return fmt.Sprintf("seq(%s)", t.Elem.format(false))
+	case KindOptional:
+		return fmt.Sprintf("optional(%s)", t.Elem.format(false))
+	case KindMap:
+		return fmt.Sprintf("map(%s, %s)", t.KeyType, t.Elem.format(false))
+	}
+	return string(t.Kind)
+}
+
 type TensorInfo struct {
 	Name  string    `json:"name"`
 	Shape []int64   `json:"shape"`
 	Dtype ONNXDtype `json:"dtype"`
+	// DimParams holds the symbolic name of each axis in Shape ("" for
+	// concrete axes). Axes sharing a name must have the same length.
+	DimParams []string `json:"dim_params,omitempty"`
+	// Type describes the value recursively, including sequence, map,
+	// optional and sparse types that leave Dtype at 0.
+	Type       *TypeInfo `json:"type,omitempty"`
+	TypeString string    `json:"type_string,omitempty"`
+}
+
+// OpsetImport is one operator set the model depends on. An empty Domain is
+// the default "ai.onnx" operator set.
+type OpsetImport struct {
+	Domain  string `json:"domain"`
+	Version int64  `json:"version"`
+}
+
+// ProtoMetadata holds the ModelProto fields outside the graph, plus class
+// labels and feature names derived from well-known metadata_props keys.
+type ProtoMetadata struct {
+	IRVersion       int64             `json:"ir_version,omitempty"`
+	OpsetImports    []OpsetImport     `json:"opset_import,omitempty"`
+	ProducerName    string            `json:"producer_name,omitempty"`
+	ProducerVersion string            `json:"producer_version,omitempty"`
+	Domain          string            `json:"domain,omitempty"`
+	ModelVersion    int64             `json:"model_version,omitempty"`
+	DocString       string            `json:"doc_string,omitempty"`
+	MetadataProps   map[string]string `json:"metadata_props,omitempty"`
+	ClassLabels     []string          `json:"class_labels,omitempty"`
+	FeatureNames    []string          `json:"feature_names,omitempty"`
 }
 
 type ModelInfo struct {
-	Inputs  []TensorInfo `json:"inputs"`
-	Outputs []TensorInfo `json:"outputs"`
+	Format string `json:"format,omitempty"`
+	ProtoMetadata
+	Inputs    []TensorInfo    `json:"inputs"`
+	Outputs   []TensorInfo    `json:"outputs"`
+	Operators *OperatorReport `json:"operators,omitempty"`
+
+	ExternalData []ExternalDataFile `json:"external_data,omitempty"`
+	Lint         []LintFinding      `json:"lint,omitempty"`
 }
 
 func LoadModelInfo(modelPath string) (*ModelInfo, error) {
@@ -40,7 +164,11 @@ func LoadModelInfo(modelPath string) (*ModelInfo, error) {
 	if err != nil {
 		return nil, fmt.Errorf("model info sidecar not found at %s: %w", infoPath, err)
 	}
+	return ParseModelInfo(data)
+}
 
+// ParseModelInfo parses the content of a model info sidecar.
+func ParseModelInfo(data []byte) (*ModelInfo, error) {
 	var info ModelInfo
 	if err := json.Unmarshal(data, &info); err != nil {
 		return nil, fmt.Errorf("failed to parse model info: %w", err)
@@ -56,6 +184,52 @@ func LoadModelInfo(modelPath string) (*ModelInfo, error) {
 	return &info, nil
 }
 
+// Metadata property keys recognised as class labels and feature names, in
+// order of preference. Values may be a JSON array or a comma-separated list.
+var (
+	classLabelKeys  = []string{"class_labels", "classes", "labels"}
+	featureNameKeys = []string{"feature_names", "features", "input_features"}
+)
+
+func labelsFromProps(props map[string]string, keys []string) []string {
+	for _, key := range keys {
+		value, ok := props[key]
+		if !ok || strings.TrimSpace(value) == "" {
+			continue
+		}
+		return parseList(value)
+	}
+	return nil
+}
+
+func parseList(value string) []string {
+	value = strings.TrimSpace(value)
+
+	if strings.HasPrefix(value, "[") {
+		var items []any
+		if err := json.Unmarshal([]byte(value), &items); err == nil {
+			out := make([]string, len(items))
+			for i, item := range items {
+				out[i] = fmt.Sprint(item)
+			}
+			return out
+		}
+	}
+
+	parts := strings.Split(value, ",")
+	out := make([]string, len(parts))
+	for i, part := range parts {
+		out[i] = strings.TrimSpace(part)
+	}
+	return out
+}
+
+// SetLabels replaces the class labels and feature names the model is served
+// with.
+func (m *ModelInfo) SetLabels(classLabels, featureNames []string) {
+	m.ClassLabels, m.FeatureNames = classLabels, featureNames
+}
+
 func (m *ModelInfo) InputSize() int {
 	shape := m.Inputs[0].Shape
 	size := int64(1)
@@ -2,7 +2,7 @@ package onnx
 
 import (
 	"fmt"
-	"os"
+	"path/filepath"
 
 	"google.golang.org/protobuf/encoding/protowire"
 )
@@ -34,49 +34,101 @@ import (
 // https://github.com/onnx/onnx/blob/main/onnx/onnx.proto
 //
 // ModelProto:
-//   field 7 = graph (GraphProto)
+//   field 1  = ir_version       (int64)
+//   field 2  = producer_name    (string)
+//   field 3  = producer_version (string)
+//   field 4  = domain           (string)
+//   field 5  = model_version    (int64)
+//   field 6  = doc_string       (string)
+//   field 7  = graph            (GraphProto)
+//   field 8  = opset_import     (repeated OperatorSetIdProto)
+//   field 14 = metadata_props   (repeated StringStringEntryProto)
+//
+// OperatorSetIdProto:
+//   field 1 = domain  (string)
+//   field 2 = version (int64)
+//
+// StringStringEntryProto:
+//   field 1 = key   (string)
+//   field 2 = value (string)
 //
 // GraphProto:
-//   field 11 = input  (repeated ValueInfoProto)
-//   field 12 = output (repeated ValueInfoProto)
-//   field 14 = initializer (repeated TensorProto) — used to filter inputs
+//   field 5  = initializer (repeated TensorProto) — used to filter inputs
+//   field 11 = input       (repeated ValueInfoProto)
+//   field 12 = output      (repeated ValueInfoProto)
 //
 // ValueInfoProto:
 //   field 1 = name   (string)
 //   field 2 = type   (TypeProto)
 //
 // TypeProto:
-//   field 1 = tensor_type (TypeProto_Tensor)
+//   field 1 = tensor_type        (TypeProto_Tensor)
+//   field 4 = sequence_type      (TypeProto_Sequence)
+//   field 5 = map_type           (TypeProto_Map)
+//   field 8 = sparse_tensor_type (TypeProto_SparseTensor)
+//   field 9 = optional_type      (TypeProto_Optional)
 //
-// TypeProto_Tensor:
+// TypeProto_Tensor / TypeProto_SparseTensor:
 //   field 1 = elem_type (int32)  — the dtype
 //   field 2 = shape     (TensorShapeProto)
 //
+// TypeProto_Sequence / TypeProto_Optional:
+//   field 1 = elem_type (TypeProto)
+//
+// TypeProto_Map:
+//   field 1 = key_type   (int32)
+//   field 2 = value_type (TypeProto)
+//
 // TensorShapeProto:
 //   field 1 = dim (repeated TensorShapeProto_Dimension)
 //
 // TensorShapeProto_Dimension:
 //   field 1 = dim_value (int64)
+//   field 2 = dim_param (string) — symbolic name, e.g. "batch_size"
 //
 // TensorProto (initializer):
 //   field 8 = name (string)
 
 const (
-	modelFieldGraph       = 7
+	modelFieldIRVersion       = 1
+	modelFieldProducerName    = 2
+	modelFieldProducerVersion = 3
+	modelFieldDomain          = 4
+	modelFieldModelVersion    = 5
+	modelFieldDocString       = 6
+	modelFieldGraph           = 7
+	modelFieldOpsetImport     = 8
+	modelFieldMetadataProps   = 14
+
+	opsetFieldDomain  = 1
+	opsetFieldVersion = 2
+
+	entryFieldKey   = 1
+	entryFieldValue = 2
+
 	graphFieldInput       = 11
 	graphFieldOutput      = 12
-	graphFieldInitializer = 14
+	graphFieldInitializer = 5
 
 	valueInfoFieldName = 1
 	valueInfoFieldType = 2
 
-	typeProtoFieldTensor = 1
+	typeProtoFieldTensor       = 1
+	typeProtoFieldSequence     = 4
+	typeProtoFieldMap          = 5
+	typeProtoFieldSparseTensor = 8
+	typeProtoFieldOptional     = 9
+
+	containerFieldElemType = 1
+	mapFieldKeyType        = 1
+	mapFieldValueType      = 2
 
 	tensorTypeFieldElemType = 1
 	tensorTypeFieldShape    = 2
 
 	shapeFieldDim    = 1
 	dimFieldDimValue = 1
+	dimFieldDimParam = 2
 
 	initializerFieldName = 8
 )
@@ -84,9 +136,14 @@ const (
 // ExtractModelInfo parses an ONNX file and returns ModelInfo without
 // any Python or external dependency. It uses raw protowire decoding.
 func ExtractModelInfo(modelPath string) (*ModelInfo, error) {
-	data, err := os.ReadFile(modelPath)
+	data, err := readModel(modelPath)
+	if err != nil {
+		return nil, err
+	}
+
+	meta, err := extractProtoMetadata(data)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read model file: %w", err)
+		return nil, fmt.Errorf("failed to extract model metadata: %w", err)
 	}
 
 	graphBytes, err := extractField(data, modelFieldGraph)
@@ -100,6 +157,12 @@ func ExtractModelInfo(modelPath string) (*ModelInfo, error) {
 		return nil, fmt.Errorf("failed to extract initializer names: %w", err)
 	}
 
+	// Weights saved with external data must sit next to the model file
+	external, err := resolveExternalData(graphBytes, filepath.Dir(modelPath))
+	if err != nil {
+		return nil, err
+	}
+
 	inputs, err := extractValueInfos(graphBytes, graphFieldInput, initNames)
 	if err != nil {
 		return nil, fmt.Errorf("failed to extract inputs: %w", err)
@@ -117,12 +180,144 @@ func ExtractModelInfo(modelPath string) (*ModelInfo, error) {
 		return nil, fmt.Errorf("model has no outputs")
 	}
 
+	operators, err := buildOperatorReport(data, graphBytes, meta.OpsetImports)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build operator report: %w", err)
+	}
+
+	lint, err := lintGraph(graphBytes, inputs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lint graph: %w", err)
+	}
+
 	return &ModelInfo{
-		Inputs:  inputs,
-		Outputs: outputs,
+		ProtoMetadata: meta,
+		Inputs:        inputs,
+		Outputs:       outputs,
+		Operators:     operators,
+		ExternalData:  external,
+		Lint:          lint,
 	}, nil
 }
 
+// extractProtoMetadata walks the top level of a ModelProto once and collects
+// everything except the graph.
+func extractProtoMetadata(data []byte) (ProtoMetadata, error) {
+	var meta ProtoMetadata
+
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return meta, fmt.Errorf("invalid protobuf tag")
+		}
+		data = data[n:]
+
+		switch {
+		case typ == protowire.VarintType:
+			val, n := protowire.ConsumeVarint(data)
+			if n < 0 {
+				return meta, fmt.Errorf("invalid varint")
+			}
+			switch num {
+			case modelFieldIRVersion:
+				meta.IRVersion = int64(val)
+			case modelFieldModelVersion:
+				meta.ModelVersion = int64(val)
+			}
+			data = data[n:]
+
+		case typ == protowire.BytesType:
+			val, n := protowire.ConsumeBytes(data)
+			if n < 0 {
+				return meta, fmt.Errorf("invalid bytes field")
+			}
+			switch num {
+			case modelFieldProducerName:
+				meta.ProducerName = string(val)
+			case modelFieldProducerVersion:
+				meta.ProducerVersion = string(val)
+			case modelFieldDomain:
+				meta.Domain = string(val)
+			case modelFieldDocString:
+				meta.DocString = string(val)
+			case modelFieldOpsetImport:
+				opset, err := parseOpsetImport(val)
+				if err != nil {
+					return meta, err
+				}
+				meta.OpsetImports = append(meta.OpsetImports, opset)
+			case modelFieldMetadataProps:
+				key, value, err := parseStringEntry(val)
+				if err != nil {
+					return meta, err
+				}
+				if meta.MetadataProps == nil {
+					meta.MetadataProps = make(map[string]string)
+				}
+				meta.MetadataProps[key] = value
+			}
+			data = data[n:]
+
+		default:
+			n := protowire.ConsumeFieldValue(num, typ, data)
+			if n < 0 {
+				return meta, fmt.Errorf("invalid field value")
+			}
+			data = data[n:]
+		}
+	}
+
+	meta.ClassLabels = labelsFromProps(meta.MetadataProps, classLabelKeys)
+	meta.FeatureNames = labelsFromProps(meta.MetadataProps, featureNameKeys)
+
+	return meta, nil
+}
+
+// parseOpsetImport decodes an OperatorSetIdProto. An empty domain means the
+// default "ai.onnx" operator set.
+func parseOpsetImport(data []byte) (OpsetImport, error) {
+	var opset OpsetImport
+
+	domain, err := extractStringField(data, opsetFieldDomain)
+	if err == nil {
+		opset.Domain = domain
+	}
+
+	version, err := extractVarintField(data, opsetFieldVersion)
+	if err != nil {
+		return opset, fmt.Errorf("opset import without version: %w", err)
+	}
+	opset.Version = int64(version)
+
+	return opset, nil
+}
+
+// parseStringEntry decodes a StringStringEntryProto.
+func parseStringEntry(data []byte) (string, string, error) {
+	key, err := extractStringField(data, entryFieldKey)
+	if err != nil {
+		return "", "", fmt.Errorf("metadata property without key: %w", err)
+	}
+
+	value, err := extractStringField(data, entryFieldValue)
+	if err != nil {
+		value = "" // proto3 omits empty strings
+	}
+
+	return key, value, nil
+}
+
+// LooksLikeModel reports whether header plausibly starts a serialized
+// ModelProto. Every ONNX exporter writes ir_version first, so a varint tag
+// for field 1 is a cheap and reliable signature.
+func LooksLikeModel(header []byte) bool {
+	num, typ, n := protowire.ConsumeTag(header)
+	if n < 0 {
+		return false
+	}
+	return num == modelFieldIRVersion && typ == protowire.VarintType
+}
+
 // extractField finds the first occurrence of a length-delimited field
 // with the given field number and returns its bytes.
 func extractField(data []byte, targetField protowire.Number) ([]byte, error) {
@@ -249,7 +444,9 @@ func extractValueInfos(graphData []byte, fieldNum protowire.Number, excludeNames
 	return results, nil
 }
 
-// parseValueInfo decodes a ValueInfoProto into a TensorInfo.
+// parseValueInfo decodes a ValueInfoProto into a TensorInfo. Dtype and
+// Shape are only filled for plain tensors, which are the only values the
+// predictor can bind; Type describes every kind recursively.
 func parseValueInfo(data []byte) (TensorInfo, error) {
 	var info TensorInfo
 
@@ -265,42 +462,113 @@ func parseValueInfo(data []byte) (TensorInfo, error) {
 		return info, nil
 	}
 
-	tensorTypeBytes, err := extractField(typeBytes, typeProtoFieldTensor)
-	if err != nil {
+	typ, err := parseTypeProto(typeBytes)
+	if err != nil || typ == nil {
 		return info, nil
 	}
+	info.Type = typ
+	info.TypeString = typ.String()
+
+	if typ.Kind == KindTensor {
+		info.Dtype = typ.ElemType
+		info.Shape = typ.Shape
+		info.DimParams = typ.DimParams
+	}
+
+	return info, nil
+}
+
+// parseTypeProto decodes a TypeProto recursively. It returns nil for type
+// kinds the parser does not know about.
+func parseTypeProto(data []byte) (*TypeInfo, error) {
+	if val, err := extractField(data, typeProtoFieldTensor); err == nil {
+		return parseTensorType(val, KindTensor)
+	}
+
+	if val, err := extractField(data, typeProtoFieldSparseTensor); err == nil {
+		return parseTensorType(val, KindSparseTensor)
+	}
+
+	if val, err := extractField(data, typeProtoFieldSequence); err == nil {
+		elem, err := parseElemType(val, containerFieldElemType)
+		if err != nil {
+			return nil, err
+		}
+		return &TypeInfo{Kind: KindSequence, Elem: elem}, nil
+	}
+
+	if val, err := extractField(data, typeProtoFieldOptional); err == nil {
+		elem, err := parseElemType(val, containerFieldElemType)
+		if err != nil {
+			return nil, err
+		}
+		return &TypeInfo{Kind: KindOptional, Elem: elem}, nil
+	}
+
+	if val, err := extractField(data, typeProtoFieldMap); err == nil {
+		key, err := extractVarintField(val, mapFieldKeyType)
+		if err != nil {
+			return nil, fmt.Errorf("map type without key type: %w", err)
+		}
+		elem, err := parseElemType(val, mapFieldValueType)
+		if err != nil {
+			return nil, err
+		}
+		return &TypeInfo{Kind: KindMap, KeyType: ONNXDtype(key), Elem: elem}, nil
+	}
+
+	return nil, nil
+}
+
+func parseTensorType(data []byte, kind TypeKind) (*TypeInfo, error) {
+	typ := &TypeInfo{Kind: kind}
 
-	dtype, err := extractVarintField(tensorTypeBytes, tensorTypeFieldElemType)
+	dtype, err := extractVarintField(data, tensorTypeFieldElemType)
 	if err == nil {
-		info.Dtype = ONNXDtype(dtype)
+		typ.ElemType = ONNXDtype(dtype)
 	}
 
-	shapeBytes, err := extractField(tensorTypeBytes, tensorTypeFieldShape)
+	shapeBytes, err := extractField(data, tensorTypeFieldShape)
 	if err == nil {
-		dims, err := extractDims(shapeBytes)
+		dims, params, err := extractDims(shapeBytes)
 		if err == nil {
-			info.Shape = dims
+			typ.Shape = dims
+			typ.DimParams = params
 		}
 	}
 
-	return info, nil
+	return typ, nil
 }
 
-// extractDims reads all dimension values from a TensorShapeProto.
-func extractDims(shapeData []byte) ([]int64, error) {
-	var dims []int64
+// parseElemType decodes the nested TypeProto stored in a container field.
+func parseElemType(data []byte, field protowire.Number) (*TypeInfo, error) {
+	val, err := extractField(data, field)
+	if err != nil {
+		return nil, fmt.Errorf("container type without element type: %w", err)
+	}
+	return parseTypeProto(val)
+}
+
+// extractDims reads all dimensions from a TensorShapeProto. Symbolic dims
+// keep the 0 placeholder in dims and carry their name in params; params is
+// nil when every dim is concrete. Scalars yield an empty, non-nil dims so
+// they can be told apart from tensors without shape information.
+func extractDims(shapeData []byte) ([]int64, []string, error) {
+	dims := []int64{}
+	var params []string
+	symbolic := false
 
 	for len(shapeData) > 0 {
 		num, typ, n := protowire.ConsumeTag(shapeData)
 		if n < 0 {
-			return nil, fmt.Errorf("invalid tag in shape")
+			return nil, nil, fmt.Errorf("invalid tag in shape")
 		}
 		shapeData = shapeData[n:]
 
 		if typ == protowire.BytesType {
 			val, n := protowire.ConsumeBytes(shapeData)
 			if n < 0 {
-				return nil, fmt.Errorf("invalid dim bytes")
+				return nil, nil, fmt.Errorf("invalid dim bytes")
 			}
 			if num == shapeFieldDim {
 				// dim_value is field 1, a varint; if missing the dim is symbolic/dynamic → 0
@@ -308,19 +576,30 @@ func extractDims(shapeData []byte) ([]int64, error) {
 				if err != nil {
 					dimVal = 0 // dynamic dim (e.g. batch size)
 				}
+				param, err := extractStringField(val, dimFieldDimParam)
+				if err != nil {
+					param = ""
+				}
+				if param != "" {
+					symbolic = true
+				}
 				dims = append(dims, int64(dimVal))
+				params = append(params, param)
 			}
 			shapeData = shapeData[n:]
 		} else {
 			n := protowire.ConsumeFieldValue(num, typ, shapeData)
 			if n < 0 {
-				return nil, fmt.Errorf("invalid field value in shape")
+				return nil, nil, fmt.Errorf("invalid field value in shape")
 			}
 			shapeData = shapeData[n:]
 		}
 	}
 
-	return dims, nil
+	if !symbolic {
+		params = nil
+	}
+	return dims, params, nil
 }
 
 // extractStringField finds a string (bytes-type) field by number.
@@ -3,8 +3,10 @@ package onnx
 import (
 	"context"
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"math"
+	"slices"
 	"sync"
 
 	"github.com/kevo-1/model-nexus/internal/domain"
@@ -27,7 +29,50 @@ func onnxTypeToORT(d ONNXDtype) (ort.TensorElementDataType, int) {
 	}
 }
 
+// bindableDtype reports whether the predictor can exchange tensors of d.
+func bindableDtype(d ONNXDtype) bool {
+	switch d {
+	case DtypeFloat, DtypeDouble, DtypeInt64, DtypeInt32:
+		return true
+	}
+	return false
+}
+
+// DtypeProblems lists inputs and outputs the predictor cannot bind. Outputs
+// that are not plain tensors are skipped at prediction time and are not
+// reported, as long as at least one tensor output remains.
+func (m *ModelInfo) DtypeProblems() []string {
+	var problems []string
+	for _, in := range m.Inputs {
+		if in.Type != nil && in.Type.Kind != KindTensor {
+			problems = append(problems, fmt.Sprintf("input %s is %s; only tensor inputs are supported", in.Name, in.TypeString))
+			continue
+		}
+		if !bindableDtype(in.Dtype) {
+			problems = append(problems, fmt.Sprintf("input %s has unsupported element type %s", in.Name, in.Dtype))
+		}
+	}
+
+	tensors := 0
+	for _, out := range m.Outputs {
+		if out.Dtype == 0 {
+			continue
+		}
+		tensors++
+		if !bindableDtype(out.Dtype) {
+			problems = append(problems, fmt.Sprintf("output %s has unsupported element type %s", out.Name, out.Dtype))
+		}
+	}
+	if tensors == 0 {
+		problems = append(problems, "model has no tensor outputs")
+	}
+	return problems
+}
+
 // ── ONNXPredictor ─────────────────────────────────────────────────
+
+var errClosed = errors.New("model was unloaded")
+
 type ONNXPredictor struct {
 	ID      string
 	Name    string
@@ -41,15 +86,46 @@ type ONNXPredictor struct {
 	inputElemSize int
 	outputTensors []*ort.CustomDataTensor
 	outputDtypes  []ONNXDtype
+	outputNames   []string
 	mu            sync.Mutex
+
+	// dynamic serves PredictTensors and batched rows; it is created on
+	// first use because most models are only ever called with a flat
+	// feature vector.
+	dynamic *ort.DynamicAdvancedSession
+
+	// data holds the model of predictors loaded from memory, which create
+	// their sessions from it instead of from Path.
+	data []byte
 }
 
 func NewONNXPredictor(id, name, version, path string) (*ONNXPredictor, error) {
+	return newONNXPredictor(id, name, version, path, nil, nil)
+}
+
+// NewONNXPredictorFromData creates a predictor from a model held in memory,
+// e.g. one decrypted from storage. info is the content of its model info
+// sidecar; when nil, the sidecar is read from next to path, which is also
+// reported by Metadata.
+func NewONNXPredictorFromData(id, name, version, path string, data, info []byte) (*ONNXPredictor, error) {
+	if len(data) == 0 {
+		return nil, fmt.Errorf("model data cannot be empty")
+	}
+	return newONNXPredictor(id, name, version, path, data, info)
+}
+
+func newONNXPredictor(id, name, version, path string, data, infoData []byte) (*ONNXPredictor, error) {
 	if id == "" || name == "" || path == "" {
 		return nil, fmt.Errorf("id, name, and path cannot be empty")
 	}
 
-	info, err := LoadModelInfo(path)
+	var info *ModelInfo
+	var err error
+	if infoData != nil {
+		info, err = ParseModelInfo(infoData)
+	} else {
+		info, err = LoadModelInfo(path)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to load model info for %s: %w", path, err)
 	}
@@ -155,14 +231,12 @@ func NewONNXPredictor(id, name, version, path string) (*ONNXPredictor, error) {
 		outputValues[i] = t
 	}
 
-	session, err := ort.NewAdvancedSession(
-		path,
-		info.InputNames(),
-		outputNames,
-		inputValues,
-		outputValues,
-		nil,
-	)
+	var session *ort.AdvancedSession
+	if data != nil {
+		session, err = ort.NewAdvancedSessionWithONNXData(data, info.InputNames(), outputNames, inputValues, outputValues, nil)
+	} else {
+		session, err = ort.NewAdvancedSession(path, info.InputNames(), outputNames, inputValues, outputValues, nil)
+	}
 	if err != nil {
 		inputTensor.Destroy()
 		cleanupTensors(outputTensors)
@@ -181,6 +255,8 @@ func NewONNXPredictor(id, name, version, path string) (*ONNXPredictor, error) {
 		inputElemSize: inputElemSize,
 		outputTensors: outputTensors,
 		outputDtypes:  outputDtypes,
+		outputNames:   outputNames,
+		data:          data,
 	}, nil
 }
 
@@ -199,6 +275,268 @@ func (p *ONNXPredictor) Predict(ctx context.Context, features []float64) ([]floa
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
+	outputs, err := p.run(features)
+	if err != nil {
+		return nil, err
+	}
+	return slices.Concat(outputs...), nil
+}
+
+// PredictOutputs is Predict with the values of each tensor output kept
+// apart, keyed by output name.
+func (p *ONNXPredictor) PredictOutputs(ctx context.Context, features []float64) (map[string][]float64, error) {
+	expectedSize := p.Info.InputSize()
+	if expectedSize > 0 && len(features) != expectedSize {
+		return nil, &domain.InvalidInputError{Expected: expectedSize, Got: len(features)}
+	}
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	outputs, err := p.run(features)
+	if err != nil {
+		return nil, err
+	}
+	named := make(map[string][]float64, len(outputs))
+	for i, values := range outputs {
+		named[p.outputNames[i]] = values
+	}
+	return named, nil
+}
+
+// OutputNames lists the tensor outputs in the order Predict concatenates
+// them; outputs that are not plain tensors are not returned.
+func (p *ONNXPredictor) OutputNames() []string {
+	return p.outputNames
+}
+
+// PredictBatch scores many rows while holding the session lock once, so a
+// sweep is not interleaved with (or starved by) other callers. Models with
+// a symbolic batch dimension score all rows in one run of the dynamic
+// session; the rest run once per row on the fixed-shape session.
+func (p *ONNXPredictor) PredictBatch(ctx context.Context, rows [][]float64) ([][]float64, error) {
+	expectedSize := p.Info.InputSize()
+	for _, features := range rows {
+		if expectedSize > 0 && len(features) != expectedSize {
+			return nil, &domain.InvalidInputError{Expected: expectedSize, Got: len(features)}
+		}
+	}
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(rows) > 1 && p.batchable() {
+		return p.runBatch(rows)
+	}
+
+	results := make([][]float64, len(rows))
+	for i, features := range rows {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		default:
+		}
+
+		outputs, err := p.run(features)
+		if err != nil {
+			return nil, err
+		}
+		results[i] = slices.Concat(outputs...)
+	}
+
+	return results, nil
+}
+
+// batchable reports whether rows can be stacked into one tensor: the model
+// has a single input whose first axis is symbolic and whose other axes are
+// fixed, and every tensor output has a symbolic first axis to split the
+// results by.
+func (p *ONNXPredictor) batchable() bool {
+	if len(p.Info.Inputs) != 1 {
+		return false
+	}
+	in := p.Info.Inputs[0]
+	if len(in.Shape) < 2 || in.Shape[0] > 0 || !bindableDtype(in.Dtype) {
+		return false
+	}
+	for _, d := range in.Shape[1:] {
+		if d <= 0 {
+			return false
+		}
+	}
+
+	for _, out := range p.Info.Outputs {
+		if out.Dtype == 0 {
+			continue // not served
+		}
+		if len(out.Shape) == 0 || out.Shape[0] > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// runBatch scores rows in one run of the dynamic session and splits every
+// output along its first axis. The caller must hold p.mu.
+func (p *ONNXPredictor) runBatch(rows [][]float64) ([][]float64, error) {
+	session, err := p.dynamicSession()
+	if err != nil {
+		return nil, err
+	}
+
+	in := p.Info.Inputs[0]
+	shape := ort.NewShape(append([]int64{int64(len(rows))}, in.Shape[1:]...)...)
+	input, err := newTypedTensor(shape, slices.Concat(rows...), in.Dtype)
+	if err != nil {
+		return nil, &domain.PredictionError{ModelID: p.ID, Cause: err}
+	}
+	defer input.Destroy()
+
+	outputValues := make([]ort.Value, len(p.outputNames))
+	defer func() {
+		for _, v := range outputValues {
+			if v != nil {
+				v.Destroy()
+			}
+		}
+	}()
+
+	if err := session.Run([]ort.Value{input}, outputValues); err != nil {
+		return nil, &domain.PredictionError{ModelID: p.ID, Cause: err}
+	}
+
+	results := make([][]float64, len(rows))
+	for i, v := range outputValues {
+		if got := v.GetShape(); len(got) == 0 || got[0] != int64(len(rows)) {
+			return nil, &domain.PredictionError{
+				ModelID: p.ID,
+				Cause:   fmt.Errorf("output %s has shape %v, expected %d rows", p.outputNames[i], got, len(rows)),
+			}
+		}
+		var values []float64
+		if err := appendTensorValues(v, &values); err != nil {
+			return nil, &domain.PredictionError{ModelID: p.ID, Cause: err}
+		}
+		per := len(values) / len(rows)
+		for r := range rows {
+			results[r] = append(results[r], values[r*per:(r+1)*per]...)
+		}
+	}
+	return results, nil
+}
+
+// PredictTensors runs the model with separately shaped named inputs. Shapes
+// are checked against the declared inputs first, so linked symbolic axes
+// (e.g. input_ids and attention_mask sharing seq_len) must agree.
+func (p *ONNXPredictor) PredictTensors(ctx context.Context, inputs []domain.NamedTensor) ([]float64, error) {
+	byName := make(map[string]domain.NamedTensor, len(inputs))
+	shapes := make(map[string][]int64, len(inputs))
+	for _, in := range inputs {
+		byName[in.Name] = in
+		shapes[in.Name] = in.Shape
+	}
+
+	if _, err := p.Info.BindDims(shapes); err != nil {
+		return nil, err
+	}
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	inputValues := make([]ort.Value, len(p.Info.Inputs))
+	defer func() {
+		for _, v := range inputValues {
+			if v != nil {
+				v.Destroy()
+			}
+		}
+	}()
+	for i, info := range p.Info.Inputs {
+		in := byName[info.Name]
+		v, err := newTypedTensor(ort.NewShape(in.Shape...), in.Data, info.Dtype)
+		if err != nil {
+			return nil, &domain.PredictionError{ModelID: p.ID, Cause: fmt.Errorf("input %s: %w", info.Name, err)}
+		}
+		inputValues[i] = v
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	session, err := p.dynamicSession()
+	if err != nil {
+		return nil, err
+	}
+
+	outputValues := make([]ort.Value, len(p.outputNames))
+	defer func() {
+		for _, v := range outputValues {
+			if v != nil {
+				v.Destroy()
+			}
+		}
+	}()
+
+	if err := session.Run(inputValues, outputValues); err != nil {
+		return nil, &domain.PredictionError{ModelID: p.ID, Cause: err}
+	}
+
+	var result []float64
+	for _, v := range outputValues {
+		if err := appendTensorValues(v, &result); err != nil {
+			return nil, &domain.PredictionError{ModelID: p.ID, Cause: err}
+		}
+	}
+
+	return result, nil
+}
+
+// dynamicSession returns the session that takes inputs of any shape,
+// creating it on first use. The caller must hold p.mu.
+func (p *ONNXPredictor) dynamicSession() (*ort.DynamicAdvancedSession, error) {
+	if p.session == nil {
+		return nil, &domain.PredictionError{ModelID: p.ID, Cause: errClosed}
+	}
+	if p.dynamic != nil {
+		return p.dynamic, nil
+	}
+
+	var session *ort.DynamicAdvancedSession
+	var err error
+	if p.data != nil {
+		session, err = ort.NewDynamicAdvancedSessionWithONNXData(p.data, p.Info.InputNames(), p.outputNames, nil)
+	} else {
+		session, err = ort.NewDynamicAdvancedSession(p.Path, p.Info.InputNames(), p.outputNames, nil)
+	}
+	if err != nil {
+		return nil, &domain.PredictionError{ModelID: p.ID, Cause: err}
+	}
+	p.dynamic = session
+	return session, nil
+}
+
+// run executes one inference and returns the values of each tensor output.
+// The caller must hold p.mu.
+func (p *ONNXPredictor) run(features []float64) ([][]float64, error) {
+	if p.session == nil {
+		return nil, &domain.PredictionError{ModelID: p.ID, Cause: errClosed}
+	}
+
 	// Write input features to the CustomDataTensor byte buffer
 	if err := writeFeatures(p.inputTensor.GetData(), features, p.inputDtype); err != nil {
 		return nil, &domain.PredictionError{ModelID: p.ID, Cause: err}
@@ -208,13 +546,12 @@ func (p *ONNXPredictor) Predict(ctx context.Context, features []float64) ([]floa
 		return nil, &domain.PredictionError{ModelID: p.ID, Cause: err}
 	}
 
-	// Collect all output values into a flat float64 slice
-	var result []float64
+	outputs := make([][]float64, len(p.outputTensors))
 	for i, t := range p.outputTensors {
-		readFloat64s(t.GetData(), p.outputDtypes[i], &result)
+		readFloat64s(t.GetData(), p.outputDtypes[i], &outputs[i])
 	}
 
-	return result, nil
+	return outputs, nil
 }
 
 func (p *ONNXPredictor) Metadata() domain.ModelMetadata {
@@ -223,16 +560,54 @@ func (p *ONNXPredictor) Metadata() domain.ModelMetadata {
 		Name:    p.Name,
 		Path:    p.Path,
 		Version: p.Version,
+		Format:  Format,
 	}
 }
 
+// Relocate records a new artifact path after the model file has been
+// moved. The open session is unaffected; the path is used by Metadata and,
+// unless the model was loaded from memory, when the dynamic session is
+// first created.
+func (p *ONNXPredictor) Relocate(path string) {
+	p.Path = path
+}
+
 func (p *ONNXPredictor) ModelInfo() *ModelInfo {
 	return p.Info
 }
 
+// SetLabels replaces the class labels and feature names read from the
+// model info sidecar, e.g. with those declared by a package.
+func (p *ONNXPredictor) SetLabels(classLabels, featureNames []string) {
+	p.Info.SetLabels(classLabels, featureNames)
+}
+
+// ClassLabels returns the labels declared in the model's metadata_props.
+func (p *ONNXPredictor) ClassLabels() []string {
+	return p.Info.ClassLabels
+}
+
+// FeatureNames returns the feature names declared in the model's
+// metadata_props.
+func (p *ONNXPredictor) FeatureNames() []string {
+	return p.Info.FeatureNames
+}
+
+// Close releases the sessions. It waits for a running inference, and
+// later calls fail, so a predictor replaced in the registry can be closed
+// while requests may still hold it.
 func (p *ONNXPredictor) Close() error {
-	if p.session != nil {
-		p.session.Destroy()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.session == nil {
+		return nil
+	}
+	p.session.Destroy()
+	p.session = nil
+	if p.dynamic != nil {
+		p.dynamic.Destroy()
+		p.dynamic = nil
 	}
 	p.inputTensor.Destroy()
 	cleanupTensors(p.outputTensors)
@@ -308,6 +683,58 @@ func readFloat64s(data []byte, dtype ONNXDtype, out *[]float64) {
 	}
 }
 
+// newTypedTensor builds an ORT tensor of the input's declared dtype from
+// float64 request data.
+func newTypedTensor(shape ort.Shape, data []float64, dtype ONNXDtype) (ort.Value, error) {
+	switch dtype {
+	case DtypeFloat:
+		buf := make([]float32, len(data))
+		for i, v := range data {
+			buf[i] = float32(v)
+		}
+		return ort.NewTensor(shape, buf)
+	case DtypeDouble:
+		return ort.NewTensor(shape, append([]float64{}, data...))
+	case DtypeInt64:
+		buf := make([]int64, len(data))
+		for i, v := range data {
+			buf[i] = int64(v)
+		}
+		return ort.NewTensor(shape, buf)
+	case DtypeInt32:
+		buf := make([]int32, len(data))
+		for i, v := range data {
+			buf[i] = int32(v)
+		}
+		return ort.NewTensor(shape, buf)
+	default:
+		return nil, fmt.Errorf("unsupported input dtype: %d", dtype)
+	}
+}
+
+// appendTensorValues flattens an output allocated by ORT into out.
+func appendTensorValues(v ort.Value, out *[]float64) error {
+	switch t := v.(type) {
+	case *ort.Tensor[float32]:
+		for _, x := range t.GetData() {
+			*out = append(*out, float64(x))
+		}
+	case *ort.Tensor[float64]:
+		*out = append(*out, t.GetData()...)
+	case *ort.Tensor[int64]:
+		for _, x := range t.GetData() {
+			*out = append(*out, float64(x))
+		}
+	case *ort.Tensor[int32]:
+		for _, x := range t.GetData() {
+			*out = append(*out, float64(x))
+		}
+	default:
+		return fmt.Errorf("unsupported output value type %T", v)
+	}
+	return nil
+}
+
 func cleanupTensors(tensors []*ort.CustomDataTensor) {
 	for _, t := range tensors {
 		if t != nil {
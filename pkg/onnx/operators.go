@@ -0,0 +1,305 @@
+package onnx
+
+import (
+	"fmt"
+	"sort"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Field numbers for operator inventory (see onnx_parser.go for the rest):
+//
+// ModelProto:
+//   field 25 = functions (repeated FunctionProto)
+//
+// GraphProto:
+//   field 1 = node (repeated NodeProto)
+//
+// NodeProto:
+//   field 4 = op_type   (string)
+//   field 5 = attribute (repeated AttributeProto)
+//   field 7 = domain    (string)
+//
+// AttributeProto:
+//   field 6  = g      (GraphProto) — If/Loop/Scan bodies
+//   field 11 = graphs (repeated GraphProto)
+//
+// FunctionProto:
+//   field 1  = name   (string)
+//   field 7  = node   (repeated NodeProto)
+//   field 10 = domain (string)
+
+const (
+	modelFieldFunctions = 25
+
+	graphFieldNode = 1
+
+	nodeFieldOpType    = 4
+	nodeFieldAttribute = 5
+	nodeFieldDomain    = 7
+
+	attributeFieldGraph  = 6
+	attributeFieldGraphs = 11
+
+	functionFieldName   = 1
+	functionFieldNode   = 7
+	functionFieldDomain = 10
+)
+
+type OperatorCount struct {
+	Domain string `json:"domain"`
+	OpType string `json:"op_type"`
+	Count  int    `json:"count"`
+}
+
+type OpsetStatus struct {
+	Domain       string `json:"domain"`
+	Version      int64  `json:"version"`
+	MaxSupported int64  `json:"max_supported"`
+	Supported    bool   `json:"supported"`
+}
+
+type UnsupportedOperator struct {
+	Domain string `json:"domain"`
+	OpType string `json:"op_type"`
+	Reason string `json:"reason"`
+}
+
+// OperatorReport is the operator histogram of a model (including If/Loop/
+// Scan subgraphs) checked against the bundled runtime's support table.
+type OperatorReport struct {
+	RuntimeVersion string                `json:"runtime_version"`
+	NodeCount      int                   `json:"node_count"`
+	Operators      []OperatorCount       `json:"operators"`
+	Opsets         []OpsetStatus         `json:"opsets"`
+	Unsupported    []UnsupportedOperator `json:"unsupported,omitempty"`
+}
+
+// Compatible reports whether the runtime can load every operator and opset.
+func (r *OperatorReport) Compatible() bool {
+	if len(r.Unsupported) > 0 {
+		return false
+	}
+	for _, o := range r.Opsets {
+		if !o.Supported {
+			return false
+		}
+	}
+	return true
+}
+
+// Problems lists every incompatibility as a human-readable line.
+func (r *OperatorReport) Problems() []string {
+	var problems []string
+	for _, o := range r.Opsets {
+		if !o.Supported {
+			problems = append(problems, fmt.Sprintf("opset %s v%d exceeds runtime %s maximum v%d",
+				displayDomain(o.Domain), o.Version, r.RuntimeVersion, o.MaxSupported))
+		}
+	}
+	for _, u := range r.Unsupported {
+		problems = append(problems, fmt.Sprintf("%s::%s: %s", displayDomain(u.Domain), u.OpType, u.Reason))
+	}
+	return problems
+}
+
+type opKey struct {
+	domain string
+	opType string
+}
+
+// buildOperatorReport walks the graph and local functions of a ModelProto.
+func buildOperatorReport(modelData, graphData []byte, opsets []OpsetImport) (*OperatorReport, error) {
+	counts := make(map[opKey]int)
+
+	nodes, err := collectNodes(graphData, counts)
+	if err != nil {
+		return nil, err
+	}
+
+	// Operators implemented by model-local functions need no runtime kernel.
+	functions, err := collectFunctions(modelData, counts)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &OperatorReport{RuntimeVersion: RuntimeVersion, NodeCount: nodes}
+
+	imported := make(map[string]int64)
+	for _, o := range opsets {
+		domain := canonicalDomain(o.Domain)
+		imported[domain] = max(imported[domain], o.Version)
+	}
+
+	domains := make([]string, 0, len(imported))
+	for d := range imported {
+		domains = append(domains, d)
+	}
+	sort.Strings(domains)
+
+	for _, d := range domains {
+		status := OpsetStatus{Domain: displayDomain(d), Version: imported[d]}
+		if limit, ok := maxOpset[d]; ok {
+			status.MaxSupported = limit
+			status.Supported = imported[d] <= limit
+		} else {
+			// Custom domains are fine as long as local functions cover them;
+			// the per-operator check below reports anything that is not.
+			status.Supported = true
+		}
+		report.Opsets = append(report.Opsets, status)
+	}
+
+	keys := make([]opKey, 0, len(counts))
+	for k := range counts {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].domain != keys[j].domain {
+			return keys[i].domain < keys[j].domain
+		}
+		return keys[i].opType < keys[j].opType
+	})
+
+	for _, k := range keys {
+		report.Operators = append(report.Operators, OperatorCount{
+			Domain: displayDomain(k.domain),
+			OpType: k.opType,
+			Count:  counts[k],
+		})
+
+		if functions[k] {
+			continue
+		}
+		if reason := checkOperator(k, imported); reason != "" {
+			report.Unsupported = append(report.Unsupported, UnsupportedOperator{
+				Domain: displayDomain(k.domain),
+				OpType: k.opType,
+				Reason: reason,
+			})
+		}
+	}
+
+	return report, nil
+}
+
+func checkOperator(k opKey, imported map[string]int64) string {
+	version, ok := imported[k.domain]
+	if !ok {
+		return "domain is not declared in opset_import"
+	}
+
+	ops, ok := supportedOps[k.domain]
+	if !ok {
+		return fmt.Sprintf("domain is not supported by runtime %s", RuntimeVersion)
+	}
+
+	since, ok := ops[k.opType]
+	if !ok {
+		return fmt.Sprintf("operator is not supported by runtime %s", RuntimeVersion)
+	}
+	if since > version {
+		return fmt.Sprintf("operator requires opset v%d but the model imports v%d", since, version)
+	}
+	return ""
+}
+
+// collectNodes counts the operators of a graph, recursing into subgraphs
+// held by node attributes. It returns the number of nodes visited.
+func collectNodes(graphData []byte, counts map[opKey]int) (int, error) {
+	total := 0
+	err := forEachBytesField(graphData, graphFieldNode, func(node []byte) error {
+		n, err := collectNode(node, counts)
+		total += n
+		return err
+	})
+	return total, err
+}
+
+func collectNode(node []byte, counts map[opKey]int) (int, error) {
+	opType, err := extractStringField(node, nodeFieldOpType)
+	if err != nil {
+		return 0, fmt.Errorf("node without op_type: %w", err)
+	}
+
+	domain, err := extractStringField(node, nodeFieldDomain)
+	if err != nil {
+		domain = ""
+	}
+
+	counts[opKey{domain: canonicalDomain(domain), opType: opType}]++
+	total := 1
+
+	err = forEachBytesField(node, nodeFieldAttribute, func(attr []byte) error {
+		return forEachSubgraph(attr, func(graph []byte) error {
+			n, err := collectNodes(graph, counts)
+			total += n
+			return err
+		})
+	})
+	return total, err
+}
+
+func forEachSubgraph(attr []byte, fn func([]byte) error) error {
+	if err := forEachBytesField(attr, attributeFieldGraph, fn); err != nil {
+		return err
+	}
+	return forEachBytesField(attr, attributeFieldGraphs, fn)
+}
+
+// collectFunctions returns the (domain, name) of every model-local function
+// and counts the operators used inside their bodies.
+func collectFunctions(modelData []byte, counts map[opKey]int) (map[opKey]bool, error) {
+	functions := make(map[opKey]bool)
+
+	err := forEachBytesField(modelData, modelFieldFunctions, func(fn []byte) error {
+		name, err := extractStringField(fn, functionFieldName)
+		if err != nil {
+			return fmt.Errorf("function without name: %w", err)
+		}
+		domain, err := extractStringField(fn, functionFieldDomain)
+		if err != nil {
+			domain = ""
+		}
+		functions[opKey{domain: canonicalDomain(domain), opType: name}] = true
+
+		return forEachBytesField(fn, functionFieldNode, func(node []byte) error {
+			_, err := collectNode(node, counts)
+			return err
+		})
+	})
+
+	return functions, err
+}
+
+// forEachBytesField calls fn for every length-delimited occurrence of a
+// repeated field.
+func forEachBytesField(data []byte, targetField protowire.Number, fn func([]byte) error) error {
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return fmt.Errorf("invalid protobuf tag")
+		}
+		data = data[n:]
+
+		if typ == protowire.BytesType {
+			val, n := protowire.ConsumeBytes(data)
+			if n < 0 {
+				return fmt.Errorf("invalid bytes field")
+			}
+			if num == targetField {
+				if err := fn(val); err != nil {
+					return err
+				}
+			}
+			data = data[n:]
+		} else {
+			n := protowire.ConsumeFieldValue(num, typ, data)
+			if n < 0 {
+				return fmt.Errorf("invalid field value")
+			}
+			data = data[n:]
+		}
+	}
+	return nil
+}
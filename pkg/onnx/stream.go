@@ -0,0 +1,424 @@
+package onnx
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Streaming reader
+//
+// Weights dominate ONNX files, but metadata extraction only needs the
+// structure around them. readModel streams a ModelProto from disk and
+// re-encodes it without tensor payloads, skipping them through their
+// length prefixes, so memory use depends on the size of the graph
+// description rather than on the size of the model.
+//
+// Payload fields dropped from TensorProto:
+//   field 4  = float_data
+//   field 5  = int32_data
+//   field 7  = int64_data
+//   field 9  = raw_data
+//   field 10 = double_data
+//   field 11 = uint64_data
+//
+// string_data (field 6) is kept so string tensors can still be sized.
+// Sparse tensors (GraphProto field 15, AttributeProto fields 22/23) are
+// dropped entirely.
+//
+// Uploaded models are untrusted, so the reader is also where every length
+// prefix is checked: nesting depth, field sizes, repeated field counts and
+// dimension values are bounded before anything is allocated, and
+// violations are reported as a *ParseError at the offending file offset.
+//
+// The compact copy is held in memory while it is decoded, so its size is
+// the real bound on the memory metadata extraction uses: everything kept,
+// tags and lengths included, counts towards maxMetadataSize, and peak use
+// stays within a small multiple of it however large the weights are.
+
+const (
+	maxDepth        = 32       // nested messages, including subgraphs
+	maxFieldLen     = 16 << 20 // any retained length-delimited field
+	maxStringLen    = 64 << 10 // names, domains, keys and similar strings
+	maxMetadataSize = 64 << 20 // compact copy of the model
+	maxGraphIO      = 4096     // inputs or outputs of one graph
+	maxNodes        = 1 << 20  // nodes or initializers of one graph or function
+	maxAttributes   = 1024     // attributes of one node
+	maxRank         = 64       // dimensions of one shape or tensor
+	maxElements     = 1 << 38  // elements of one tensor, keeps byte totals within int64
+	maxEntries      = 4096     // opset imports or metadata properties
+)
+
+// ParseError reports a malformed or oversized model. Offset is the byte
+// position in the model file where the problem was found.
+type ParseError struct {
+	Offset int64
+	Reason string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("invalid model at byte %d: %s", e.Offset, e.Reason)
+}
+
+// schema describes how the reader treats the fields of one message type.
+type schema struct {
+	name     string
+	children map[protowire.Number]*schema // nested messages to descend into
+	drop     map[protowire.Number]bool    // payloads to skip
+	strings  map[protowire.Number]bool    // bounded by maxStringLen
+	limits   map[protowire.Number]int     // maximum occurrences
+	dims     protowire.Number             // dimension field (varint or packed), 0 if none
+}
+
+var (
+	modelSchema      = &schema{name: "ModelProto"}
+	graphSchema      = &schema{name: "GraphProto"}
+	nodeSchema       = &schema{name: "NodeProto"}
+	attributeSchema  = &schema{name: "AttributeProto"}
+	functionSchema   = &schema{name: "FunctionProto"}
+	tensorSchema     = &schema{name: "TensorProto"}
+	entrySchema      = &schema{name: "StringStringEntryProto"}
+	opsetSchema      = &schema{name: "OperatorSetIdProto"}
+	valueInfoSchema  = &schema{name: "ValueInfoProto"}
+	typeSchema       = &schema{name: "TypeProto"}
+	containerSchema  = &schema{name: "TypeProto.Sequence"}
+	mapSchema        = &schema{name: "TypeProto.Map"}
+	tensorTypeSchema = &schema{name: "TypeProto.Tensor"}
+	shapeSchema      = &schema{name: "TensorShapeProto"}
+	dimSchema        = &schema{name: "TensorShapeProto.Dimension"}
+)
+
+func init() {
+	modelSchema.children = map[protowire.Number]*schema{
+		modelFieldGraph:         graphSchema,
+		modelFieldFunctions:     functionSchema,
+		modelFieldOpsetImport:   opsetSchema,
+		modelFieldMetadataProps: entrySchema,
+	}
+	modelSchema.strings = map[protowire.Number]bool{
+		modelFieldProducerName: true, modelFieldProducerVersion: true, modelFieldDomain: true,
+	}
+	modelSchema.limits = map[protowire.Number]int{
+		modelFieldOpsetImport: maxEntries, modelFieldMetadataProps: maxEntries,
+	}
+
+	graphSchema.children = map[protowire.Number]*schema{
+		graphFieldNode:        nodeSchema,
+		graphFieldInitializer: tensorSchema,
+		graphFieldInput:       valueInfoSchema,
+		graphFieldOutput:      valueInfoSchema,
+		graphFieldValueInfo:   valueInfoSchema,
+	}
+	graphSchema.drop = map[protowire.Number]bool{graphFieldSparseInitializer: true}
+	graphSchema.strings = map[protowire.Number]bool{graphFieldName: true}
+	graphSchema.limits = map[protowire.Number]int{
+		graphFieldNode: maxNodes, graphFieldInitializer: maxNodes,
+		graphFieldInput: maxGraphIO, graphFieldOutput: maxGraphIO,
+	}
+
+	nodeSchema.children = map[protowire.Number]*schema{nodeFieldAttribute: attributeSchema}
+	nodeSchema.strings = map[protowire.Number]bool{
+		nodeFieldInput: true, nodeFieldOutput: true, nodeFieldName: true,
+		nodeFieldOpType: true, nodeFieldDomain: true,
+	}
+	nodeSchema.limits = map[protowire.Number]int{nodeFieldAttribute: maxAttributes}
+
+	attributeSchema.children = map[protowire.Number]*schema{
+		attributeFieldTensor:  tensorSchema,
+		attributeFieldGraph:   graphSchema,
+		attributeFieldTensors: tensorSchema,
+		attributeFieldGraphs:  graphSchema,
+	}
+	attributeSchema.drop = map[protowire.Number]bool{
+		attributeFieldSparseTensor: true, attributeFieldSparseTensors: true,
+	}
+	attributeSchema.strings = map[protowire.Number]bool{attributeFieldName: true}
+
+	functionSchema.children = map[protowire.Number]*schema{functionFieldNode: nodeSchema}
+	functionSchema.strings = map[protowire.Number]bool{functionFieldName: true, functionFieldDomain: true}
+	functionSchema.limits = map[protowire.Number]int{functionFieldNode: maxNodes}
+
+	tensorSchema.children = map[protowire.Number]*schema{tensorFieldExternalData: entrySchema}
+	tensorSchema.drop = map[protowire.Number]bool{4: true, 5: true, 7: true, 9: true, 10: true, 11: true}
+	tensorSchema.strings = map[protowire.Number]bool{initializerFieldName: true}
+	tensorSchema.dims = tensorFieldDims
+
+	entrySchema.strings = map[protowire.Number]bool{entryFieldKey: true}
+	opsetSchema.strings = map[protowire.Number]bool{opsetFieldDomain: true}
+
+	valueInfoSchema.children = map[protowire.Number]*schema{valueInfoFieldType: typeSchema}
+	valueInfoSchema.strings = map[protowire.Number]bool{valueInfoFieldName: true}
+
+	typeSchema.children = map[protowire.Number]*schema{
+		typeProtoFieldTensor:       tensorTypeSchema,
+		typeProtoFieldSequence:     containerSchema,
+		typeProtoFieldMap:          mapSchema,
+		typeProtoFieldSparseTensor: tensorTypeSchema,
+		typeProtoFieldOptional:     containerSchema,
+	}
+	containerSchema.children = map[protowire.Number]*schema{containerFieldElemType: typeSchema}
+	mapSchema.children = map[protowire.Number]*schema{mapFieldValueType: typeSchema}
+	tensorTypeSchema.children = map[protowire.Number]*schema{tensorTypeFieldShape: shapeSchema}
+
+	shapeSchema.children = map[protowire.Number]*schema{shapeFieldDim: dimSchema}
+	shapeSchema.limits = map[protowire.Number]int{shapeFieldDim: maxRank}
+
+	dimSchema.strings = map[protowire.Number]bool{dimFieldDimParam: true}
+	dimSchema.dims = dimFieldDimValue
+}
+
+// readModel returns the ModelProto at path with tensor payloads stripped.
+func readModel(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read model file: %w", err)
+	}
+	defer f.Close()
+
+	stat, err := f.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read model file: %w", err)
+	}
+
+	return readModelFrom(f, stat.Size())
+}
+
+// readModelFrom is readModel for a model of size bytes read from f.
+func readModelFrom(f io.ReadSeeker, size int64) ([]byte, error) {
+	s := &streamReader{f: f, r: bufio.NewReader(f)}
+	return s.message(size, modelSchema, 0)
+}
+
+type streamReader struct {
+	f    io.ReadSeeker
+	r    *bufio.Reader
+	pos  int64
+	kept int64
+}
+
+func (s *streamReader) errorf(offset int64, format string, args ...any) error {
+	return &ParseError{Offset: offset, Reason: fmt.Sprintf(format, args...)}
+}
+
+// dimChecker bounds the rank of one message and the product of its
+// dimensions.
+type dimChecker struct {
+	s        *streamReader
+	sc       *schema
+	rank     int
+	elements int64
+}
+
+func (c *dimChecker) add(offset int64, v uint64) error {
+	d := int64(v)
+	if d < 0 {
+		return c.s.errorf(offset, "%s has negative or overflowing dimension %d", c.sc.name, d)
+	}
+	c.rank++
+	if c.rank > maxRank {
+		return c.s.errorf(offset, "%s has more than %d dimensions", c.sc.name, maxRank)
+	}
+	if d > 0 && c.elements > maxElements/d {
+		return c.s.errorf(offset, "%s has more than %d elements", c.sc.name, int64(maxElements))
+	}
+	c.elements *= d
+	return nil
+}
+
+// message re-encodes the next length bytes of the stream as a message of
+// the given schema.
+func (s *streamReader) message(length int64, sc *schema, depth int) ([]byte, error) {
+	if depth > maxDepth {
+		return nil, s.errorf(s.pos, "%s nested deeper than %d levels", sc.name, maxDepth)
+	}
+
+	var out []byte
+	end := s.pos + length
+	counts := make(map[protowire.Number]int)
+	dims := &dimChecker{s: s, sc: sc, elements: 1}
+
+	for s.pos < end {
+		start := s.pos
+		tag, err := s.varint()
+		if err != nil {
+			return nil, err
+		}
+		num, typ := protowire.DecodeTag(tag)
+		if num <= 0 || num > protowire.MaxValidNumber {
+			return nil, s.errorf(start, "invalid field number %d in %s", num, sc.name)
+		}
+
+		counts[num]++
+		if limit, ok := sc.limits[num]; ok && counts[num] > limit {
+			return nil, s.errorf(start, "%s has more than %d occurrences of field %d", sc.name, limit, num)
+		}
+
+		switch typ {
+		case protowire.VarintType:
+			v, err := s.varint()
+			if err != nil {
+				return nil, err
+			}
+			if num == sc.dims {
+				if err := dims.add(start, v); err != nil {
+					return nil, err
+				}
+			}
+			if err := s.keep(start, s.pos-start); err != nil {
+				return nil, err
+			}
+			out = protowire.AppendTag(out, num, typ)
+			out = protowire.AppendVarint(out, v)
+
+		case protowire.Fixed32Type, protowire.Fixed64Type:
+			size := int64(4)
+			if typ == protowire.Fixed64Type {
+				size = 8
+			}
+			if size > end-s.pos {
+				return nil, s.errorf(start, "field %d overruns %s", num, sc.name)
+			}
+			if err := s.keep(start, s.pos-start+size); err != nil {
+				return nil, err
+			}
+			buf, err := s.bytes(size)
+			if err != nil {
+				return nil, err
+			}
+			out = protowire.AppendTag(out, num, typ)
+			out = append(out, buf...)
+
+		case protowire.BytesType:
+			v, err := s.varint()
+			if err != nil {
+				return nil, err
+			}
+			n := int64(v)
+			if n < 0 || n > end-s.pos {
+				return nil, s.errorf(start, "field %d of %s claims %d bytes, only %d remain", num, sc.name, v, end-s.pos)
+			}
+
+			if sc.drop[num] {
+				if err := s.skip(n); err != nil {
+					return nil, err
+				}
+				continue
+			}
+
+			// Tag and length; the value is counted as it is read.
+			if err := s.keep(start, s.pos-start); err != nil {
+				return nil, err
+			}
+
+			valueStart := s.pos
+			var val []byte
+			if child := sc.children[num]; child != nil {
+				val, err = s.message(n, child, depth+1)
+			} else {
+				val, err = s.field(start, n, sc, num)
+			}
+			if err != nil {
+				return nil, err
+			}
+
+			if num == sc.dims {
+				// Packed dimensions
+				for packed, offset := val, valueStart; len(packed) > 0; {
+					d, m := protowire.ConsumeVarint(packed)
+					if m < 0 {
+						return nil, s.errorf(offset, "invalid packed dimensions in %s", sc.name)
+					}
+					if err := dims.add(offset, d); err != nil {
+						return nil, err
+					}
+					packed, offset = packed[m:], offset+int64(m)
+				}
+			}
+
+			out = protowire.AppendTag(out, num, typ)
+			out = protowire.AppendBytes(out, val)
+
+		default:
+			return nil, s.errorf(start, "unsupported wire type %d for field %d in %s", typ, num, sc.name)
+		}
+	}
+
+	if s.pos != end {
+		return nil, s.errorf(end, "%s overruns its length", sc.name)
+	}
+	return out, nil
+}
+
+// field reads a retained length-delimited field after checking its size.
+func (s *streamReader) field(start, n int64, sc *schema, num protowire.Number) ([]byte, error) {
+	if sc.strings[num] && n > maxStringLen {
+		return nil, s.errorf(start, "string field %d of %s is %d bytes, limit is %d", num, sc.name, n, maxStringLen)
+	}
+	if n > maxFieldLen {
+		return nil, s.errorf(start, "field %d of %s is %d bytes, limit is %d", num, sc.name, n, maxFieldLen)
+	}
+	if err := s.keep(start, n); err != nil {
+		return nil, err
+	}
+	return s.bytes(n)
+}
+
+// keep accounts for n more bytes of the compact copy.
+func (s *streamReader) keep(start, n int64) error {
+	s.kept += n
+	if s.kept > maxMetadataSize {
+		return s.errorf(start, "model metadata exceeds %d bytes", maxMetadataSize)
+	}
+	return nil
+}
+
+func (s *streamReader) varint() (uint64, error) {
+	start := s.pos
+	var v uint64
+	for i := 0; i < 10; i++ {
+		b, err := s.r.ReadByte()
+		if err != nil {
+			return 0, s.truncated(err)
+		}
+		s.pos++
+		v |= uint64(b&0x7f) << (7 * i)
+		if b < 0x80 {
+			return v, nil
+		}
+	}
+	return 0, s.errorf(start, "invalid varint")
+}
+
+func (s *streamReader) bytes(n int64) ([]byte, error) {
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(s.r, buf); err != nil {
+		return nil, s.truncated(err)
+	}
+	s.pos += n
+	return buf, nil
+}
+
+// skip moves past n bytes, seeking over anything not already buffered.
+func (s *streamReader) skip(n int64) error {
+	if buffered := int64(s.r.Buffered()); n > buffered {
+		if _, err := s.f.Seek(n-buffered, io.SeekCurrent); err != nil {
+			return err
+		}
+		s.r.Reset(s.f)
+	} else if _, err := s.r.Discard(int(n)); err != nil {
+		return s.truncated(err)
+	}
+	s.pos += n
+	return nil
+}
+
+func (s *streamReader) truncated(err error) error {
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return s.errorf(s.pos, "unexpected end of file")
+	}
+	return err
+}